@@ -64,6 +64,8 @@ func scanStdinAndDoHttp(ctx context.Context, httpUrl string) error {
 	aod := os.Getenv(git.EnvAlternativeObjectDirectories)
 	qp := os.Getenv(git.EnvQuarantinePath)
 	od := os.Getenv(git.EnvObjectDirectory)
+	isWiki, _ := strconv.ParseBool(os.Getenv(git.EnvIsWiki))
+	pushOptions := getPushOptions()
 	scanner := bufio.NewScanner(os.Stdin)
 	for scanner.Scan() {
 		line := strings.TrimSpace(string(scanner.Bytes()))
@@ -92,6 +94,8 @@ func scanStdinAndDoHttp(ctx context.Context, httpUrl string) error {
 			ObjectDirectory:              od,
 			AlternativeObjectDirectories: aod,
 			QuarantinePath:               qp,
+			PushOptions:                  pushOptions,
+			IsWiki:                       isWiki,
 		}
 		if err := doHttp(ctx, client, reqVO, httpUrl); err != nil {
 			return err
@@ -100,6 +104,16 @@ func scanStdinAndDoHttp(ctx context.Context, httpUrl string) error {
 	return nil
 }
 
+// getPushOptions 获取git push -o传递的参数
+func getPushOptions() []string {
+	count, _ := strconv.Atoi(os.Getenv(git.EnvPushOptionCount))
+	ret := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		ret = append(ret, os.Getenv(git.EnvPushOptionPrefix+strconv.Itoa(i)))
+	}
+	return ret
+}
+
 func runHookPostReceive(c *cli.Context) error {
 	if isInternal, _ := strconv.ParseBool(os.Getenv(git.EnvIsInternal)); isInternal {
 		return nil
@@ -8,16 +8,29 @@ import (
 	"regexp"
 	"zgit/pkg/git"
 	"zgit/setting"
+	"zgit/standalone/modules/api/accesstokenapi"
 	"zgit/standalone/modules/api/branchapi"
 	"zgit/standalone/modules/api/cfgapi"
+	"zgit/standalone/modules/api/codesearchapi"
+	"zgit/standalone/modules/api/commitstatusapi"
+	"zgit/standalone/modules/api/gitapi"
+	"zgit/standalone/modules/api/gpgkeyapi"
 	"zgit/standalone/modules/api/hookapi"
 	"zgit/standalone/modules/api/lfsapi"
+	"zgit/standalone/modules/api/mirrorapi"
 	"zgit/standalone/modules/api/projectapi"
 	"zgit/standalone/modules/api/pullrequestapi"
+	"zgit/standalone/modules/api/pushruleapi"
+	"zgit/standalone/modules/api/releaseapi"
 	"zgit/standalone/modules/api/repoapi"
 	"zgit/standalone/modules/api/sshkeyapi"
+	"zgit/standalone/modules/api/tagapi"
 	"zgit/standalone/modules/api/userapi"
+	"zgit/standalone/modules/api/webhookapi"
+	"zgit/standalone/modules/api/wikiapi"
 	"zgit/standalone/modules/service/cfgsrv"
+	"zgit/standalone/modules/service/mirrorsrv"
+	"zgit/standalone/modules/service/reposrv"
 	"zgit/standalone/sshserv"
 )
 
@@ -54,14 +67,40 @@ func runStandalone(*cli.Context) error {
 	repoapi.InitApi()
 	// ssh公钥
 	sshkeyapi.InitApi()
+	// gpg公钥
+	gpgkeyapi.InitApi()
 	// 项目
 	projectapi.InitApi()
 	// 合并请求
 	pullrequestapi.InitApi()
 	// 分支
 	branchapi.InitApi()
+	// 保护标签
+	tagapi.InitApi()
+	// 仓库事件webhook
+	webhookapi.InitApi()
 	// 系统配置api
 	cfgapi.InitApi()
+	// smart http
+	gitapi.InitApi()
+	// 个人访问令牌
+	accesstokenapi.InitApi()
+	// 提交状态
+	commitstatusapi.InitApi()
+	// 推送规则
+	pushruleapi.InitApi()
+	// 镜像仓库和推送镜像
+	mirrorapi.InitApi()
+	// 代码搜索
+	codesearchapi.InitApi()
+	// 仓库wiki
+	wikiapi.InitApi()
+	// 发布版本
+	releaseapi.InitApi()
+	// 镜像定时同步
+	mirrorsrv.InitTask()
+	// 压缩包缓存清理
+	reposrv.InitArchiveCleanTask()
 	starter.Run()
 	return nil
 }
@@ -2,11 +2,19 @@ package sshproxy
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/LeeZXin/zsf/logger"
 	"github.com/gliderlabs/ssh"
 	gossh "golang.org/x/crypto/ssh"
+	"io"
 	"net"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"zgit/httpclient/metadataclient"
+	"zgit/pkg/i18n"
 	"zgit/setting"
 	"zgit/standalone/modules/model/usermd"
 	"zgit/standalone/modules/service/gitsrv"
@@ -16,6 +24,14 @@ import (
 	"zgit/util"
 )
 
+var (
+	nodeCache = util.NewGoCache()
+	// forwardEnvs 允许转发到节点的客户端环境变量
+	forwardEnvs = map[string]bool{
+		"GIT_PROTOCOL": true,
+	}
+)
+
 type NodeInfo struct {
 	Id   string `json:"id"`
 	Host string `json:"host"`
@@ -43,6 +59,12 @@ func sessionHandler(session ssh.Session) {
 	ctx := session.Context()
 	userInfo := session.Context().Value(sshserv.ZgitUserAccount).(usermd.UserInfo)
 	if err := gitsrv.HandleSshCommand(ctx, session.RawCommand(), userInfo, session, handleProxyCommand); err != nil {
+		var exitErr *gossh.ExitError
+		// 节点返回的错误信息已通过stderr传输
+		if errors.As(err, &exitErr) {
+			session.Exit(exitErr.ExitStatus())
+			return
+		}
 		util.ExitWithErrMsg(session, err.Error())
 	} else {
 		session.Exit(0)
@@ -50,77 +72,118 @@ func sessionHandler(session ssh.Session) {
 }
 
 func handleProxyCommand(ctx context.Context, operator usermd.UserInfo, words []string, session ssh.Session) error {
-	//repoPath := strings.TrimPrefix(words[1], "/")
-	//relativeRepoPath, err := util.ParseRelativeRepoPath(repoPath)
-	//if err != nil {
-	//	return fmt.Errorf("repo path is invalid: %s", repoPath)
-	//}
-	//corpInfo, b, err := corpsrv.GetCorpInfoByCorpId(ctx, relativeRepoPath.CorpId)
-	//if !b || err != nil {
-	//	return fmt.Errorf("could not find repo: %s", repoPath)
-	//}
-	//nodeInfo, b, err := clustersrv.GetNodeInfoByNodeId(ctx, corpInfo.NodeId)
-	//if !b || err != nil {
-	//	return fmt.Errorf("could not nodeInfo: %s", repoPath)
-	//}
-	//// 建立SSH连接
-	//client, err := gossh.Dial("tcp", fmt.Sprintf("%s:%d", nodeInfo.Host, nodeInfo.Port), clientConfig)
-	//if err != nil {
-	//	return errors.New("connect to proxy failed")
-	//}
-	//defer client.Close()
-	//proxySession, err := client.NewSession()
-	//if err != nil {
-	//	return errors.New("connect to proxy failed")
-	//}
-	//defer proxySession.Close()
-	//for _, env := range session.Environ() {
-	//	b, a, f := strings.Cut(env, "=")
-	//	if f {
-	//		if err = proxySession.Setenv(b, a); err != nil {
-	//			return fmt.Errorf("can not transfer env name: %s", b)
-	//		}
-	//	}
-	//}
-	//if err = proxySession.Setenv("ZGIT_PROXY_NAME", proxyName); err != nil {
-	//	return errors.New("can not transfer proxy name:" + proxyName)
-	//}
-	//if err = proxySession.Setenv("ZGIT_LOGIN_USER", operator.Account); err != nil {
-	//	return errors.New("can not transfer login user")
-	//}
-	//stdout, err := proxySession.StdoutPipe()
-	//if err != nil {
-	//	return errors.New("network err")
-	//}
-	//stderr, err := proxySession.StderrPipe()
-	//if err != nil {
-	//	return errors.New("network err")
-	//}
-	//stdin, err := proxySession.StdinPipe()
-	//if err != nil {
-	//	return errors.New("network err")
-	//}
-	//defer stdin.Close()
-	//wg := &sync.WaitGroup{}
-	//wg.Add(2)
-	//if err = proxySession.Start(session.RawCommand()); err != nil {
-	//	return errors.New("network err")
-	//}
-	//go func() {
-	//	defer stdin.Close()
-	//	io.Copy(stdin, session)
-	//}()
-	//go func() {
-	//	defer wg.Done()
-	//	io.Copy(session, stdout)
-	//}()
-	//go func() {
-	//	defer wg.Done()
-	//	io.Copy(session.Stderr(), stderr)
-	//}()
-	//wg.Wait()
-	//return proxySession.Wait()
-	return nil
+	repoPath := strings.TrimPrefix(words[1], "/")
+	corpId, _, found := strings.Cut(repoPath, "/")
+	if !found || corpId == "" {
+		return fmt.Errorf("repo path is invalid: %s", repoPath)
+	}
+	nodeInfo, b, err := getNodeInfoByCorpId(ctx, corpId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return errors.New(i18n.GetByKey(i18n.SystemInternalError))
+	}
+	if !b {
+		return fmt.Errorf("could not find repo: %s", repoPath)
+	}
+	// 建立SSH连接
+	client, err := gossh.Dial("tcp", net.JoinHostPort(nodeInfo.Host, strconv.Itoa(nodeInfo.Port)), clientConfig)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Errorf("connect to node: %s err: %v", nodeInfo.Id, err)
+		return errors.New("connect to proxy failed")
+	}
+	defer client.Close()
+	proxySession, err := client.NewSession()
+	if err != nil {
+		return errors.New("connect to proxy failed")
+	}
+	defer proxySession.Close()
+	// 只转发git需要的环境变量 ZGIT_开头的变量由代理设置 防止客户端伪造登录用户
+	for _, env := range session.Environ() {
+		k, v, f := strings.Cut(env, "=")
+		if f && forwardEnvs[k] && !strings.HasPrefix(k, "ZGIT_") {
+			if err = proxySession.Setenv(k, v); err != nil {
+				return fmt.Errorf("can not transfer env name: %s", k)
+			}
+		}
+	}
+	if err = proxySession.Setenv(sshserv.ProxyNameEnv, proxyName); err != nil {
+		return errors.New("can not transfer proxy name:" + proxyName)
+	}
+	if err = proxySession.Setenv(sshserv.ProxyLoginUserEnv, operator.Account); err != nil {
+		return errors.New("can not transfer login user")
+	}
+	stdout, err := proxySession.StdoutPipe()
+	if err != nil {
+		return errors.New("network err")
+	}
+	stderr, err := proxySession.StderrPipe()
+	if err != nil {
+		return errors.New("network err")
+	}
+	stdin, err := proxySession.StdinPipe()
+	if err != nil {
+		return errors.New("network err")
+	}
+	if err = proxySession.Start(session.RawCommand()); err != nil {
+		stdin.Close()
+		return errors.New("network err")
+	}
+	go func() {
+		defer stdin.Close()
+		io.Copy(stdin, session)
+	}()
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(session, stdout)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(session.Stderr(), stderr)
+	}()
+	wg.Wait()
+	return proxySession.Wait()
+}
+
+// getNodeInfoByCorpId 通过企业id获取所在节点信息
+func getNodeInfoByCorpId(ctx context.Context, corpId string) (NodeInfo, bool, error) {
+	v, b := nodeCache.Get(corpId)
+	if b {
+		ret := v.(NodeInfo)
+		// 来自空缓存
+		if ret.Id == "" {
+			return ret, false, nil
+		}
+		return ret, true, nil
+	}
+	corpResp, err := metadataclient.GetCorpInfo(ctx, metadataclient.GetCorpInfoReqVO{
+		CorpId: corpId,
+	})
+	if err != nil {
+		return NodeInfo{}, false, err
+	}
+	if !corpResp.IsExists || corpResp.Corp.NodeId == "" {
+		nodeCache.Set(corpId, NodeInfo{}, time.Second)
+		return NodeInfo{}, false, nil
+	}
+	nodeResp, err := metadataclient.GetClusterNodeInfo(ctx, metadataclient.GetClusterNodeInfoReqVO{
+		NodeId: corpResp.Corp.NodeId,
+	})
+	if err != nil {
+		return NodeInfo{}, false, err
+	}
+	if !nodeResp.IsExists {
+		nodeCache.Set(corpId, NodeInfo{}, time.Second)
+		return NodeInfo{}, false, nil
+	}
+	ret := NodeInfo{
+		Id:   nodeResp.Node.NodeId,
+		Host: nodeResp.Node.Host,
+		Port: nodeResp.Node.Port,
+	}
+	nodeCache.Set(corpId, ret, time.Minute)
+	return ret, true, nil
 }
 
 type proxy struct {
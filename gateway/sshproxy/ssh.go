@@ -5,6 +5,7 @@ import (
 	"github.com/LeeZXin/zsf/logger"
 	"github.com/LeeZXin/zsf/property/static"
 	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 	"os"
 	"path/filepath"
 	"zgit/setting"
@@ -18,6 +19,8 @@ var (
 	serverHostKey      = "ssh/proxy.rsa"
 	serverPort         = static.GetInt("ssh.proxy.port")
 	proxyName          = static.GetString("ssh.proxy.name")
+	// 节点公钥 格式同openssh known_hosts
+	knownHostsFile = static.GetString("ssh.proxy.knownHosts")
 
 	serverKeySigner gossh.Signer
 
@@ -52,6 +55,16 @@ func StartSSHProxy() {
 	if err != nil {
 		logger.Logger.Panicf("parse signer failed %s: %v", serverHostKey, err)
 	}
+	if knownHostsFile == "" {
+		knownHostsFile = filepath.Join(setting.DataDir(), "ssh", "known_hosts")
+	} else if !filepath.IsAbs(knownHostsFile) {
+		knownHostsFile = filepath.Join(setting.DataDir(), knownHostsFile)
+	}
+	// 校验节点公钥
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		logger.Logger.Panicf("read known hosts failed %s: %v", knownHostsFile, err)
+	}
 	clientConfig = &gossh.ClientConfig{
 		Config: gossh.Config{
 			KeyExchanges: serverKeyExchanges,
@@ -62,7 +75,7 @@ func StartSSHProxy() {
 		Auth: []gossh.AuthMethod{
 			gossh.PublicKeys(serverKeySigner),
 		},
-		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+		HostKeyCallback: hostKeyCallback,
 	}
 	s := newProxy()
 	quit.AddShutdownHook(s.Shutdown)
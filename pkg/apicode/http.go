@@ -23,6 +23,24 @@ const (
 	SshKeyVerifyFailedCode
 	InvalidReviewCountWhenCreatePrCode
 	ForcePushForbiddenCode
+	MergeStrategyNotAllowedCode
+	RequiredStatusCheckFailedCode
+	CodeOwnerNotApprovedCode
+	UnsignedCommitForbiddenCode
+	PushRuleRejectedCode
+	SecretDetectedCode
+	QuotaExceededCode
+	ProtectedTagForbiddenCode
+	RepoImportFailedCode
+	MirrorRepoPushForbiddenCode
+	MirrorSyncingCode
+	BranchOutOfDateCode
+	BranchAlreadyExistsCode
+	FileConflictCode
+	PushRejectedCode
+	TagAlreadyExistsCode
+	ReleaseAlreadyExistsCode
+	ReleaseAssetConflictCode
 )
 
 func (c Code) Int() int {
@@ -0,0 +1,168 @@
+package codesearch
+
+import (
+	"encoding/json"
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/mapping"
+	"os"
+)
+
+const (
+	codeAnalyzer = "code"
+	// 索引状态在bleve内部存储的key
+	stateKey = "state"
+	// 批量写入大小
+	batchSize = 100
+)
+
+type Document struct {
+	RepoId   string `json:"repoId"`
+	Branch   string `json:"branch"`
+	Path     string `json:"path"`
+	Language string `json:"language"`
+	Content  string `json:"content"`
+}
+
+// DocId 分支名不能包含冒号 可以作为分隔符
+func DocId(branch, filePath string) string {
+	return branch + ":" + filePath
+}
+
+// State 各分支已索引到的提交
+type State struct {
+	Branches map[string]string `json:"branches"`
+}
+
+func newIndexMapping() (mapping.IndexMapping, error) {
+	m := bleve.NewIndexMapping()
+	// 代码不做词干和停用词处理
+	if err := m.AddCustomAnalyzer(codeAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{lowercase.Name},
+	}); err != nil {
+		return nil, err
+	}
+	keywordField := bleve.NewTextFieldMapping()
+	keywordField.Analyzer = keyword.Name
+	keywordField.IncludeInAll = false
+	keywordField.IncludeTermVectors = false
+	contentField := bleve.NewTextFieldMapping()
+	contentField.Analyzer = codeAnalyzer
+	contentField.IncludeInAll = false
+	doc := bleve.NewDocumentMapping()
+	doc.AddFieldMappingsAt("repoId", keywordField)
+	doc.AddFieldMappingsAt("branch", keywordField)
+	doc.AddFieldMappingsAt("path", keywordField)
+	doc.AddFieldMappingsAt("language", keywordField)
+	doc.AddFieldMappingsAt("content", contentField)
+	m.DefaultMapping = doc
+	m.DefaultAnalyzer = codeAnalyzer
+	return m, nil
+}
+
+// OpenIndex 打开索引 不存在则创建
+func OpenIndex(indexPath string) (bleve.Index, error) {
+	if _, err := os.Stat(indexPath); err == nil {
+		return bleve.Open(indexPath)
+	}
+	m, err := newIndexMapping()
+	if err != nil {
+		return nil, err
+	}
+	return bleve.New(indexPath, m)
+}
+
+func GetState(idx bleve.Index) (State, error) {
+	ret := State{
+		Branches: make(map[string]string),
+	}
+	val, err := idx.GetInternal([]byte(stateKey))
+	if err != nil || len(val) == 0 {
+		return ret, err
+	}
+	if err = json.Unmarshal(val, &ret); err != nil {
+		return ret, err
+	}
+	if ret.Branches == nil {
+		ret.Branches = make(map[string]string)
+	}
+	return ret, nil
+}
+
+func SetState(idx bleve.Index, state State) error {
+	val, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return idx.SetInternal([]byte(stateKey), val)
+}
+
+// DeleteBranch 删除分支下的所有文档
+func DeleteBranch(idx bleve.Index, branch string) error {
+	q := bleve.NewTermQuery(branch)
+	q.SetField("branch")
+	for {
+		res, err := idx.Search(bleve.NewSearchRequestOptions(q, 1000, 0, false))
+		if err != nil {
+			return err
+		}
+		if len(res.Hits) == 0 {
+			return nil
+		}
+		b := idx.NewBatch()
+		for _, hit := range res.Hits {
+			b.Delete(hit.ID)
+		}
+		if err = idx.Batch(b); err != nil {
+			return err
+		}
+	}
+}
+
+// Batch 满批量自动写入
+type Batch struct {
+	idx   bleve.Index
+	batch *bleve.Batch
+}
+
+func NewBatch(idx bleve.Index) *Batch {
+	return &Batch{
+		idx:   idx,
+		batch: idx.NewBatch(),
+	}
+}
+
+func (b *Batch) Index(doc Document) error {
+	if err := b.batch.Index(DocId(doc.Branch, doc.Path), doc); err != nil {
+		return err
+	}
+	return b.flushIfFull()
+}
+
+func (b *Batch) Delete(branch, filePath string) error {
+	b.batch.Delete(DocId(branch, filePath))
+	return b.flushIfFull()
+}
+
+func (b *Batch) flushIfFull() error {
+	if b.batch.Size() < batchSize {
+		return nil
+	}
+	return b.Flush()
+}
+
+func (b *Batch) Flush() error {
+	if b.batch.Size() == 0 {
+		return nil
+	}
+	if err := b.idx.Batch(b.batch); err != nil {
+		return err
+	}
+	b.batch.Reset()
+	return nil
+}
@@ -0,0 +1,80 @@
+package codesearch
+
+import (
+	"path"
+	"strings"
+)
+
+var (
+	// 文件后缀对应的语言
+	extLanguages = map[string]string{
+		".go":     "go",
+		".java":   "java",
+		".kt":     "kotlin",
+		".kts":    "kotlin",
+		".scala":  "scala",
+		".groovy": "groovy",
+		".gradle": "groovy",
+		".c":      "c",
+		".h":      "c",
+		".cc":     "c++",
+		".cpp":    "c++",
+		".cxx":    "c++",
+		".hpp":    "c++",
+		".hh":     "c++",
+		".cs":     "c#",
+		".m":      "objective-c",
+		".mm":     "objective-c",
+		".swift":  "swift",
+		".rs":     "rust",
+		".py":     "python",
+		".rb":     "ruby",
+		".php":    "php",
+		".pl":     "perl",
+		".pm":     "perl",
+		".lua":    "lua",
+		".r":      "r",
+		".dart":   "dart",
+		".js":     "javascript",
+		".mjs":    "javascript",
+		".cjs":    "javascript",
+		".jsx":    "javascript",
+		".ts":     "typescript",
+		".tsx":    "typescript",
+		".vue":    "vue",
+		".html":   "html",
+		".htm":    "html",
+		".css":    "css",
+		".scss":   "scss",
+		".less":   "less",
+		".sh":     "shell",
+		".bash":   "shell",
+		".zsh":    "shell",
+		".ps1":    "powershell",
+		".sql":    "sql",
+		".proto":  "protobuf",
+		".json":   "json",
+		".yaml":   "yaml",
+		".yml":    "yaml",
+		".toml":   "toml",
+		".xml":    "xml",
+		".ini":    "ini",
+		".md":     "markdown",
+		".txt":    "text",
+	}
+	// 无后缀的特殊文件名
+	fileNameLanguages = map[string]string{
+		"dockerfile":     "dockerfile",
+		"makefile":       "makefile",
+		"cmakelists.txt": "cmake",
+	}
+)
+
+// DetectLanguage 通过文件名判断语言 无法判断返回空
+func DetectLanguage(filePath string) string {
+	name := strings.ToLower(path.Base(filePath))
+	if lang, b := fileNameLanguages[name]; b {
+		return lang
+	}
+	return extLanguages[path.Ext(name)]
+}
@@ -0,0 +1,152 @@
+package codesearch
+
+import (
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/search/query"
+	"strings"
+	"unicode"
+)
+
+const (
+	// 每个文件最多返回的匹配行
+	maxSnippets = 5
+	// 匹配行最大长度
+	maxSnippetLength = 256
+)
+
+// Target 搜索的仓库和分支
+type Target struct {
+	RepoId string
+	Branch string
+}
+
+type SearchOpts struct {
+	Keyword string
+	// 路径通配符 如 src/*.go
+	PathGlob string
+	Language string
+	Targets  []Target
+	From     int
+	Size     int
+}
+
+type Snippet struct {
+	LineNo int
+	Line   string
+}
+
+type Hit struct {
+	RepoId   string
+	Branch   string
+	Path     string
+	Language string
+	Snippets []Snippet
+}
+
+type SearchResult struct {
+	Total uint64
+	Hits  []Hit
+}
+
+// Search 多个仓库的索引通过IndexAlias一起搜索
+func Search(indexes []bleve.Index, opts SearchOpts) (SearchResult, error) {
+	if len(indexes) == 0 || len(opts.Targets) == 0 {
+		return SearchResult{
+			Hits: []Hit{},
+		}, nil
+	}
+	targetQueries := make([]query.Query, 0, len(opts.Targets))
+	for _, target := range opts.Targets {
+		repoQuery := bleve.NewTermQuery(target.RepoId)
+		repoQuery.SetField("repoId")
+		branchQuery := bleve.NewTermQuery(target.Branch)
+		branchQuery.SetField("branch")
+		targetQueries = append(targetQueries, bleve.NewConjunctionQuery(repoQuery, branchQuery))
+	}
+	contentQuery := bleve.NewMatchPhraseQuery(opts.Keyword)
+	contentQuery.SetField("content")
+	queries := []query.Query{
+		contentQuery,
+		bleve.NewDisjunctionQuery(targetQueries...),
+	}
+	if opts.PathGlob != "" {
+		pathQuery := bleve.NewWildcardQuery(opts.PathGlob)
+		pathQuery.SetField("path")
+		queries = append(queries, pathQuery)
+	}
+	if opts.Language != "" {
+		langQuery := bleve.NewTermQuery(strings.ToLower(opts.Language))
+		langQuery.SetField("language")
+		queries = append(queries, langQuery)
+	}
+	req := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(queries...), opts.Size, opts.From, false)
+	req.Fields = []string{"repoId", "branch", "path", "language", "content"}
+	var idx bleve.Index
+	if len(indexes) == 1 {
+		idx = indexes[0]
+	} else {
+		idx = bleve.NewIndexAlias(indexes...)
+	}
+	res, err := idx.Search(req)
+	if err != nil {
+		return SearchResult{}, err
+	}
+	ret := SearchResult{
+		Total: res.Total,
+		Hits:  make([]Hit, 0, len(res.Hits)),
+	}
+	for _, match := range res.Hits {
+		ret.Hits = append(ret.Hits, Hit{
+			RepoId:   fieldString(match.Fields, "repoId"),
+			Branch:   fieldString(match.Fields, "branch"),
+			Path:     fieldString(match.Fields, "path"),
+			Language: fieldString(match.Fields, "language"),
+			Snippets: findSnippets(fieldString(match.Fields, "content"), opts.Keyword),
+		})
+	}
+	return ret, nil
+}
+
+func fieldString(fields map[string]interface{}, name string) string {
+	ret, _ := fields[name].(string)
+	return ret
+}
+
+// findSnippets 优先找包含完整关键字的行 找不到再找包含任一分词的行
+func findSnippets(content, keyword string) []Snippet {
+	lines := strings.Split(content, "\n")
+	ret := findLines(lines, []string{strings.ToLower(keyword)})
+	if len(ret) > 0 {
+		return ret
+	}
+	tokens := strings.FieldsFunc(strings.ToLower(keyword), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '_'
+	})
+	return findLines(lines, tokens)
+}
+
+func findLines(lines []string, keywords []string) []Snippet {
+	ret := make([]Snippet, 0)
+	if len(keywords) == 0 {
+		return ret
+	}
+	for i, line := range lines {
+		lower := strings.ToLower(line)
+		for _, keyword := range keywords {
+			if keyword != "" && strings.Contains(lower, keyword) {
+				if len(line) > maxSnippetLength {
+					line = strings.ToValidUTF8(line[:maxSnippetLength], "")
+				}
+				ret = append(ret, Snippet{
+					LineNo: i + 1,
+					Line:   strings.TrimRight(line, "\r"),
+				})
+				break
+			}
+		}
+		if len(ret) >= maxSnippets {
+			break
+		}
+	}
+	return ret
+}
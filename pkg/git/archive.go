@@ -0,0 +1,70 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"zgit/pkg/git/command"
+)
+
+type ArchiveFormat string
+
+const (
+	ZipFormat   ArchiveFormat = "zip"
+	TarGzFormat ArchiveFormat = "tar.gz"
+	TarFormat   ArchiveFormat = "tar"
+)
+
+func (f ArchiveFormat) IsValid() bool {
+	switch f {
+	case ZipFormat, TarGzFormat, TarFormat:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetArchiveFormatBySuffix 根据文件名后缀获取打包格式 如v1.0.tar.gz
+func GetArchiveFormatBySuffix(fileName string) (string, ArchiveFormat, bool) {
+	// tar.gz需在tar之前判断
+	for _, f := range []ArchiveFormat{TarGzFormat, ZipFormat, TarFormat} {
+		suffix := "." + string(f)
+		if strings.HasSuffix(fileName, suffix) {
+			return strings.TrimSuffix(fileName, suffix), f, true
+		}
+	}
+	return "", "", false
+}
+
+// GetRefTreeId 获取引用对应的提交id和树id
+func GetRefTreeId(ctx context.Context, repoPath, ref string) (string, string, error) {
+	result, err := command.NewCommand("rev-parse", ref+"^{commit}", ref+"^{tree}").
+		Run(ctx, command.WithDir(repoPath))
+	if err != nil {
+		return "", "", err
+	}
+	fields := strings.Fields(result.ReadAsString())
+	if len(fields) != 2 {
+		return "", "", errors.New("ref not found")
+	}
+	return fields[0], fields[1], nil
+}
+
+type CreateArchiveOpts struct {
+	CommitId string
+	Format   ArchiveFormat
+	// 压缩包内文件的前缀目录 为空则不加
+	Prefix string
+	// 输出文件路径
+	Output string
+}
+
+// CreateArchive 打包仓库代码
+func CreateArchive(ctx context.Context, repoPath string, opts CreateArchiveOpts) error {
+	cmd := command.NewCommand("archive", "--format="+string(opts.Format), "--output="+opts.Output)
+	if opts.Prefix != "" {
+		cmd.AddArgs("--prefix=" + strings.TrimSuffix(opts.Prefix, "/") + "/")
+	}
+	_, err := cmd.AddArgs(opts.CommitId).Run(ctx, command.WithDir(repoPath))
+	return err
+}
@@ -0,0 +1,138 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"zgit/pkg/git/command"
+)
+
+type BlameOpts struct {
+	Ref      string
+	FilePath string
+	// 行号从1开始 都为0时为整个文件
+	StartLine int
+	EndLine   int
+}
+
+// BlamePart 连续且来自同一提交的行
+type BlamePart struct {
+	Commit Commit
+	// 该提交中的文件名 文件重命名时与当前文件名不同
+	FilePath  string
+	StartLine int
+	EndLine   int
+	Lines     []string
+}
+
+// Blame 解析git blame --porcelain
+func Blame(ctx context.Context, repoPath string, opts BlameOpts) ([]BlamePart, error) {
+	cmd := command.NewCommand("blame", "--porcelain")
+	if opts.StartLine > 0 && opts.EndLine > 0 {
+		cmd.AddArgs("-L", fmt.Sprintf("%d,%d", opts.StartLine, opts.EndLine))
+	}
+	result, err := cmd.AddArgs(opts.Ref, "--", opts.FilePath).Run(ctx, command.WithDir(repoPath))
+	if err != nil {
+		return nil, err
+	}
+	return parseBlamePorcelain(result.ReadAsBytes())
+}
+
+func parseBlamePorcelain(content []byte) ([]BlamePart, error) {
+	var (
+		ret = make([]BlamePart, 0)
+		// 提交信息只在第一次出现时输出
+		commits = make(map[string]*Commit)
+		// 同一提交的文件名只在第一次或变化时输出
+		fileNames = make(map[string]string)
+		commit    *Commit
+		// 名称 邮箱 时间 时区
+		author    = make([]string, 4)
+		committer = make([]string, 4)
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// 文件内容行
+		if strings.HasPrefix(line, "\t") {
+			if len(ret) == 0 {
+				return nil, fmt.Errorf("unexpected blame line: %s", line)
+			}
+			cur := &ret[len(ret)-1]
+			cur.Lines = append(cur.Lines, line[1:])
+			cur.EndLine = cur.StartLine + len(cur.Lines) - 1
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			author[0] = value
+		case "author-mail":
+			author[1] = value
+		case "author-time":
+			author[2] = value
+		case "author-tz":
+			author[3] = value
+		case "committer":
+			committer[0] = value
+		case "committer-mail":
+			committer[1] = value
+		case "committer-time":
+			committer[2] = value
+		case "committer-tz":
+			committer[3] = value
+		case "summary":
+			// summary在作者信息之后
+			commit.Author, commit.AuthorSigTime = parseUserAndTime(author)
+			commit.Committer, commit.CommitSigTime = parseUserAndTime(committer)
+			commit.CommitMsg = value
+		case "filename":
+			if commit != nil {
+				fileNames[commit.Id] = value
+			}
+			if len(ret) > 0 {
+				ret[len(ret)-1].FilePath = value
+			}
+		case "previous", "boundary":
+		default:
+			// 行头 <sha> <原行号> <当前行号> [<分组行数>]
+			fields := strings.Fields(line)
+			if len(fields) < 3 || len(key) != 40 {
+				return nil, fmt.Errorf("unexpected blame line: %s", line)
+			}
+			c, b := commits[key]
+			if !b {
+				nc := newCommit(key)
+				c = &nc
+				commits[key] = c
+			}
+			commit = c
+			// 新的分组
+			if len(fields) == 4 {
+				finalLine, err := strconv.Atoi(fields[2])
+				if err != nil {
+					return nil, fmt.Errorf("unexpected blame line: %s", line)
+				}
+				ret = append(ret, BlamePart{
+					FilePath:  fileNames[key],
+					StartLine: finalLine,
+					EndLine:   finalLine,
+					Lines:     make([]string, 0),
+				})
+				// 先记录提交id 全部解析完再填充提交信息
+				ret[len(ret)-1].Commit.Id = key
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for i := range ret {
+		ret[i].Commit = *commits[ret[i].Commit.Id]
+	}
+	return ret, nil
+}
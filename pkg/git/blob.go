@@ -0,0 +1,140 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"zgit/pkg/git/command"
+)
+
+type TreeBlob struct {
+	Mode FileMode
+	Path string
+	Blob string
+	Size int64
+}
+
+// ListTreeBlobs 列出提交下所有文件 不包含子模块
+func ListTreeBlobs(ctx context.Context, repoPath, commitId string) ([]TreeBlob, error) {
+	result, err := command.NewCommand("ls-tree", "--full-tree", "-r", "-z", "-l", commitId).
+		Run(ctx, command.WithDir(repoPath))
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]TreeBlob, 0)
+	for _, entry := range strings.Split(result.ReadAsString(), "\x00") {
+		if entry == "" {
+			continue
+		}
+		// <mode> SP <type> SP <object> SP+ <size> TAB <path>
+		info, path, b := strings.Cut(entry, "\t")
+		if !b {
+			return nil, fmt.Errorf("unexpected ls-tree entry: %s", entry)
+		}
+		fields := strings.Fields(info)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected ls-tree entry: %s", entry)
+		}
+		if fields[1] != BlobType {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected ls-tree entry: %s", entry)
+		}
+		ret = append(ret, TreeBlob{
+			Mode: FileMode(fields[0]),
+			Path: path,
+			Blob: fields[2],
+			Size: size,
+		})
+	}
+	return ret, nil
+}
+
+type TreeChange struct {
+	Path string
+	// 变更后的文件 删除时为空
+	Mode      FileMode
+	Blob      string
+	IsDeleted bool
+}
+
+// DiffTreeChanges 两个提交之间变更的文件 重命名视为删除加新增
+func DiffTreeChanges(ctx context.Context, repoPath, oldCommitId, newCommitId string) ([]TreeChange, error) {
+	result, err := command.NewCommand("diff-tree", "-r", "-z", "--no-renames", oldCommitId, newCommitId).
+		Run(ctx, command.WithDir(repoPath))
+	if err != nil {
+		return nil, err
+	}
+	// :<old mode> SP <new mode> SP <old sha> SP <new sha> SP <status> NUL <path> NUL
+	entries := strings.Split(result.ReadAsString(), "\x00")
+	ret := make([]TreeChange, 0)
+	for i := 0; i+1 < len(entries); i += 2 {
+		fields := strings.Fields(strings.TrimPrefix(entries[i], ":"))
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected diff-tree entry: %s", entries[i])
+		}
+		c := TreeChange{
+			Path: entries[i+1],
+		}
+		if fields[4] == "D" {
+			c.IsDeleted = true
+		} else {
+			c.Mode = FileMode(fields[1])
+			c.Blob = fields[3]
+		}
+		ret = append(ret, c)
+	}
+	return ret, nil
+}
+
+// CatFileBatchBlobs 逐个读取文件内容 readFn中的reader只在回调内有效
+func CatFileBatchBlobs(ctx context.Context, repoPath string, blobIdList []string, readFn func(string, int64, io.Reader) error) error {
+	if len(blobIdList) == 0 {
+		return nil
+	}
+	pipe := command.NewCommand("cat-file", "--batch").RunWithStdinPipe(ctx, command.WithDir(repoPath))
+	defer pipe.ClosePipe()
+	reader := bufio.NewReader(pipe.Reader())
+	for _, blobId := range blobIdList {
+		if _, err := pipe.Writer().Write([]byte(blobId + "\n")); err != nil {
+			return err
+		}
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("read line err: %v", err)
+		}
+		_, typ, size, err := readBatchLine(strings.TrimSpace(line))
+		if err != nil {
+			return fmt.Errorf("readBatchLine err: %v", err)
+		}
+		if typ != BlobType {
+			return fmt.Errorf("unsupported type: %s", typ)
+		}
+		limitReader := io.LimitReader(reader, size)
+		if err = readFn(blobId, size, limitReader); err != nil {
+			return err
+		}
+		// 回调未读完的内容和末尾换行符都要跳过
+		if _, err = io.Copy(io.Discard, limitReader); err != nil {
+			return err
+		}
+		if _, err = reader.Discard(1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsBinaryContent 前8000字节包含0则视为二进制 与git判断方式一致
+func IsBinaryContent(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
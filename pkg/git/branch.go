@@ -2,10 +2,32 @@ package git
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"strings"
 	"zgit/pkg/git/command"
+	"zgit/setting"
+	"zgit/util"
 )
 
+type Branch struct {
+	Name     string
+	CommitId string
+}
+
+type ListBranchesOpts struct {
+	// Keyword 分支名称包含关键字 不区分大小写
+	Keyword string
+	Offset  int
+	Limit   int
+}
+
+// PushRefOpts 分支和标签的修改通过推送完成 会经过钩子 保护分支和保护标签规则同样生效
+type PushRefOpts struct {
+	RepoId   string
+	PusherId string
+}
+
 func GetAllBranchList(ctx context.Context, repoPath string) ([]string, error) {
 	cmd := command.NewCommand("for-each-ref", "--format=%(objectname) %(refname)", BranchPrefix, "--sort=-committerdate")
 	pipeResult := cmd.RunWithReadPipe(ctx, command.WithDir(repoPath))
@@ -20,6 +42,34 @@ func GetAllBranchList(ctx context.Context, repoPath string) ([]string, error) {
 	return ret, err
 }
 
+// ListBranches 按最近提交时间倒序分页 返回当前页和符合条件的总数
+func ListBranches(ctx context.Context, repoPath string, opts ListBranchesOpts) ([]Branch, int, error) {
+	cmd := command.NewCommand("for-each-ref", "--format=%(objectname) %(refname)", BranchPrefix, "--sort=-committerdate")
+	pipeResult := cmd.RunWithReadPipe(ctx, command.WithDir(repoPath))
+	keyword := strings.ToLower(opts.Keyword)
+	ret := make([]Branch, 0)
+	total := 0
+	err := pipeResult.RangeStringLines(func(_ int, line string) (bool, error) {
+		commitId, refName, found := strings.Cut(strings.TrimSpace(line), " ")
+		if !found {
+			return true, nil
+		}
+		name := strings.TrimPrefix(refName, BranchPrefix)
+		if keyword != "" && !strings.Contains(strings.ToLower(name), keyword) {
+			return true, nil
+		}
+		total++
+		if total > opts.Offset && len(ret) < opts.Limit {
+			ret = append(ret, Branch{
+				Name:     name,
+				CommitId: commitId,
+			})
+		}
+		return true, nil
+	})
+	return ret, total, err
+}
+
 func CheckRefIsBranch(ctx context.Context, repoPath string, branch string) bool {
 	if !strings.HasPrefix(branch, BranchPrefix) {
 		branch = BranchPrefix + branch
@@ -34,3 +84,65 @@ func CheckCommitIfInBranch(ctx context.Context, repoPath, commitId, branch strin
 	}
 	return len(strings.TrimSpace(result.ReadAsString())) > 0, nil
 }
+
+// CountAheadBehind head相对于base领先和落后的提交数 以两者的merge-base为基准
+func CountAheadBehind(ctx context.Context, repoPath, base, head string) (int, int, error) {
+	result, err := command.NewCommand("rev-list", "--left-right", "--count", base+"..."+head, "--").
+		Run(ctx, command.WithDir(repoPath))
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(result.ReadAsString())
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %s", result.ReadAsString())
+	}
+	behind, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	ahead, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return ahead, behind, nil
+}
+
+// CreateBranch 基于提交创建分支
+func CreateBranch(ctx context.Context, repoPath, branch, commitId string, opts PushRefOpts) error {
+	return pushRefs(ctx, repoPath, ".", opts, commitId+":"+BranchPrefix+branch)
+}
+
+// DeleteBranch 删除分支
+func DeleteBranch(ctx context.Context, repoPath, branch string, opts PushRefOpts) error {
+	return pushRefs(ctx, repoPath, ".", opts, ":"+BranchPrefix+branch)
+}
+
+// RenameBranch 新建分支和删除旧分支原子推送 HEAD指向的分支需先修改HEAD 否则无法删除
+func RenameBranch(ctx context.Context, repoPath, branch, newBranch string, opts PushRefOpts) error {
+	return pushRefs(ctx, repoPath, ".", opts,
+		"--atomic",
+		BranchPrefix+branch+":"+BranchPrefix+newBranch,
+		":"+BranchPrefix+branch,
+	)
+}
+
+// pushRefs 在dir下推送到remote remote为"."时推送到仓库自身
+func pushRefs(ctx context.Context, dir, remote string, opts PushRefOpts, args ...string) error {
+	_, err := command.NewCommand("push", remote).
+		AddArgs(args...).
+		Run(ctx,
+			command.WithDir(dir),
+			command.WithEnv(
+				util.JoinFields(
+					EnvAppUrl, setting.AppUrl(),
+					EnvHookToken, setting.HookToken(),
+					EnvRepoId, opts.RepoId,
+					EnvPusherId, opts.PusherId,
+				),
+			),
+		)
+	if err != nil {
+		return wrapPushErr(err)
+	}
+	return nil
+}
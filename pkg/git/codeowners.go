@@ -0,0 +1,117 @@
+package git
+
+import (
+	"context"
+	"github.com/IGLOU-EU/go-wildcard/v2"
+	"path"
+	"strings"
+)
+
+const (
+	// CodeOwnerGroupPrefix 项目用户组 如@group:developer
+	CodeOwnerGroupPrefix = "@group:"
+)
+
+// CodeOwnersFilePaths 按顺序查找CODEOWNERS文件
+var CodeOwnersFilePaths = []string{"CODEOWNERS", ".zgit/CODEOWNERS", "docs/CODEOWNERS"}
+
+type CodeOwner struct {
+	Name    string
+	IsGroup bool
+}
+
+func (o CodeOwner) String() string {
+	if o.IsGroup {
+		return CodeOwnerGroupPrefix + o.Name
+	}
+	return "@" + o.Name
+}
+
+type CodeOwnerRule struct {
+	Pattern string
+	Owners  []CodeOwner
+}
+
+// Match 匹配文件路径
+// 以/开头的从仓库根目录匹配 以/结尾的匹配目录下所有文件 不含/的匹配任意层级
+func (r *CodeOwnerRule) Match(filePath string) bool {
+	filePath = strings.TrimPrefix(filePath, "/")
+	pattern := r.Pattern
+	isDir := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" || pattern == "*" {
+		return true
+	}
+	if strings.HasPrefix(pattern, "/") {
+		pattern = strings.TrimPrefix(pattern, "/")
+		return matchCodeOwnerPattern(pattern, filePath, isDir)
+	}
+	if !strings.Contains(pattern, "/") {
+		if !isDir && wildcard.Match(pattern, path.Base(filePath)) {
+			return true
+		}
+		return wildcard.Match(pattern+"/*", filePath) || wildcard.Match("*/"+pattern+"/*", filePath)
+	}
+	return matchCodeOwnerPattern(pattern, filePath, isDir) || matchCodeOwnerPattern("*/"+pattern, filePath, isDir)
+}
+
+func matchCodeOwnerPattern(pattern, filePath string, isDir bool) bool {
+	if !isDir && wildcard.Match(pattern, filePath) {
+		return true
+	}
+	return wildcard.Match(pattern+"/*", filePath)
+}
+
+// ParseCodeOwners 解析CODEOWNERS内容 每行格式: 路径 @账号 @group:用户组
+func ParseCodeOwners(content string) []CodeOwnerRule {
+	ret := make([]CodeOwnerRule, 0)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		rule := CodeOwnerRule{
+			Pattern: fields[0],
+			Owners:  make([]CodeOwner, 0, len(fields)-1),
+		}
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "#") {
+				break
+			}
+			if strings.HasPrefix(field, CodeOwnerGroupPrefix) {
+				if name := strings.TrimPrefix(field, CodeOwnerGroupPrefix); name != "" {
+					rule.Owners = append(rule.Owners, CodeOwner{Name: name, IsGroup: true})
+				}
+			} else if strings.HasPrefix(field, "@") && len(field) > 1 {
+				rule.Owners = append(rule.Owners, CodeOwner{Name: field[1:]})
+			}
+		}
+		ret = append(ret, rule)
+	}
+	return ret
+}
+
+// FindCodeOwnerRule 最后一条匹配的规则生效
+func FindCodeOwnerRule(rules []CodeOwnerRule, filePath string) (CodeOwnerRule, bool) {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].Match(filePath) {
+			return rules[i], true
+		}
+	}
+	return CodeOwnerRule{}, false
+}
+
+// GetCodeOwners 读取分支上的CODEOWNERS文件
+func GetCodeOwners(ctx context.Context, repoPath, refName string) ([]CodeOwnerRule, bool, error) {
+	for _, filePath := range CodeOwnersFilePaths {
+		mode, content, b, err := GetFileContentByRef(ctx, repoPath, refName, filePath)
+		if err != nil {
+			return nil, false, err
+		}
+		if b && mode == RegularFileMode {
+			return ParseCodeOwners(content), true, nil
+		}
+	}
+	return nil, false, nil
+}
@@ -2,6 +2,7 @@ package git
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -19,6 +20,7 @@ import (
 const (
 	CommitType = "commit"
 	TagType    = "tag"
+	BlobType   = "blob"
 )
 
 var (
@@ -90,6 +92,7 @@ type Tag struct {
 	Tagger    User
 	TagTime   time.Time
 	CommitMsg string
+	GpgSig    signature.GPGSig
 }
 
 type Tree struct {
@@ -192,6 +195,11 @@ func GetCommitByTag(ctx context.Context, repoPath string, tag string) (c Commit,
 			c, err = GetCommitByCommitId(ctx, repoPath, t.Object)
 			c.Tag = t
 			return err
+		case CommitType:
+			// 轻量标签直接指向提交
+			var err error
+			c, err = GetCommitByCommitId(ctx, repoPath, id)
+			return err
 		default:
 			return fmt.Errorf("unsupported type: %s", typ)
 		}
@@ -221,6 +229,26 @@ func genTag(r io.Reader, tag *Tag) error {
 		if len(fields) < 1 {
 			continue
 		}
+		if lineStr == signature.StartGPGSigLineTag || lineStr == signature.StartSSHSigLineTag {
+			// 签名在消息之后 直到结尾
+			sigPayload := strings.Builder{}
+			sigPayload.WriteString(lineStr + "\n")
+			for {
+				line, isPrefix, err = reader.ReadLine()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return fmt.Errorf("read tag signature err: %v", err)
+				}
+				if isPrefix {
+					continue
+				}
+				sigPayload.WriteString(strings.TrimSpace(string(line)) + "\n")
+			}
+			tag.GpgSig = signature.GPGSig(sigPayload.String())
+			return nil
+		}
 		switch fields[0] {
 		case "object":
 			tag.Object = fields[1]
@@ -231,7 +259,8 @@ func genTag(r io.Reader, tag *Tag) error {
 		case "tagger":
 			tag.Tagger, tag.TagTime = parseUserAndTime(fields[1:])
 		default:
-			commitMsg.WriteString(lineStr)
+			// 保留换行 多行标签消息不再被拼接为一行
+			commitMsg.WriteString(lineStr + "\n")
 		}
 	}
 }
@@ -375,6 +404,64 @@ func GetFileLastCommit(ctx context.Context, repoPath, refName, filePath string)
 	return GetCommitByCommitId(ctx, repoPath, commitId)
 }
 
+type ListCommitsOpts struct {
+	Ref string
+	// 只看修改过该路径的提交
+	Path string
+	// 跟踪文件重命名 仅Path为文件时有效
+	Follow bool
+	// 作者名称或邮箱 忽略大小写
+	Author string
+	// 提交信息关键字 忽略大小写
+	Grep  string
+	Since time.Time
+	Until time.Time
+	Skip  int
+	Limit int
+}
+
+// ListCommits 按提交时间倒序获取ref的提交历史
+func ListCommits(ctx context.Context, repoPath string, opts ListCommitsOpts) ([]Commit, error) {
+	cmd := command.NewCommand("log", PrettyLogFormat)
+	if opts.Skip > 0 {
+		cmd.AddArgs("--skip=" + strconv.Itoa(opts.Skip))
+	}
+	if opts.Limit > 0 {
+		cmd.AddArgs("--max-count=" + strconv.Itoa(opts.Limit))
+	}
+	if opts.Author != "" || opts.Grep != "" {
+		cmd.AddArgs("--fixed-strings", "--regexp-ignore-case")
+		if opts.Author != "" {
+			cmd.AddArgs("--author=" + opts.Author)
+		}
+		if opts.Grep != "" {
+			cmd.AddArgs("--grep=" + opts.Grep)
+		}
+	}
+	if !opts.Since.IsZero() {
+		cmd.AddArgs("--since=" + opts.Since.Format(time.RFC3339))
+	}
+	if !opts.Until.IsZero() {
+		cmd.AddArgs("--until=" + opts.Until.Format(time.RFC3339))
+	}
+	if opts.Follow && opts.Path != "" {
+		cmd.AddArgs("--follow")
+	}
+	cmd.AddArgs(opts.Ref, "--")
+	if opts.Path != "" {
+		cmd.AddArgs(opts.Path)
+	}
+	result, err := cmd.Run(ctx, command.WithDir(repoPath))
+	if err != nil {
+		return nil, err
+	}
+	commitIdList := strings.Fields(result.ReadAsString())
+	if len(commitIdList) == 0 {
+		return []Commit{}, nil
+	}
+	return catFileBatchCommits(ctx, repoPath, commitIdList, nil)
+}
+
 func GetCommit(ctx context.Context, repoPath string, refName string) (Commit, string, error) {
 	if CheckRefIsTag(ctx, repoPath, refName) {
 		if !strings.HasPrefix(refName, TagPrefix) {
@@ -401,22 +488,168 @@ func GetCommit(ctx context.Context, repoPath string, refName string) (Commit, st
 	return Commit{}, "", fmt.Errorf("%s unsupported type", refName)
 }
 
-type DetectForcePushEnv struct {
+// QuarantineEnv pre-receive阶段推送的对象还在隔离区 需带上该环境变量才能读取
+type QuarantineEnv struct {
 	ObjectDirectory              string
 	AlternativeObjectDirectories string
 	QuarantinePath               string
 }
 
-func DetectForcePush(ctx context.Context, repoPath, oldCommitId, newCommitId string, env DetectForcePushEnv) (bool, error) {
+func (e QuarantineEnv) toEnv() []string {
+	return util.JoinFields(
+		EnvObjectDirectory, e.ObjectDirectory,
+		EnvAlternativeObjectDirectories, e.AlternativeObjectDirectories,
+		EnvQuarantinePath, e.QuarantinePath,
+	)
+}
+
+func DetectForcePush(ctx context.Context, repoPath, oldCommitId, newCommitId string, env QuarantineEnv) (bool, error) {
 	result, err := command.NewCommand("rev-list", "--max-count=1", oldCommitId, "^"+newCommitId).
-		Run(ctx, command.WithDir(repoPath),
-			command.WithEnv(util.JoinFields(
-				EnvObjectDirectory, env.ObjectDirectory,
-				EnvAlternativeObjectDirectories, env.AlternativeObjectDirectories,
-				EnvQuarantinePath, env.QuarantinePath,
-			)))
+		Run(ctx, command.WithDir(repoPath), command.WithEnv(env.toEnv()))
 	if err != nil {
 		return false, err
 	}
 	return len(result.ReadAsBytes()) > 0, nil
 }
+
+// ListPushCommits 获取本次推送新增的提交 oldCommitId为空提交时获取仓库中原本不存在的提交
+func ListPushCommits(ctx context.Context, repoPath, oldCommitId, newRev string, env QuarantineEnv) ([]Commit, error) {
+	cmd := command.NewCommand("rev-list", newRev)
+	if oldCommitId == ZeroCommitId {
+		cmd.AddArgs("--not", "--all")
+	} else {
+		cmd.AddArgs("^" + oldCommitId)
+	}
+	result, err := cmd.Run(ctx, command.WithDir(repoPath), command.WithEnv(env.toEnv()))
+	if err != nil {
+		return nil, err
+	}
+	commitIdList := strings.Fields(result.ReadAsString())
+	if len(commitIdList) == 0 {
+		return []Commit{}, nil
+	}
+	return catFileBatchCommits(ctx, repoPath, commitIdList, env.toEnv())
+}
+
+// catFileBatchCommits 批量读取提交对象
+func catFileBatchCommits(ctx context.Context, repoPath string, commitIdList []string, env []string) ([]Commit, error) {
+	result, err := command.NewCommand("cat-file", "--batch").
+		Run(ctx,
+			command.WithDir(repoPath),
+			command.WithEnv(env),
+			command.WithStdin(strings.NewReader(strings.Join(commitIdList, "\n")+"\n")),
+		)
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(bytes.NewReader(result.ReadAsBytes()))
+	ret := make([]Commit, 0, len(commitIdList))
+	for range commitIdList {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("read line err: %v", err)
+		}
+		commitId, typ, size, err := readBatchLine(strings.TrimSpace(line))
+		if err != nil {
+			return nil, fmt.Errorf("readBatchLine err: %v", err)
+		}
+		if typ != CommitType {
+			return nil, fmt.Errorf("unsupported type: %s", typ)
+		}
+		c := newCommit(commitId)
+		if err = genCommit(io.LimitReader(reader, size), &c); err != nil {
+			return nil, err
+		}
+		// 跳过对象内容后的换行符
+		if _, err = reader.Discard(1); err != nil {
+			return nil, err
+		}
+		ret = append(ret, c)
+	}
+	return ret, nil
+}
+
+// ListPushChangedFiles 获取提交中新增或修改的文件路径
+func ListPushChangedFiles(ctx context.Context, repoPath string, commitIdList []string, env QuarantineEnv) ([]string, error) {
+	if len(commitIdList) == 0 {
+		return []string{}, nil
+	}
+	result, err := command.NewCommand("diff-tree", "--stdin", "-r", "-z", "--name-only", "--no-commit-id", "--root", "--diff-filter=ACMR").
+		Run(ctx,
+			command.WithDir(repoPath),
+			command.WithEnv(env.toEnv()),
+			command.WithStdin(strings.NewReader(strings.Join(commitIdList, "\n")+"\n")),
+		)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]string, 0)
+	existsMap := make(map[string]bool)
+	for _, filePath := range strings.Split(result.ReadAsString(), "\x00") {
+		if filePath == "" || existsMap[filePath] {
+			continue
+		}
+		existsMap[filePath] = true
+		ret = append(ret, filePath)
+	}
+	return ret, nil
+}
+
+type PushBlob struct {
+	Id   string
+	Path string
+	Size int64
+}
+
+// ListPushBlobs 获取本次推送新增的文件对象
+func ListPushBlobs(ctx context.Context, repoPath, oldCommitId, newRev string, env QuarantineEnv) ([]PushBlob, error) {
+	cmd := command.NewCommand("rev-list", "--objects", newRev)
+	if oldCommitId == ZeroCommitId {
+		cmd.AddArgs("--not", "--all")
+	} else {
+		cmd.AddArgs("^" + oldCommitId)
+	}
+	result, err := cmd.Run(ctx, command.WithDir(repoPath), command.WithEnv(env.toEnv()))
+	if err != nil {
+		return nil, err
+	}
+	idList := make([]string, 0)
+	pathMap := make(map[string]string)
+	for _, line := range strings.Split(result.ReadAsString(), "\n") {
+		// 提交对象没有路径
+		id, objPath, found := strings.Cut(line, " ")
+		if !found || objPath == "" {
+			continue
+		}
+		idList = append(idList, id)
+		pathMap[id] = objPath
+	}
+	if len(idList) == 0 {
+		return []PushBlob{}, nil
+	}
+	result, err = command.NewCommand("cat-file", "--batch-check").
+		Run(ctx,
+			command.WithDir(repoPath),
+			command.WithEnv(env.toEnv()),
+			command.WithStdin(strings.NewReader(strings.Join(idList, "\n")+"\n")),
+		)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]PushBlob, 0)
+	for _, line := range strings.Split(strings.TrimSpace(result.ReadAsString()), "\n") {
+		id, typ, size, err := readBatchLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("readBatchLine err: %v", err)
+		}
+		if typ != BlobType {
+			continue
+		}
+		ret = append(ret, PushBlob{
+			Id:   id,
+			Path: pathMap[id],
+			Size: size,
+		})
+	}
+	return ret, nil
+}
@@ -0,0 +1,283 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/LeeZXin/zsf-utils/idutil"
+	"path"
+	"path/filepath"
+	"strings"
+	"zgit/pkg/git/command"
+	"zgit/setting"
+	"zgit/util"
+)
+
+type FileAction string
+
+const (
+	CreateFileAction FileAction = "create"
+	UpdateFileAction FileAction = "update"
+	DeleteFileAction FileAction = "delete"
+	// MoveFileAction 重命名或移动 内容不变
+	MoveFileAction FileAction = "move"
+)
+
+func (a FileAction) IsValid() bool {
+	switch a {
+	case CreateFileAction, UpdateFileAction, DeleteFileAction, MoveFileAction:
+		return true
+	default:
+		return false
+	}
+}
+
+type FileChange struct {
+	Action   FileAction
+	FilePath string
+	// PrevPath 移动前的路径
+	PrevPath string
+	Content  string
+}
+
+type CommitFilesOpts struct {
+	RepoId   string
+	PusherId string
+	Author   User
+	Branch   string
+	// StartCommitId 修改基于的提交 分支已有新的提交则失败
+	StartCommitId string
+	// NewBranch 不为空则提交到新分支
+	NewBranch string
+	Message   string
+	Changes   []FileChange
+}
+
+type ErrFileNotFound struct {
+	FilePath string
+}
+
+func (e *ErrFileNotFound) Error() string {
+	return "file not found: " + e.FilePath
+}
+
+type ErrFileAlreadyExists struct {
+	FilePath string
+}
+
+func (e *ErrFileAlreadyExists) Error() string {
+	return "file already exists: " + e.FilePath
+}
+
+type indexEntry struct {
+	Mode string
+	Blob string
+	Path string
+}
+
+// CommitFiles 在临时裸仓库的索引上修改文件 提交后推送到分支 返回新的提交id
+func CommitFiles(ctx context.Context, repoPath string, opts CommitFilesOpts) (string, error) {
+	tempDir := filepath.Join(setting.TempDir(), "edit-"+idutil.RandomUuid())
+	defer util.RemoveAll(tempDir)
+	if _, err := command.NewCommand("clone", "-s", "--bare", "-b", opts.Branch, repoPath, tempDir).Run(ctx); err != nil {
+		return "", fmt.Errorf("clone tempDir:%s failed with err:%v", tempDir, err)
+	}
+	headCommitId, err := GetRefCommitId(ctx, tempDir, "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("get head commitId failed with err:%v", err)
+	}
+	if headCommitId != opts.StartCommitId {
+		return "", &ErrPushOutOfDate{
+			err: fmt.Errorf("branch %s has been updated to %s", opts.Branch, headCommitId),
+		}
+	}
+	if _, err = command.NewCommand("read-tree", opts.StartCommitId).
+		Run(ctx, command.WithDir(tempDir)); err != nil {
+		return "", fmt.Errorf("read tree failed with err:%v", err)
+	}
+	// 按顺序修改 后面的修改基于前面修改后的索引
+	for _, change := range opts.Changes {
+		if err = applyFileChange(ctx, tempDir, change); err != nil {
+			return "", err
+		}
+	}
+	tree, err := WriteTree(ctx, tempDir)
+	if err != nil {
+		return "", fmt.Errorf("write tree failed with err:%v", err)
+	}
+	commitId, err := CommitTree(ctx, tempDir, tree, CommitTreeOpts{
+		Parents: []string{opts.StartCommitId},
+		Message: opts.Message,
+		Author:  opts.Author,
+	})
+	if err != nil {
+		return "", fmt.Errorf("commit tree failed with err:%v", err)
+	}
+	branch := opts.Branch
+	if opts.NewBranch != "" {
+		branch = opts.NewBranch
+	}
+	// 带上推送人 保护分支等规则同样生效
+	_, err = command.NewCommand("push", DefaultRemote, commitId+":"+BranchPrefix+branch).
+		Run(ctx,
+			command.WithDir(tempDir),
+			command.WithEnv(
+				util.JoinFields(
+					EnvAppUrl, setting.AppUrl(),
+					EnvHookToken, setting.HookToken(),
+					EnvRepoId, opts.RepoId,
+					EnvPusherId, opts.PusherId,
+				),
+			),
+		)
+	if err != nil {
+		return "", wrapPushErr(err)
+	}
+	return commitId, nil
+}
+
+func applyFileChange(ctx context.Context, dir string, change FileChange) error {
+	switch change.Action {
+	case CreateFileAction:
+		if err := checkFileAbsent(ctx, dir, change.FilePath); err != nil {
+			return err
+		}
+		return addContentToIndex(ctx, dir, RegularFileMode.String(), change.FilePath, change.Content)
+	case UpdateFileAction:
+		entry, err := getIndexFile(ctx, dir, change.FilePath)
+		if err != nil {
+			return err
+		}
+		// 保留可执行权限
+		return addContentToIndex(ctx, dir, entry.Mode, change.FilePath, change.Content)
+	case DeleteFileAction:
+		if _, err := getIndexFile(ctx, dir, change.FilePath); err != nil {
+			return err
+		}
+		return RemoveFilesFromIndex(ctx, dir, change.FilePath)
+	case MoveFileAction:
+		entry, err := getIndexFile(ctx, dir, change.PrevPath)
+		if err != nil {
+			return err
+		}
+		if err = RemoveFilesFromIndex(ctx, dir, change.PrevPath); err != nil {
+			return err
+		}
+		if err = checkFileAbsent(ctx, dir, change.FilePath); err != nil {
+			return err
+		}
+		return addObjectToIndexNoReplace(ctx, dir, entry.Mode, entry.Blob, change.FilePath)
+	default:
+		return fmt.Errorf("unknown file action: %s", change.Action)
+	}
+}
+
+func addContentToIndex(ctx context.Context, dir, mode, filePath, content string) error {
+	object, err := HashObjectByStdin(ctx, dir, strings.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("hash content failed with err:%v", err)
+	}
+	return addObjectToIndexNoReplace(ctx, dir, mode, object, filePath)
+}
+
+// addObjectToIndexNoReplace 不加--replace 避免文件和目录冲突时被静默覆盖
+func addObjectToIndexNoReplace(ctx context.Context, dir, mode, object, filePath string) error {
+	_, err := command.NewCommand("update-index", "--add", "--cacheinfo", mode, object, filePath).
+		Run(ctx, command.WithDir(dir))
+	if err != nil {
+		return fmt.Errorf("addObjectToIndex failed with err:%v", err)
+	}
+	return nil
+}
+
+// lsIndexPath 索引中该路径的文件 路径是目录时返回目录下所有文件
+func lsIndexPath(ctx context.Context, dir, filePath string) ([]indexEntry, error) {
+	result, err := command.NewCommand("ls-files", "--stage", "-z", "--", filePath).
+		Run(ctx, command.WithDir(dir), command.WithEnv(util.JoinFields("GIT_LITERAL_PATHSPECS", "1")))
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]indexEntry, 0)
+	for _, line := range bytes.Split(result.ReadAsBytes(), []byte{0}) {
+		// <mode> <object> <stage>\t<file>
+		info, name, found := strings.Cut(string(line), "\t")
+		if !found {
+			continue
+		}
+		fields := strings.Fields(info)
+		if len(fields) != 3 {
+			continue
+		}
+		ret = append(ret, indexEntry{
+			Mode: fields[0],
+			Blob: fields[1],
+			Path: name,
+		})
+	}
+	return ret, nil
+}
+
+// getIndexFile 文件必须存在且不是目录
+func getIndexFile(ctx context.Context, dir, filePath string) (indexEntry, error) {
+	entries, err := lsIndexPath(ctx, dir, filePath)
+	if err != nil {
+		return indexEntry{}, err
+	}
+	for _, entry := range entries {
+		if entry.Path == filePath && entry.Mode != SubModuleMode.String() {
+			return entry, nil
+		}
+	}
+	return indexEntry{}, &ErrFileNotFound{
+		FilePath: filePath,
+	}
+}
+
+// checkFileAbsent 路径和上级目录都不能是已存在的文件
+func checkFileAbsent(ctx context.Context, dir, filePath string) error {
+	for p := filePath; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+		entries, err := lsIndexPath(ctx, dir, p)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.Path == p || p == filePath {
+				return &ErrFileAlreadyExists{
+					FilePath: p,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func wrapPushErr(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "non-fast-forward"), strings.Contains(msg, "fetch first"):
+		return &ErrPushOutOfDate{
+			err: err,
+		}
+	case strings.Contains(msg, "hook declined"):
+		// 钩子返回的原因
+		reasons := make([]string, 0)
+		for _, line := range strings.Split(msg, "\n") {
+			if reason, found := strings.CutPrefix(line, "remote: "); found {
+				if reason = strings.TrimSpace(reason); reason != "" {
+					reasons = append(reasons, reason)
+				}
+			}
+		}
+		if len(reasons) == 0 {
+			return &ErrPushRejected{
+				err: err,
+			}
+		}
+		return &ErrPushRejected{
+			err: errors.New(strings.Join(reasons, "\n")),
+		}
+	default:
+		return fmt.Errorf("push failed with err:%v", err)
+	}
+}
@@ -31,3 +31,11 @@ type ErrPushRejected struct {
 func (e *ErrPushRejected) Error() string {
 	return e.err.Error()
 }
+
+type ErrMergeNotFastForward struct {
+	err error
+}
+
+func (e *ErrMergeNotFastForward) Error() string {
+	return e.err.Error()
+}
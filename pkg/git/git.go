@@ -14,6 +14,7 @@ import (
 	"strings"
 	"zgit/pkg/git/command"
 	"zgit/setting"
+	"zgit/util"
 )
 
 const (
@@ -61,6 +62,8 @@ const (
 	EnvIsInternal = "ZGIT_INTERNAL_PUSH"
 	EnvAppUrl     = "ZGIT_APP_URL"
 	EnvHookToken  = "ZGIT_HOOK_TOKEN"
+	// EnvIsWiki 推送的是仓库的wiki
+	EnvIsWiki = "ZGIT_IS_WIKI"
 )
 
 const (
@@ -76,6 +79,7 @@ const (
 	EnvObjectDirectory              = "GIT_OBJECT_DIRECTORY"
 	EnvQuarantinePath               = "GIT_QUARANTINE_PATH"
 	EnvPushOptionCount              = "GIT_PUSH_OPTION_COUNT"
+	EnvPushOptionPrefix             = "GIT_PUSH_OPTION_"
 )
 
 const notRegularFileMode = os.ModeSymlink | os.ModeNamedPipe | os.ModeSocket | os.ModeDevice | os.ModeCharDevice | os.ModeIrregular
@@ -295,6 +299,8 @@ func WriteTree(ctx context.Context, repoPath string) (Tree, error) {
 type CommitTreeOpts struct {
 	Parents []string
 	Message string
+	// Author 为空则使用全局配置
+	Author User
 }
 
 // CommitTree creates a commit from a given tree id for the user with provided message
@@ -306,7 +312,14 @@ func CommitTree(ctx context.Context, repoPath string, tree Tree, opts CommitTree
 	message := new(bytes.Buffer)
 	message.WriteString(opts.Message)
 	message.WriteString("\n")
-	result, err := cmd.Run(ctx, command.WithDir(repoPath), command.WithStdin(message))
+	var env []string
+	if opts.Author.Account != "" {
+		env = util.JoinFields(
+			"GIT_AUTHOR_NAME", opts.Author.Account,
+			"GIT_AUTHOR_EMAIL", opts.Author.Email,
+		)
+	}
+	result, err := cmd.Run(ctx, command.WithDir(repoPath), command.WithStdin(message), command.WithEnv(env))
 	if err != nil {
 		return "", err
 	}
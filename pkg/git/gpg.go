@@ -1,11 +1,20 @@
 package git
 
-import "zgit/setting"
+import (
+	"context"
+	"fmt"
+	"strings"
+	"zgit/pkg/git/command"
+	"zgit/pkg/git/signature"
+	"zgit/setting"
+)
 
 type CommitScene int
 
 const (
 	FirstCommitScene CommitScene = iota
+	TagScene
+	MergeScene
 )
 
 func GetGpnKeyId(repo string, sceneType CommitScene) string {
@@ -20,7 +29,32 @@ func GetGpnKeyId(repo string, sceneType CommitScene) string {
 	signKey := setting.SignKey()
 	if signKey == "" || signKey == "default" {
 		key, _ := GetRepoSignKey(repo)
-		return key
+		return strings.TrimSpace(key)
 	}
 	return signKey
 }
+
+// VerifyCommitSignedBy 校验服务端生成的提交是否由服务端密钥签名
+// 签名的密钥id需与服务端配置一致 且通过服务端密钥环校验
+func VerifyCommitSignedBy(ctx context.Context, repoPath string, commit Commit, keyId string, env QuarantineEnv) bool {
+	if keyId == "" || !commit.GpgSig.IsGPGSig() {
+		return false
+	}
+	sig, err := signature.ParseGPGSignature(commit.GpgSig)
+	if err != nil {
+		return false
+	}
+	sigKeyId := sig.GetGPGSignatureKeyId()
+	if sigKeyId == "" {
+		return false
+	}
+	sigKeyId = fmt.Sprintf("%016s", sigKeyId)
+	// 配置可能为完整指纹、长id或短id
+	keyId = strings.ToUpper(strings.TrimPrefix(keyId, "0x"))
+	if !strings.HasSuffix(keyId, sigKeyId) && !strings.HasSuffix(sigKeyId, keyId) {
+		return false
+	}
+	_, err = command.NewCommand("verify-commit", commit.Id).
+		Run(ctx, command.WithDir(repoPath), command.WithEnv(env.toEnv()))
+	return err == nil
+}
@@ -18,6 +18,7 @@ import (
 const (
 	MergeBranch    = "base"
 	TrackingBranch = "tracking"
+	StagingBranch  = "staging"
 )
 
 var (
@@ -43,10 +44,43 @@ func (i *DiffCommitsInfo) IsMergeAble() bool {
 	return len(i.Commits) > 0 && len(i.ConflictFiles) == 0
 }
 
+type MergeStrategy string
+
+const (
+	// MergeCommitStrategy 创建合并提交
+	MergeCommitStrategy MergeStrategy = "merge"
+	// SquashStrategy 压缩为一个提交
+	SquashStrategy MergeStrategy = "squash"
+	// RebaseStrategy 变基后快进合并
+	RebaseStrategy MergeStrategy = "rebase"
+	// FastForwardOnlyStrategy 仅允许快进合并
+	FastForwardOnlyStrategy MergeStrategy = "fastForwardOnly"
+)
+
+func (s MergeStrategy) IsValid() bool {
+	switch s {
+	case MergeCommitStrategy, SquashStrategy, RebaseStrategy, FastForwardOnlyStrategy:
+		return true
+	default:
+		return false
+	}
+}
+
 type MergeRepoOpts struct {
 	PrId     string
 	PusherId string
 	Message  string
+	// 合并方式 默认为合并提交
+	Strategy MergeStrategy
+	// 不为空时使用该密钥签名服务端生成的提交
+	SignKeyId string
+}
+
+func (o *MergeRepoOpts) signArg() string {
+	if o.SignKeyId == "" {
+		return "--no-gpg-sign"
+	}
+	return "--gpg-sign=" + o.SignKeyId
 }
 
 func GetDiffCommitsInfo(ctx context.Context, repoPath, target, head string) (DiffCommitsInfo, error) {
@@ -141,12 +175,17 @@ func doMerge(ctx context.Context, repoPath string, pr DiffCommitsInfo, opts Merg
 	if _, err = command.NewCommand("read-tree", "HEAD").Run(ctx, command.WithDir(tempDir)); err != nil {
 		return err
 	}
-	if _, err = command.NewCommand("merge", "--no-ff", "--no-commit", TrackingBranch).
-		Run(ctx, command.WithDir(tempDir)); err != nil {
-		return fmt.Errorf("git merge err: %v", err)
+	switch opts.Strategy {
+	case SquashStrategy:
+		err = doSquashMerge(ctx, tempDir, opts)
+	case RebaseStrategy:
+		err = doRebaseMerge(ctx, tempDir)
+	case FastForwardOnlyStrategy:
+		err = doFastForwardMerge(ctx, tempDir)
+	default:
+		err = doMergeCommit(ctx, tempDir, opts)
 	}
-	mergeCmd := command.NewCommand("commit", "--no-gpg-sign", "-m", opts.Message)
-	if _, err = mergeCmd.Run(ctx, command.WithDir(tempDir)); err != nil {
+	if err != nil {
 		return err
 	}
 	if _, err = command.NewCommand("push", "origin", MergeBranch+":"+pr.Head).
@@ -166,6 +205,63 @@ func doMerge(ctx context.Context, repoPath string, pr DiffCommitsInfo, opts Merg
 	return nil
 }
 
+func doMergeCommit(ctx context.Context, tempDir string, opts MergeRepoOpts) error {
+	if _, err := command.NewCommand("merge", "--no-ff", "--no-commit", TrackingBranch).
+		Run(ctx, command.WithDir(tempDir)); err != nil {
+		return fmt.Errorf("git merge err: %v", err)
+	}
+	if _, err := command.NewCommand("commit", opts.signArg(), "-m", opts.Message).
+		Run(ctx, command.WithDir(tempDir)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func doSquashMerge(ctx context.Context, tempDir string, opts MergeRepoOpts) error {
+	if _, err := command.NewCommand("merge", "--squash", TrackingBranch).
+		Run(ctx, command.WithDir(tempDir)); err != nil {
+		return fmt.Errorf("git merge --squash err: %v", err)
+	}
+	if _, err := command.NewCommand("commit", opts.signArg(), "-m", opts.Message).
+		Run(ctx, command.WithDir(tempDir)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func doRebaseMerge(ctx context.Context, tempDir string) error {
+	// 在staging分支上变基
+	if _, err := command.NewCommand("checkout", "-b", StagingBranch, TrackingBranch).
+		Run(ctx, command.WithDir(tempDir)); err != nil {
+		return fmt.Errorf("git checkout staging err: %v", err)
+	}
+	if _, err := command.NewCommand("rebase", MergeBranch).
+		Run(ctx, command.WithDir(tempDir)); err != nil {
+		return &ErrMergeConflict{
+			err: fmt.Errorf("git rebase err: %v", err),
+		}
+	}
+	if _, err := command.NewCommand("checkout", MergeBranch).
+		Run(ctx, command.WithDir(tempDir)); err != nil {
+		return fmt.Errorf("git checkout base err: %v", err)
+	}
+	if _, err := command.NewCommand("merge", "--ff-only", StagingBranch).
+		Run(ctx, command.WithDir(tempDir)); err != nil {
+		return fmt.Errorf("git merge --ff-only err: %v", err)
+	}
+	return nil
+}
+
+func doFastForwardMerge(ctx context.Context, tempDir string) error {
+	if _, err := command.NewCommand("merge", "--ff-only", TrackingBranch).
+		Run(ctx, command.WithDir(tempDir)); err != nil {
+		return &ErrMergeNotFastForward{
+			err: fmt.Errorf("git merge --ff-only err: %v", err),
+		}
+	}
+	return nil
+}
+
 func prepare4Merge(ctx context.Context, repoPath string, tempDir string, pr DiffCommitsInfo) error {
 	if err := initEmptyRepository(ctx, tempDir, false); err != nil {
 		return err
@@ -0,0 +1,85 @@
+package git
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"zgit/pkg/git/command"
+)
+
+// GetFileContentWithEnv 读取隔离区中某个版本的文件内容
+func GetFileContentWithEnv(ctx context.Context, repoPath, rev, filePath string, env QuarantineEnv) (string, bool, error) {
+	name := rev + ":" + filePath
+	if _, err := command.NewCommand("cat-file", "-e", name).
+		Run(ctx, command.WithDir(repoPath), command.WithEnv(env.toEnv())); err != nil {
+		return "", false, nil
+	}
+	result, err := command.NewCommand("cat-file", "-p", name).
+		Run(ctx, command.WithDir(repoPath), command.WithEnv(env.toEnv()))
+	if err != nil {
+		return "", false, err
+	}
+	return result.ReadAsString(), true, nil
+}
+
+// RangePushAddedLines 遍历提交中新增的代码行 二进制文件和删除的行不处理
+func RangePushAddedLines(ctx context.Context, repoPath string, commitIdList []string, env QuarantineEnv, rangeFn func(filePath string, lineNo int, line string) error) error {
+	if len(commitIdList) == 0 {
+		return nil
+	}
+	pipeResult := command.NewCommand("diff-tree", "--stdin", "-r", "-p", "-U0", "--no-commit-id", "--root",
+		"--no-color", "--no-ext-diff", "--diff-filter=ACMR").
+		RunWithReadPipe(ctx,
+			command.WithDir(repoPath),
+			command.WithEnv(env.toEnv()),
+			command.WithStdin(strings.NewReader(strings.Join(commitIdList, "\n")+"\n")),
+		)
+	var (
+		filePath string
+		lineNo   int
+		inHunk   bool
+	)
+	return pipeResult.RangeStringLines(func(_ int, line string) (bool, error) {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			filePath = ""
+			inHunk = false
+		case !inHunk && strings.HasPrefix(line, "+++ "):
+			filePath = parseDiffFilePath(strings.TrimPrefix(line, "+++ "))
+		case strings.HasPrefix(line, "@@ "):
+			inHunk = true
+			lineNo = parseHunkNewStart(line)
+		case inHunk && strings.HasPrefix(line, "+"):
+			if filePath != "" {
+				if err := rangeFn(filePath, lineNo, line[1:]); err != nil {
+					return false, err
+				}
+			}
+			lineNo++
+		}
+		return true, nil
+	})
+}
+
+func parseDiffFilePath(name string) string {
+	if strings.HasPrefix(name, "\"") {
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			name = unquoted
+		}
+	}
+	if name == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(name, "b/")
+}
+
+// parseHunkNewStart 解析@@ -a,b +c,d @@中的c
+func parseHunkNewStart(line string) int {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0
+	}
+	start, _, _ := strings.Cut(strings.TrimPrefix(fields[2], "+"), ",")
+	ret, _ := strconv.Atoi(start)
+	return ret
+}
@@ -2,6 +2,8 @@ package git
 
 import (
 	"context"
+	"encoding/base64"
+	"strings"
 	"zgit/pkg/git/command"
 )
 
@@ -22,3 +24,115 @@ func RemoveRemote(ctx context.Context, repoPath, name string) error {
 	_, err := command.NewCommand("remote", "rm", name).Run(ctx, command.WithDir(repoPath))
 	return err
 }
+
+// RemoteAuth 远端http basic认证信息
+type RemoteAuth struct {
+	Username string
+	Password string
+}
+
+// toEnv 通过环境变量设置git配置 避免凭证写入仓库配置或出现在进程参数中
+// 禁止跟随重定向 防止认证信息被发送到重定向后的地址
+func (a RemoteAuth) toEnv() []string {
+	if a.Username == "" && a.Password == "" {
+		return []string{
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.followRedirects",
+			"GIT_CONFIG_VALUE_0=false",
+		}
+	}
+	token := base64.StdEncoding.EncodeToString([]byte(a.Username + ":" + a.Password))
+	return []string{
+		"GIT_CONFIG_COUNT=2",
+		"GIT_CONFIG_KEY_0=http.followRedirects",
+		"GIT_CONFIG_VALUE_0=false",
+		"GIT_CONFIG_KEY_1=http.extraHeader",
+		"GIT_CONFIG_VALUE_1=Authorization: Basic " + token,
+	}
+}
+
+// AddMirrorRemote 添加镜像远端 只同步分支和标签
+func AddMirrorRemote(ctx context.Context, repoPath, name, url string) error {
+	if err := AddRemote(ctx, repoPath, name, url, false); err != nil {
+		return err
+	}
+	key := "remote." + name + ".fetch"
+	_, err := command.NewCommand("config", "--replace-all", key, "+"+BranchPrefix+"*:"+BranchPrefix+"*").
+		Run(ctx, command.WithDir(repoPath))
+	if err != nil {
+		return err
+	}
+	_, err = command.NewCommand("config", "--add", key, "+"+TagPrefix+"*:"+TagPrefix+"*").
+		Run(ctx, command.WithDir(repoPath))
+	return err
+}
+
+// FetchRemote 拉取远端 prune会删除远端已不存在的分支和标签
+func FetchRemote(ctx context.Context, repoPath, name string, auth RemoteAuth, prune bool) error {
+	cmd := command.NewCommand("fetch")
+	if prune {
+		cmd.AddArgs("--prune")
+	}
+	_, err := cmd.AddArgs(name).Run(ctx, command.WithDir(repoPath), command.WithEnv(auth.toEnv()))
+	return err
+}
+
+// GetRemoteHeadBranch 获取远端默认分支 远端为空仓库时返回空
+func GetRemoteHeadBranch(ctx context.Context, repoPath, name string, auth RemoteAuth) (string, error) {
+	result, err := command.NewCommand("ls-remote", "--symref", name, "HEAD").
+		Run(ctx, command.WithDir(repoPath), command.WithEnv(auth.toEnv()))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(result.ReadAsString(), "\n") {
+		if !strings.HasPrefix(line, "ref: ") {
+			continue
+		}
+		ref, _, _ := strings.Cut(strings.TrimPrefix(line, "ref: "), "\t")
+		return strings.TrimPrefix(ref, BranchPrefix), nil
+	}
+	return "", nil
+}
+
+// PushMirror 将所有分支和标签镜像推送到远端 远端多余的引用会被删除
+func PushMirror(ctx context.Context, repoPath, url string, auth RemoteAuth) error {
+	_, err := command.NewCommand("push", "--mirror", url).
+		Run(ctx, command.WithDir(repoPath), command.WithEnv(auth.toEnv()))
+	return err
+}
+
+type ImportRepoOpts struct {
+	RepoPath  string
+	RemoteUrl string
+	Auth      RemoteAuth
+	// 是否保留远端用于镜像同步
+	IsMirror bool
+}
+
+// ImportRepository 从远端导入仓库 返回远端默认分支
+func ImportRepository(ctx context.Context, opts ImportRepoOpts) (string, error) {
+	if err := initEmptyRepository(ctx, opts.RepoPath, true); err != nil {
+		return "", err
+	}
+	if err := AddMirrorRemote(ctx, opts.RepoPath, DefaultRemote, opts.RemoteUrl); err != nil {
+		return "", err
+	}
+	if err := FetchRemote(ctx, opts.RepoPath, DefaultRemote, opts.Auth, false); err != nil {
+		return "", err
+	}
+	branch, err := GetRemoteHeadBranch(ctx, opts.RepoPath, DefaultRemote, opts.Auth)
+	if err != nil {
+		return "", err
+	}
+	if branch != "" {
+		if err = SetDefaultBranch(ctx, opts.RepoPath, branch); err != nil {
+			return "", err
+		}
+	}
+	if !opts.IsMirror {
+		if err = RemoveRemote(ctx, opts.RepoPath, DefaultRemote); err != nil {
+			return "", err
+		}
+	}
+	return branch, InitRepoHook(opts.RepoPath)
+}
@@ -45,22 +45,21 @@ func initEmptyRepository(ctx context.Context, repoPath string, bare bool) error
 		return errors.New("repoPath is empty")
 	}
 	logger.Logger.WithContext(ctx).Infof("init repo: %s", repoPath)
-	isExist, err := util.IsExist(repoPath)
-	if err != nil {
+	if err := os.MkdirAll(filepath.Dir(repoPath), os.ModePerm); err != nil {
 		return err
 	}
-	if isExist {
-		return fmt.Errorf("%s is exist", repoPath)
-	}
-	err = os.MkdirAll(repoPath, os.ModePerm)
-	if err != nil {
+	// Mkdir在目录已存在时失败 并发创建同一仓库只有一个能成功
+	if err := os.Mkdir(repoPath, os.ModePerm); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("%s is exist: %w", repoPath, os.ErrExist)
+		}
 		return err
 	}
 	cmd := command.NewCommand("init")
 	if bare {
 		cmd.AddArgs(command.BareFlag)
 	}
-	_, err = cmd.Run(ctx, command.WithDir(repoPath))
+	_, err := cmd.Run(ctx, command.WithDir(repoPath))
 	return err
 }
 
@@ -2,7 +2,10 @@ package signature
 
 import (
 	"bytes"
+	"errors"
 	"github.com/42wim/sshsig"
+	"github.com/42wim/sshsig/pem"
+	gossh "golang.org/x/crypto/ssh"
 	"io"
 )
 
@@ -23,3 +26,16 @@ func SignSshSignature(privateKey string, data io.Reader) (string, error) {
 	}
 	return string(sign), nil
 }
+
+// GetSshSignaturePublicKey 获取ssh签名中携带的公钥
+func GetSshSignaturePublicKey(sig string) (gossh.PublicKey, error) {
+	block, _ := pem.Decode([]byte(sig))
+	if block == nil {
+		return nil, errors.New("unable to decode ssh signature")
+	}
+	var wrappedSig sshsig.WrappedSig
+	if err := gossh.Unmarshal(block.Bytes, &wrappedSig); err != nil {
+		return nil, err
+	}
+	return gossh.ParsePublicKey([]byte(wrappedSig.PublicKey))
+}
@@ -2,10 +2,26 @@ package git
 
 import (
 	"context"
+	"fmt"
+	"github.com/LeeZXin/zsf-utils/idutil"
+	"path/filepath"
 	"strings"
 	"zgit/pkg/git/command"
+	"zgit/setting"
+	"zgit/util"
 )
 
+type CreateTagOpts struct {
+	PushRefOpts
+	Tag      string
+	CommitId string
+	// Message 不为空则创建附注标签
+	Message string
+	Tagger  User
+	// SignKeyId 不为空则用该密钥签名 签名标签一定是附注标签
+	SignKeyId string
+}
+
 func GetAllTagList(ctx context.Context, repoPath string) ([]string, error) {
 	cmd := command.NewCommand("tag")
 	pipeResult := cmd.RunWithReadPipe(ctx, command.WithDir(repoPath))
@@ -17,9 +33,43 @@ func GetAllTagList(ctx context.Context, repoPath string) ([]string, error) {
 	return ret, err
 }
 
-func DeleteTag(ctx context.Context, repoPath string, tag string) error {
-	_, err := command.NewCommand("tag", "-d", tag).Run(ctx, command.WithDir(repoPath))
-	return err
+// CreateTag 轻量标签直接推送 附注标签在临时仓库创建后推送
+func CreateTag(ctx context.Context, repoPath string, opts CreateTagOpts) error {
+	if opts.Message == "" && opts.SignKeyId == "" {
+		return pushRefs(ctx, repoPath, ".", opts.PushRefOpts, opts.CommitId+":"+TagPrefix+opts.Tag)
+	}
+	tempDir := filepath.Join(setting.TempDir(), "tag-"+idutil.RandomUuid())
+	defer util.RemoveAll(tempDir)
+	if _, err := command.NewCommand("clone", "-s", "--bare", "--no-tags", repoPath, tempDir).Run(ctx); err != nil {
+		return fmt.Errorf("clone tempDir:%s failed with err:%v", tempDir, err)
+	}
+	message := opts.Message
+	if message == "" {
+		message = opts.Tag
+	}
+	cmd := command.NewCommand("tag", "-a", "-m", message)
+	if opts.SignKeyId != "" {
+		cmd.AddArgs("-u", opts.SignKeyId)
+	}
+	cmd.AddArgs("--", opts.Tag, opts.CommitId)
+	// tagger取自committer
+	if _, err := cmd.Run(ctx,
+		command.WithDir(tempDir),
+		command.WithEnv(
+			util.JoinFields(
+				"GIT_COMMITTER_NAME", opts.Tagger.Account,
+				"GIT_COMMITTER_EMAIL", opts.Tagger.Email,
+			),
+		),
+	); err != nil {
+		return fmt.Errorf("create tag failed with err:%v", err)
+	}
+	return pushRefs(ctx, tempDir, DefaultRemote, opts.PushRefOpts, TagPrefix+opts.Tag+":"+TagPrefix+opts.Tag)
+}
+
+// DeleteTag 推送删除 保护标签不允许删除
+func DeleteTag(ctx context.Context, repoPath string, tag string, opts PushRefOpts) error {
+	return pushRefs(ctx, repoPath, ".", opts, ":"+TagPrefix+tag)
 }
 
 func CheckRefIsTag(ctx context.Context, repoPath string, tag string) bool {
@@ -28,3 +78,36 @@ func CheckRefIsTag(ctx context.Context, repoPath string, tag string) bool {
 	}
 	return CheckExists(ctx, repoPath, tag)
 }
+
+// GetPreviousTag head之前最近的标签 跳过指向head本身的标签 不存在返回false
+func GetPreviousTag(ctx context.Context, repoPath, head string) (string, bool, error) {
+	headCommitId, err := GetRefCommitId(ctx, repoPath, head+"^{commit}")
+	if err != nil {
+		return "", false, err
+	}
+	cmd := command.NewCommand("for-each-ref", "--format=%(objectname) %(*objectname) %(refname)", "--merged", headCommitId, "--sort=-creatordate", TagPrefix)
+	pipeResult := cmd.RunWithReadPipe(ctx, command.WithDir(repoPath))
+	var ret string
+	err = pipeResult.RangeStringLines(func(_ int, line string) (bool, error) {
+		fields := strings.Fields(line)
+		var commitId, refName string
+		switch len(fields) {
+		// 轻量标签没有*objectname
+		case 2:
+			commitId, refName = fields[0], fields[1]
+		case 3:
+			commitId, refName = fields[1], fields[2]
+		default:
+			return true, nil
+		}
+		if commitId == headCommitId {
+			return true, nil
+		}
+		ret = strings.TrimPrefix(refName, TagPrefix)
+		return false, nil
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return ret, ret != "", nil
+}
@@ -13,6 +13,8 @@ import (
 
 const (
 	WikiDefaultBranch = "master"
+	// WikiPageExt wiki页面文件后缀
+	WikiPageExt = ".md"
 )
 
 type Wiki struct {
@@ -31,7 +33,25 @@ func InitWiki(ctx context.Context, wiki Wiki) error {
 	})
 }
 
-func UpdateWikiPage(ctx context.Context, wikiPath, pageName, content, message string) error {
+// ListWikiPages 列出wiki根目录下的页面文件
+func ListWikiPages(ctx context.Context, wikiPath string) ([]TreeBlob, error) {
+	if !IsBranchExist(ctx, wikiPath, WikiDefaultBranch) {
+		return []TreeBlob{}, nil
+	}
+	blobs, err := ListTreeBlobs(ctx, wikiPath, BranchPrefix+WikiDefaultBranch)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]TreeBlob, 0, len(blobs))
+	for _, blob := range blobs {
+		if !strings.Contains(blob.Path, "/") && strings.HasSuffix(blob.Path, WikiPageExt) {
+			ret = append(ret, blob)
+		}
+	}
+	return ret, nil
+}
+
+func UpdateWikiPage(ctx context.Context, wikiPath, pageName, content, message string, author User) error {
 	tempDir := filepath.Join(setting.TempDir(), "wiki-"+idutil.RandomUuid())
 	defer util.RemoveAll(tempDir)
 	hasMasterBranch, err := prepareUpdateWikiPage(ctx, wikiPath, tempDir)
@@ -45,10 +65,10 @@ func UpdateWikiPage(ctx context.Context, wikiPath, pageName, content, message st
 	if err = AddObjectToIndex(ctx, tempDir, RegularFileMode.String(), object, pageName); err != nil {
 		return fmt.Errorf("addObjectToIndex failed with err:%v", err)
 	}
-	return afterUpdateWikiPage(ctx, tempDir, message, hasMasterBranch)
+	return afterUpdateWikiPage(ctx, tempDir, message, hasMasterBranch, author)
 }
 
-func DeleteWikiPage(ctx context.Context, wikiPath, pageName, message string) error {
+func DeleteWikiPage(ctx context.Context, wikiPath, pageName, message string, author User) error {
 	tempDir := filepath.Join(setting.TempDir(), "wiki-"+idutil.RandomUuid())
 	defer util.RemoveAll(tempDir)
 	hasMasterBranch, err := prepareUpdateWikiPage(ctx, wikiPath, tempDir)
@@ -58,16 +78,17 @@ func DeleteWikiPage(ctx context.Context, wikiPath, pageName, message string) err
 	if err = RemoveFilesFromIndex(ctx, tempDir, pageName); err != nil {
 		return fmt.Errorf("RemoveFilesFromIndex failed with err:%v", err)
 	}
-	return afterUpdateWikiPage(ctx, tempDir, message, hasMasterBranch)
+	return afterUpdateWikiPage(ctx, tempDir, message, hasMasterBranch, author)
 }
 
-func afterUpdateWikiPage(ctx context.Context, tempDir string, message string, hasMasterBranch bool) error {
+func afterUpdateWikiPage(ctx context.Context, tempDir string, message string, hasMasterBranch bool, author User) error {
 	tree, err := WriteTree(ctx, tempDir)
 	if err != nil {
 		return fmt.Errorf("write tree failed with err:%v", err)
 	}
 	opts := CommitTreeOpts{
 		Message: message,
+		Author:  author,
 	}
 	if hasMasterBranch {
 		opts.Parents = []string{"HEAD"}
@@ -76,8 +97,9 @@ func afterUpdateWikiPage(ctx context.Context, tempDir string, message string, ha
 	if err != nil {
 		return fmt.Errorf("commit tree failed with err:%v", err)
 	}
+	// wiki大小由调用方更新 不需要走钩子
 	if _, err = command.NewCommand("push", DefaultRemote, fmt.Sprintf("%s:%s", commitHash, BranchPrefix+WikiDefaultBranch)).
-		Run(ctx, command.WithDir(tempDir)); err != nil {
+		Run(ctx, command.WithDir(tempDir), command.WithEnv(util.JoinFields(EnvIsInternal, "true"))); err != nil {
 		return fmt.Errorf("push failed with err:%v", err)
 	}
 	return nil
@@ -5,6 +5,11 @@ const (
 	ApiPostReceiveUrl = "api/internal/hook/post-receive"
 )
 
+const (
+	// PushOptionSkipSecretScan 管理员跳过密钥扫描 git push -o secret-scan.skip
+	PushOptionSkipSecretScan = "secret-scan.skip"
+)
+
 type RevInfo struct {
 	OldCommitId string `json:"oldCommitId"`
 	NewCommitId string `json:"newCommitId"`
@@ -19,4 +24,6 @@ type Opts struct {
 	ObjectDirectory              string    `json:"objectDirectory"`
 	AlternativeObjectDirectories string    `json:"alternativeObjectDirectories"`
 	QuarantinePath               string    `json:"quarantinePath"`
+	PushOptions                  []string  `json:"pushOptions"`
+	IsWiki                       bool      `json:"isWiki"`
 }
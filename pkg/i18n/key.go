@@ -67,6 +67,34 @@ const (
 	RepoInvalidGitIgnoreName Key = "repo.invalidGitIgnore"
 
 	RepoCountOutOfLimit Key = "repo.countOutOfLimit"
+
+	RepoImportFailed        Key = "repo.importFailed"
+	RepoMirrorNotAllowPush  Key = "repo.mirrorNotAllowPush"
+	RepoMirrorIsSyncing     Key = "repo.mirrorIsSyncing"
+	RepoRemoteUrlNotAllowed Key = "repo.remoteUrlNotAllowed"
+
+	RepoPushMirrorCountOutOfLimit Key = "repo.pushMirrorCountOutOfLimit"
+
+	RepoBranchOutOfDate             Key = "repo.branchOutOfDate"
+	RepoBranchAlreadyExists         Key = "repo.branchAlreadyExists"
+	RepoFileNotFoundWarnFormat      Key = "repo.fileNotFoundWarnFormat"
+	RepoFileAlreadyExistsWarnFormat Key = "repo.fileAlreadyExistsWarnFormat"
+	RepoPushRejectedWarnFormat      Key = "repo.pushRejectedWarnFormat"
+	RepoDefaultBranchNotAllowDelete Key = "repo.defaultBranchNotAllowDelete"
+	RepoTagAlreadyExists            Key = "repo.tagAlreadyExists"
+	RepoSignKeyNotConfigured        Key = "repo.signKeyNotConfigured"
+)
+
+const (
+	WikiPageNotFound Key = "wiki.pageNotFound"
+)
+
+const (
+	ReleaseAlreadyExists     Key = "release.alreadyExists"
+	ReleaseAssetNameConflict Key = "release.assetNameConflict"
+	ReleaseAssetTooLarge     Key = "release.assetTooLarge"
+	ReleaseChangelogTitle    Key = "release.changelogTitle"
+	ReleaseChangelogCompare  Key = "release.changelogCompare"
 )
 
 const (
@@ -97,11 +125,24 @@ const (
 	PullRequestUnknownStatus Key = "pullRequest.unknownStatus"
 	PullRequestMergeMessage  Key = "pullRequest.mergeMessage"
 
+	PullRequestCannotFastForward Key = "pullRequest.cannotFastForward"
+
 	PullRequestAgreeMergeStatus    Key = "pullRequest.agreeMerge"
 	PullRequestDisagreeMergeStatus Key = "pullRequest.disagreeMerge"
 	PullRequestUnknownReviewStatus Key = "pullRequest.unknownReviewStatus"
 
 	PullRequestReviewerCountLowerThanCfg Key = "pullRequest.reviewerCountLowerThanCfg"
+
+	PullRequestRequiredStatusCheckFailed Key = "pullRequest.requiredStatusCheckFailed"
+	PullRequestCodeOwnerNotApproved      Key = "pullRequest.codeOwnerNotApproved"
+)
+
+const (
+	CommitStatusPendingState Key = "commitStatus.pendingState"
+	CommitStatusSuccessState Key = "commitStatus.successState"
+	CommitStatusFailureState Key = "commitStatus.failureState"
+	CommitStatusErrorState   Key = "commitStatus.errorState"
+	CommitStatusUnknownState Key = "commitStatus.unknownState"
 )
 
 const (
@@ -115,6 +156,42 @@ const (
 	ProtectedBranchNotAllowForcePush              Key = "protectedBranch.notAllowForcePush"
 	ProtectedBranchNotAllowDelete                 Key = "protectedBranch.notAllowDelete"
 	ProtectedBranchNotAllowDirectPush             Key = "protectedBranch.notAllowDirectPush"
+	ProtectedBranchNotAllowMergeStrategy          Key = "protectedBranch.notAllowMergeStrategy"
+	ProtectedBranchUnsignedCommitWarnFormat       Key = "protectedBranch.unsignedCommitWarnFormat"
+	ProtectedBranchSignedCommitsNotAllowRebase    Key = "protectedBranch.signedCommitsNotAllowRebase"
+)
+
+const (
+	ProtectedTagNotAllowCreate Key = "protectedTag.notAllowCreate"
+	ProtectedTagNotAllowUpdate Key = "protectedTag.notAllowUpdate"
+	ProtectedTagNotAllowDelete Key = "protectedTag.notAllowDelete"
+)
+
+const (
+	PushRuleInvalidCommitMessagePattern     Key = "pushRule.invalidCommitMessagePattern"
+	PushRuleCommitMessageNotMatchWarnFormat Key = "pushRule.commitMessageNotMatchWarnFormat"
+	PushRuleCommitEmailNotMatchWarnFormat   Key = "pushRule.commitEmailNotMatchWarnFormat"
+	PushRuleBlobSizeExceedWarnFormat        Key = "pushRule.blobSizeExceedWarnFormat"
+	PushRuleForbiddenPathWarnFormat         Key = "pushRule.forbiddenPathWarnFormat"
+	PushRuleBranchNameNotAllowedWarnFormat  Key = "pushRule.branchNameNotAllowedWarnFormat"
+)
+
+const (
+	SecretScanDetectedWarnFormat Key = "secretScan.detectedWarnFormat"
+	SecretScanSkipNotAllowed     Key = "secretScan.skipNotAllowed"
+)
+
+const (
+	QuotaRepoGitSizeExceedWarnFormat Key = "quota.repoGitSizeExceedWarnFormat"
+	QuotaRepoLfsSizeExceedWarnFormat Key = "quota.repoLfsSizeExceedWarnFormat"
+	QuotaCorpGitSizeExceedWarnFormat Key = "quota.corpGitSizeExceedWarnFormat"
+	QuotaCorpLfsSizeExceedWarnFormat Key = "quota.corpLfsSizeExceedWarnFormat"
+	QuotaRepoCountExceedWarnFormat   Key = "quota.repoCountExceedWarnFormat"
+)
+
+const (
+	GpgKeyFormatError   Key = "gpgKey.formatErr"
+	GpgKeyAlreadyExists Key = "gpgKey.alreadyExists"
 )
 
 const (
@@ -169,6 +246,29 @@ var (
 		RepoNotFound:             "仓库不存在",
 		RepoCountOutOfLimit:      "仓库数量大于上限",
 		RepoInvalidId:            "仓库id不合法",
+		RepoImportFailed:         "仓库导入失败 请检查远端地址和凭证",
+		RepoMirrorNotAllowPush:   "镜像仓库不允许推送",
+		RepoMirrorIsSyncing:      "镜像仓库正在同步",
+		RepoRemoteUrlNotAllowed:  "不允许使用内网远端地址",
+
+		RepoPushMirrorCountOutOfLimit: "推送镜像数量大于上限",
+
+		RepoBranchOutOfDate:             "分支已有新的提交 请刷新后重试",
+		RepoBranchAlreadyExists:         "分支已存在",
+		RepoFileNotFoundWarnFormat:      "文件不存在: %s",
+		RepoFileAlreadyExistsWarnFormat: "文件已存在: %s",
+		RepoPushRejectedWarnFormat:      "推送被拒绝: %s",
+		RepoDefaultBranchNotAllowDelete: "默认分支不允许删除",
+		RepoTagAlreadyExists:            "标签已存在",
+		RepoSignKeyNotConfigured:        "服务端未配置签名密钥",
+
+		WikiPageNotFound: "wiki页面不存在",
+
+		ReleaseAlreadyExists:     "该标签已有发布版本",
+		ReleaseAssetNameConflict: "附件名称已存在",
+		ReleaseAssetTooLarge:     "附件大小超过限制",
+		ReleaseChangelogTitle:    "变更记录",
+		ReleaseChangelogCompare:  "对比",
 
 		CorpEmptyId: "公司id为空",
 
@@ -210,12 +310,49 @@ var (
 		ProtectedBranchNotAllowDelete:     "保护分支禁止删除",
 		ProtectedBranchNotAllowDirectPush: "保护分支不可直接push",
 
+		ProtectedBranchNotAllowMergeStrategy:       "保护分支不允许该合并方式",
+		ProtectedBranchUnsignedCommitWarnFormat:    "保护分支要求提交签名, 提交%s未签名或签名无效",
+		ProtectedBranchSignedCommitsNotAllowRebase: "保护分支要求提交签名, 变基合并会丢失原有签名",
+		PullRequestCannotFastForward:               "无法快进合并",
+
+		ProtectedTagNotAllowCreate: "无权限创建保护标签",
+		ProtectedTagNotAllowUpdate: "保护标签禁止修改",
+		ProtectedTagNotAllowDelete: "保护标签禁止删除",
+
+		GpgKeyFormatError:   "gpg公钥格式错误",
+		GpgKeyAlreadyExists: "gpg公钥已存在",
+
+		PushRuleInvalidCommitMessagePattern:     "提交信息正则表达式不合法",
+		PushRuleCommitMessageNotMatchWarnFormat: "提交%s的提交信息不符合推送规则",
+		PushRuleCommitEmailNotMatchWarnFormat:   "提交%s的作者或提交者邮箱与推送人邮箱不一致",
+		PushRuleBlobSizeExceedWarnFormat:        "文件%s超过推送规则的大小限制",
+		PushRuleForbiddenPathWarnFormat:         "推送规则禁止提交文件%s",
+		PushRuleBranchNameNotAllowedWarnFormat:  "推送规则不允许新建分支%s",
+
+		SecretScanDetectedWarnFormat: "推送内容中发现疑似密钥%s, 如为误报请配置.zgit/secret-allowlist",
+		SecretScanSkipNotAllowed:     "只有管理员可以跳过密钥扫描",
+
+		QuotaRepoGitSizeExceedWarnFormat: "超过仓库大小限制%s",
+		QuotaRepoLfsSizeExceedWarnFormat: "超过仓库lfs大小限制%s",
+		QuotaCorpGitSizeExceedWarnFormat: "超过企业仓库总大小配额%s",
+		QuotaCorpLfsSizeExceedWarnFormat: "超过企业lfs总大小配额%s",
+		QuotaRepoCountExceedWarnFormat:   "仓库数量已达企业上限%s",
+
 		PullRequestAgreeMergeStatus:    "同意合并",
 		PullRequestDisagreeMergeStatus: "不同意合并",
 		PullRequestUnknownReviewStatus: "未知状态",
 
 		PullRequestReviewerCountLowerThanCfg: "代码评审数量小于配置数量",
 
+		PullRequestRequiredStatusCheckFailed: "必需的状态检查未通过",
+		PullRequestCodeOwnerNotApproved:      "代码所有者未全部同意",
+
+		CommitStatusPendingState: "等待中",
+		CommitStatusSuccessState: "成功",
+		CommitStatusFailureState: "失败",
+		CommitStatusErrorState:   "错误",
+		CommitStatusUnknownState: "未知状态",
+
 		LfsNotSupported: "不支持lfs",
 
 		LfsExceedSingleFileLimitSize: "%s 文件大小：%s, 超过配置大小: %s",
@@ -0,0 +1,99 @@
+package markdown
+
+import (
+	"bytes"
+	"github.com/russross/blackfriday/v2"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	htmlFlags = blackfriday.CommonHTMLFlags |
+		// 不输出原始html 防止xss
+		blackfriday.SkipHTML |
+		blackfriday.Safelink
+)
+
+var (
+	// [[页面]] 或 [[页面|显示文本]]
+	wikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]|\n]+)(?:\|([^\[\]\n]+))?]]`)
+)
+
+// Render 渲染为html
+func Render(content string) string {
+	return string(render(parse(content)))
+}
+
+// RenderWiki 渲染wiki页面 [[页面]]和不带协议的相对链接指向同级wiki页面
+func RenderWiki(content string) string {
+	root := parse(replaceWikiLinks(content))
+	root.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && node.Type == blackfriday.Link {
+			node.LinkData.Destination = toWikiPageLink(node.LinkData.Destination)
+		}
+		return blackfriday.GoToNext
+	})
+	return string(render(root))
+}
+
+func parse(content string) *blackfriday.Node {
+	return blackfriday.New(blackfriday.WithExtensions(blackfriday.CommonExtensions)).
+		Parse([]byte(content))
+}
+
+func render(root *blackfriday.Node) []byte {
+	renderer := blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{
+		Flags: htmlFlags,
+	})
+	buf := new(bytes.Buffer)
+	renderer.RenderHeader(buf, root)
+	root.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		return renderer.RenderNode(buf, node, entering)
+	})
+	renderer.RenderFooter(buf, root)
+	return buf.Bytes()
+}
+
+// replaceWikiLinks [[页面]]转换为markdown链接 代码块内的不处理
+func replaceWikiLinks(content string) string {
+	lines := strings.Split(content, "\n")
+	inCodeBlock := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock || !strings.Contains(line, "[[") {
+			continue
+		}
+		lines[i] = wikiLinkPattern.ReplaceAllStringFunc(line, func(s string) string {
+			match := wikiLinkPattern.FindStringSubmatch(s)
+			page := strings.TrimSpace(match[1])
+			text := strings.TrimSpace(match[2])
+			if text == "" {
+				text = page
+			}
+			return "[" + text + "](" + url.PathEscape(page) + ")"
+		})
+	}
+	return strings.Join(lines, "\n")
+}
+
+// toWikiPageLink 页面链接去掉.md后缀 加上./使其通过安全链接检查
+func toWikiPageLink(dest []byte) []byte {
+	link := string(dest)
+	if link == "" || strings.HasPrefix(link, "#") || strings.HasPrefix(link, "/") || strings.HasPrefix(link, ".") {
+		return dest
+	}
+	if u, err := url.Parse(link); err != nil || u.Scheme != "" || u.Host != "" {
+		return dest
+	}
+	page, anchor, _ := strings.Cut(link, "#")
+	page = strings.TrimSuffix(page, ".md")
+	if anchor != "" {
+		page += "#" + anchor
+	}
+	return []byte("./" + page)
+}
@@ -0,0 +1,80 @@
+package secretscan
+
+import (
+	"math"
+	"regexp"
+)
+
+const (
+	// DefaultMinEntropy 通用密钥的最小香农熵 低于该值视为普通字符串
+	DefaultMinEntropy = 3.5
+)
+
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// 取匹配的第几个分组作为密钥 0为整个匹配
+	SecretGroup int
+	// 大于0时需检查密钥的香农熵
+	MinEntropy float64
+}
+
+var DefaultRules = []Rule{
+	{
+		Name:    "private-key",
+		Pattern: regexp.MustCompile(`-----BEGIN (?:RSA |DSA |EC |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`),
+	},
+	{
+		Name:    "aws-access-key-id",
+		Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA)[0-9A-Z]{16}\b`),
+	},
+	{
+		Name:    "gcp-api-key",
+		Pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`),
+	},
+	{
+		Name:    "alibaba-access-key-id",
+		Pattern: regexp.MustCompile(`\bLTAI[0-9A-Za-z]{12,20}\b`),
+	},
+	{
+		Name:    "tencent-secret-id",
+		Pattern: regexp.MustCompile(`\bAKID[0-9A-Za-z]{32}\b`),
+	},
+	{
+		Name:    "github-token",
+		Pattern: regexp.MustCompile(`\b(?:gh[pousr]_[0-9A-Za-z]{36,}|github_pat_[0-9A-Za-z_]{82})\b`),
+	},
+	{
+		Name:    "slack-token",
+		Pattern: regexp.MustCompile(`\bxox[abposr]-[0-9A-Za-z\-]{10,}\b`),
+	},
+	{
+		Name:    "jwt",
+		Pattern: regexp.MustCompile(`\beyJ[0-9A-Za-z_\-]{10,}\.eyJ[0-9A-Za-z_\-]{10,}\.[0-9A-Za-z_\-]{10,}`),
+	},
+	{
+		Name:        "generic-secret",
+		Pattern:     regexp.MustCompile(`(?i)(?:secret|token|passwd|password|api[_\-]?key|access[_\-]?key|private[_\-]?key)[\w\-]*["']?\s*[:=]{1,2}\s*["']?([0-9A-Za-z+/=_\-.]{20,})`),
+		SecretGroup: 1,
+		MinEntropy:  DefaultMinEntropy,
+	},
+}
+
+// ShannonEntropy 计算字符串每个字符的香农熵
+func ShannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	total := 0
+	for _, c := range s {
+		counts[c]++
+		total++
+	}
+	ret := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		ret -= p * math.Log2(p)
+	}
+	return ret
+}
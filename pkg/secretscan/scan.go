@@ -0,0 +1,121 @@
+package secretscan
+
+import (
+	"github.com/IGLOU-EU/go-wildcard/v2"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	// AllowlistFilePath 仓库中的白名单文件
+	AllowlistFilePath = ".zgit/secret-allowlist"
+	// AllowlistPathPrefix 白名单中以path:开头的为文件路径通配符 其余为密钥正则表达式
+	AllowlistPathPrefix = "path:"
+)
+
+type Finding struct {
+	RuleName string
+	FilePath string
+	LineNo   int
+	Secret   string
+}
+
+type Allowlist struct {
+	pathPatterns   []string
+	secretPatterns []*regexp.Regexp
+}
+
+// ParseAllowlist 解析白名单 每行一条 #开头为注释
+func ParseAllowlist(content string) *Allowlist {
+	ret := new(Allowlist)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, AllowlistPathPrefix) {
+			if pattern := strings.TrimSpace(strings.TrimPrefix(line, AllowlistPathPrefix)); pattern != "" {
+				ret.pathPatterns = append(ret.pathPatterns, pattern)
+			}
+			continue
+		}
+		if pattern, err := regexp.Compile(line); err == nil {
+			ret.secretPatterns = append(ret.secretPatterns, pattern)
+		}
+	}
+	return ret
+}
+
+func (a *Allowlist) IsPathAllowed(filePath string) bool {
+	if a == nil {
+		return false
+	}
+	for _, pattern := range a.pathPatterns {
+		if wildcard.Match(pattern, filePath) || wildcard.Match(pattern, path.Base(filePath)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Allowlist) IsSecretAllowed(secret string) bool {
+	if a == nil {
+		return false
+	}
+	for _, pattern := range a.secretPatterns {
+		if pattern.MatchString(secret) {
+			return true
+		}
+	}
+	return false
+}
+
+type Scanner struct {
+	rules     []Rule
+	allowlist *Allowlist
+}
+
+func NewScanner(rules []Rule, allowlist *Allowlist) *Scanner {
+	return &Scanner{
+		rules:     rules,
+		allowlist: allowlist,
+	}
+}
+
+// ScanLine 扫描单行内容
+func (s *Scanner) ScanLine(filePath string, lineNo int, line string) []Finding {
+	if s.allowlist.IsPathAllowed(filePath) {
+		return nil
+	}
+	var ret []Finding
+	for _, rule := range s.rules {
+		for _, match := range rule.Pattern.FindAllStringSubmatch(line, -1) {
+			if rule.SecretGroup >= len(match) {
+				continue
+			}
+			secret := match[rule.SecretGroup]
+			if rule.MinEntropy > 0 && ShannonEntropy(secret) < rule.MinEntropy {
+				continue
+			}
+			if s.allowlist.IsSecretAllowed(secret) {
+				continue
+			}
+			ret = append(ret, Finding{
+				RuleName: rule.Name,
+				FilePath: filePath,
+				LineNo:   lineNo,
+				Secret:   secret,
+			})
+		}
+	}
+	return ret
+}
+
+// MaskSecret 隐藏密钥中间部分 避免在日志或提示中泄露
+func MaskSecret(secret string) string {
+	if len(secret) <= 8 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:4] + strings.Repeat("*", len(secret)-8) + secret[len(secret)-4:]
+}
@@ -0,0 +1,73 @@
+package webhook
+
+import "time"
+
+type Event string
+
+const (
+	// PushEvent 分支推送
+	PushEvent Event = "push"
+	// TagEvent 标签创建或删除
+	TagEvent Event = "tag"
+	// PrOpenedEvent 合并请求创建
+	PrOpenedEvent Event = "prOpened"
+	// PrMergedEvent 合并请求合并
+	PrMergedEvent Event = "prMerged"
+	// PrClosedEvent 合并请求关闭
+	PrClosedEvent Event = "prClosed"
+	// ReviewEvent 合并请求评审
+	ReviewEvent Event = "review"
+)
+
+func (e Event) IsValid() bool {
+	switch e {
+	case PushEvent, TagEvent, PrOpenedEvent, PrMergedEvent, PrClosedEvent, ReviewEvent:
+		return true
+	default:
+		return false
+	}
+}
+
+type RepoInfo struct {
+	RepoId    string `json:"repoId"`
+	Name      string `json:"name"`
+	ProjectId string `json:"projectId"`
+}
+
+type PushPayload struct {
+	Repo      RepoInfo  `json:"repo"`
+	Ref       string    `json:"ref"`
+	Before    string    `json:"before"`
+	After     string    `json:"after"`
+	IsCreated bool      `json:"isCreated"`
+	IsDeleted bool      `json:"isDeleted"`
+	Pusher    string    `json:"pusher"`
+	EventTime time.Time `json:"eventTime"`
+}
+
+type PullRequestInfo struct {
+	PrId           string `json:"prId"`
+	Title          string `json:"title"`
+	Target         string `json:"target"`
+	TargetCommitId string `json:"targetCommitId"`
+	Head           string `json:"head"`
+	HeadCommitId   string `json:"headCommitId"`
+	CreateBy       string `json:"createBy"`
+}
+
+type PullRequestPayload struct {
+	Repo        RepoInfo        `json:"repo"`
+	PullRequest PullRequestInfo `json:"pullRequest"`
+	Operator    string          `json:"operator"`
+	EventTime   time.Time       `json:"eventTime"`
+}
+
+type ReviewPayload struct {
+	Repo        RepoInfo        `json:"repo"`
+	PullRequest PullRequestInfo `json:"pullRequest"`
+	Reviewer    string          `json:"reviewer"`
+	ReviewMsg   string          `json:"reviewMsg"`
+	// 是否同意合并
+	Agree     bool      `json:"agree"`
+	EventTime time.Time `json:"eventTime"`
+}
@@ -0,0 +1,46 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+)
+
+const (
+	JsonContentType = "application/json"
+	FormContentType = "application/x-www-form-urlencoded"
+)
+
+const (
+	EventHeader     = "X-Zgit-Event"
+	DeliveryHeader  = "X-Zgit-Delivery"
+	SignatureHeader = "X-Zgit-Signature-256"
+)
+
+func IsContentTypeValid(contentType string) bool {
+	return contentType == JsonContentType || contentType == FormContentType
+}
+
+// BuildRequestBody 根据contentType构造请求体 form表单格式payload放在payload字段中
+func BuildRequestBody(contentType string, payload any) ([]byte, error) {
+	m, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	if contentType == FormContentType {
+		return []byte(url.Values{"payload": {string(m)}}.Encode()), nil
+	}
+	return m, nil
+}
+
+// Sign 使用hmac-sha256对请求体签名 secret为空时不签名
+func Sign(secret string, body []byte) string {
+	if secret == "" {
+		return ""
+	}
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(body)
+	return "sha256=" + hex.EncodeToString(h.Sum(nil))
+}
@@ -16,7 +16,7 @@ import (
 var (
 	dataDir, homeDir, appPath, repoDir string
 
-	tempDir, lfsDir, avatarDir string
+	tempDir, lfsDir, avatarDir, archiveDir, codeIndexDir string
 
 	appUrl = strings.TrimSuffix(static.GetString("app.url"), "/")
 
@@ -42,6 +42,8 @@ func init() {
 	tempDir = filepath.Join(dataDir, "temp")
 	lfsDir = filepath.Join(dataDir, "lfs")
 	avatarDir = filepath.Join(dataDir, "avatar")
+	archiveDir = filepath.Join(dataDir, "archive")
+	codeIndexDir = filepath.Join(dataDir, "codeindex")
 	err = os.MkdirAll(homeDir, os.ModePerm)
 	if err != nil {
 		logger.Logger.Panicf("zgit os.MkdirAll homeDir err: %v", err)
@@ -62,6 +64,14 @@ func init() {
 	if err != nil {
 		logger.Logger.Panicf("zgit os.MkdirAll avatarDir err: %v", err)
 	}
+	err = os.MkdirAll(archiveDir, os.ModePerm)
+	if err != nil {
+		logger.Logger.Panicf("zgit os.MkdirAll archiveDir err: %v", err)
+	}
+	err = os.MkdirAll(codeIndexDir, os.ModePerm)
+	if err != nil {
+		logger.Logger.Panicf("zgit os.MkdirAll codeIndexDir err: %v", err)
+	}
 	path, err := getAppPath()
 	if err != nil {
 		logger.Logger.Panicf("zgit getAppPath err: %v", err)
@@ -138,6 +148,16 @@ func LfsDir() string {
 	return lfsDir
 }
 
+// ArchiveDir 仓库代码压缩包缓存目录
+func ArchiveDir() string {
+	return archiveDir
+}
+
+// CodeIndexDir 代码搜索索引目录
+func CodeIndexDir() string {
+	return codeIndexDir
+}
+
 func Lang() string {
 	return lang
 }
@@ -0,0 +1,51 @@
+package setting
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/property/static"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	secretKeyOnce  sync.Once
+	secretKeyBytes []byte
+)
+
+// SecretKeyBytes 加密敏感数据的密钥 未配置app.secretKey时生成并保存在data/secret.key中
+func SecretKeyBytes() []byte {
+	secretKeyOnce.Do(func() {
+		key := static.GetString("app.secretKey")
+		if key == "" {
+			var err error
+			key, err = loadOrCreateSecretKey(filepath.Join(DataDir(), "secret.key"))
+			if err != nil {
+				logger.Logger.Panicf("zgit load secret key err: %v", err)
+			}
+		}
+		h := sha256.Sum256([]byte(key))
+		secretKeyBytes = h[:]
+	})
+	return secretKeyBytes
+}
+
+func loadOrCreateSecretKey(keyPath string) (string, error) {
+	content, err := os.ReadFile(keyPath)
+	if err == nil {
+		return strings.TrimSpace(string(content)), nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	bytes := make([]byte, 32)
+	if _, err = rand.Read(bytes); err != nil {
+		return "", err
+	}
+	key := base64.RawURLEncoding.EncodeToString(bytes)
+	return key, os.WriteFile(keyPath, []byte(key), 0o600)
+}
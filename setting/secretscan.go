@@ -0,0 +1,11 @@
+package setting
+
+import "github.com/LeeZXin/zsf/property/static"
+
+var (
+	secretScanEnabled = static.GetBool("secretScan.enabled")
+)
+
+func SecretScanEnabled() bool {
+	return secretScanEnabled
+}
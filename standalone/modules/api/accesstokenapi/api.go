@@ -0,0 +1,99 @@
+package accesstokenapi
+
+import (
+	"github.com/LeeZXin/zsf-utils/ginutil"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf-utils/timeutil"
+	"github.com/LeeZXin/zsf/http/httpserver"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"zgit/standalone/modules/api/apicommon"
+	"zgit/standalone/modules/model/accesstokenmd"
+	"zgit/standalone/modules/service/accesstokensrv"
+	"zgit/util"
+)
+
+func InitApi() {
+	httpserver.AppendRegisterRouterFunc(func(e *gin.Engine) {
+		group := e.Group("/api/accessToken", apicommon.CheckLogin, apicommon.ForbidAccessToken)
+		{
+			// 创建
+			group.POST("/insert", insertAccessToken)
+			// 删除
+			group.POST("/delete", deleteAccessToken)
+			// 列表展示
+			group.POST("/list", listAccessToken)
+		}
+	})
+}
+
+func insertAccessToken(c *gin.Context) {
+	var req InsertAccessTokenReqVO
+	if util.ShouldBindJSON(&req, c) {
+		scopes, _ := listutil.Map(req.Scopes, func(t string) (accesstokenmd.Scope, error) {
+			return accesstokenmd.Scope(t), nil
+		})
+		token, err := accesstokensrv.InsertAccessToken(c.Request.Context(), accesstokensrv.InsertAccessTokenReqDTO{
+			Name:     req.Name,
+			Scopes:   scopes,
+			ExpireAt: req.ExpireAt,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, InsertAccessTokenRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+			Token:    token,
+		})
+	}
+}
+
+func deleteAccessToken(c *gin.Context) {
+	var req DeleteAccessTokenReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := accesstokensrv.DeleteAccessToken(c.Request.Context(), accesstokensrv.DeleteAccessTokenReqDTO{
+			TokenId:  req.TokenId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func listAccessToken(c *gin.Context) {
+	var req ListAccessTokenReqVO
+	if util.ShouldBindJSON(&req, c) {
+		respDTO, err := accesstokensrv.ListAccessToken(c.Request.Context(), accesstokensrv.ListAccessTokenReqDTO{
+			Offset:   req.Offset,
+			Limit:    req.Limit,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		ret := ListAccessTokenRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+			Cursor:   respDTO.Cursor,
+		}
+		ret.Data, _ = listutil.Map(respDTO.TokenList, func(t accesstokensrv.AccessTokenDTO) (AccessTokenVO, error) {
+			scopes, _ := listutil.Map(t.Scopes, func(s accesstokenmd.Scope) (string, error) {
+				return string(s), nil
+			})
+			return AccessTokenVO{
+				TokenId:  t.TokenId,
+				Name:     t.Name,
+				Scopes:   scopes,
+				ExpireAt: t.ExpireAt,
+				LastUsed: t.LastUsed,
+				Created:  t.Created.Format(timeutil.DefaultTimeFormat),
+			}, nil
+		})
+		c.JSON(http.StatusOK, ret)
+	}
+}
@@ -0,0 +1,39 @@
+package accesstokenapi
+
+import "github.com/LeeZXin/zsf-utils/ginutil"
+
+type InsertAccessTokenReqVO struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// 过期时间 毫秒时间戳 0为永不过期
+	ExpireAt int64 `json:"expireAt"`
+}
+
+type InsertAccessTokenRespVO struct {
+	ginutil.BaseResp
+	Token string `json:"token"`
+}
+
+type DeleteAccessTokenReqVO struct {
+	TokenId string `json:"tokenId"`
+}
+
+type ListAccessTokenReqVO struct {
+	Offset int64 `json:"offset"`
+	Limit  int   `json:"limit"`
+}
+
+type AccessTokenVO struct {
+	TokenId  string   `json:"tokenId"`
+	Name     string   `json:"name"`
+	Scopes   []string `json:"scopes"`
+	ExpireAt int64    `json:"expireAt"`
+	LastUsed int64    `json:"lastUsed"`
+	Created  string   `json:"created"`
+}
+
+type ListAccessTokenRespVO struct {
+	ginutil.BaseResp
+	Data   []AccessTokenVO `json:"data"`
+	Cursor int64           `json:"cursor"`
+}
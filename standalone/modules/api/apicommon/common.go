@@ -5,17 +5,21 @@ import (
 	"github.com/LeeZXin/zsf/logger"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strings"
 	"time"
 	"zgit/pkg/apicode"
 	"zgit/pkg/apisession"
 	"zgit/pkg/i18n"
+	"zgit/standalone/modules/model/accesstokenmd"
 	"zgit/standalone/modules/model/usermd"
+	"zgit/standalone/modules/service/accesstokensrv"
 )
 
 const (
 	LoginUser           = "loginUser"
 	LoginCookie         = "zgit-auth"
 	AuthorizationHeader = "Authorization"
+	AccessToken         = "accessToken"
 )
 
 func CheckLogin(c *gin.Context) {
@@ -28,6 +32,11 @@ func CheckLogin(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	// 个人访问令牌
+	if accesstokenmd.IsAccessToken(sessionId) {
+		checkAccessToken(c, sessionId)
+		return
+	}
 	sessionStore := apisession.GetStore()
 	session, b, err := sessionStore.GetBySessionId(sessionId)
 	if err != nil {
@@ -57,6 +66,48 @@ func CheckLogin(c *gin.Context) {
 	c.Next()
 }
 
+func checkAccessToken(c *gin.Context, token string) {
+	info, user, b, err := accesstokensrv.CheckAccessToken(c.Request.Context(), token)
+	if err != nil {
+		logger.Logger.WithContext(c.Request.Context()).Error(err)
+		c.JSON(http.StatusInternalServerError, ginutil.BaseResp{
+			Code:    apicode.InternalErrorCode.Int(),
+			Message: i18n.GetByKey(i18n.SystemInternalError),
+		})
+		c.Abort()
+		return
+	}
+	if !b {
+		c.JSON(http.StatusUnauthorized, ginutil.BaseResp{
+			Code:    apicode.NotLoginCode.Int(),
+			Message: i18n.GetByKey(i18n.SystemNotLogin),
+		})
+		c.Abort()
+		return
+	}
+	// 令牌权限由路由声明
+	if !checkTokenScope(c, info) {
+		c.JSON(http.StatusForbidden, ginutil.BaseResp{
+			Code:    apicode.UnauthorizedCode.Int(),
+			Message: i18n.GetByKey(i18n.SystemUnauthorized),
+		})
+		c.Abort()
+		return
+	}
+	c.Set(LoginUser, user)
+	c.Set(AccessToken, info)
+	c.Next()
+}
+
+// GetAccessToken 获取通过个人访问令牌登录的token信息
+func GetAccessToken(c *gin.Context) (accesstokenmd.TokenInfo, bool) {
+	v, b := c.Get(AccessToken)
+	if b {
+		return v.(accesstokenmd.TokenInfo), true
+	}
+	return accesstokenmd.TokenInfo{}, false
+}
+
 func GetLoginUser(c *gin.Context) (usermd.UserInfo, bool) {
 	v, b := c.Get(LoginUser)
 	if b {
@@ -73,7 +124,13 @@ func MustGetLoginUser(c *gin.Context) usermd.UserInfo {
 func GetSessionId(c *gin.Context) string {
 	cookie, _ := c.Cookie(LoginCookie)
 	if cookie == "" {
-		return c.GetHeader(AuthorizationHeader)
+		header := c.GetHeader(AuthorizationHeader)
+		// 兼容 Bearer/token 前缀
+		if fields := strings.Fields(header); len(fields) == 2 &&
+			(strings.EqualFold(fields[0], "Bearer") || strings.EqualFold(fields[0], "token")) {
+			return fields[1]
+		}
+		return header
 	}
 	return cookie
 }
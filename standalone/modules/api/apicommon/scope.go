@@ -0,0 +1,55 @@
+package apicommon
+
+import (
+	"github.com/gin-gonic/gin"
+	"reflect"
+	"runtime"
+	"zgit/standalone/modules/model/accesstokenmd"
+	"zgit/util"
+)
+
+// 个人访问令牌的权限在注册路由时声明
+// ReadOnly 只读接口 仓库读写令牌均可访问
+// RepoWrite 仓库、合并请求、发布版本等写接口 需要仓库写权限
+// 未声明的接口只允许admin令牌访问
+var (
+	readOnlyName  = nameOfFunction(ReadOnly)
+	repoWriteName = nameOfFunction(RepoWrite)
+)
+
+// ReadOnly 声明只读接口
+func ReadOnly(c *gin.Context) {
+	c.Next()
+}
+
+// RepoWrite 声明仓库写接口
+func RepoWrite(c *gin.Context) {
+	c.Next()
+}
+
+// ForbidAccessToken 账号凭证相关接口不允许通过令牌访问
+func ForbidAccessToken(c *gin.Context) {
+	if _, b := GetAccessToken(c); b {
+		util.HandleApiErr(util.UnauthorizedError(), c)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// checkTokenScope 根据路由声明校验令牌权限
+func checkTokenScope(c *gin.Context, info accesstokenmd.TokenInfo) bool {
+	for _, name := range c.HandlerNames() {
+		switch name {
+		case readOnlyName:
+			return info.CanReadRepo()
+		case repoWriteName:
+			return info.CanWriteRepo()
+		}
+	}
+	return info.HasScope(accesstokenmd.AdminScope)
+}
+
+func nameOfFunction(f any) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
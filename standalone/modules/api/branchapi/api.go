@@ -19,7 +19,7 @@ func InitApi() {
 			// 新增保护分支
 			group.POST("/insert", insertProtectedBranch)
 			group.POST("/delete", deleteProtectedBranch)
-			group.POST("/list", listProtectedBranch)
+			group.POST("/list", apicommon.ReadOnly, listProtectedBranch)
 		}
 	})
 }
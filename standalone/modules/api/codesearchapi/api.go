@@ -0,0 +1,134 @@
+package codesearchapi
+
+import (
+	"github.com/LeeZXin/zsf-utils/ginutil"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/http/httpserver"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"zgit/standalone/modules/api/apicommon"
+	"zgit/standalone/modules/service/codesearchsrv"
+	"zgit/util"
+)
+
+func InitApi() {
+	httpserver.AppendRegisterRouterFunc(func(e *gin.Engine) {
+		// 代码搜索
+		group := e.Group("/api/codeSearch", apicommon.CheckLogin)
+		{
+			// 搜索仓库
+			group.POST("/repo", apicommon.ReadOnly, searchRepo)
+			// 搜索项目下所有仓库
+			group.POST("/project", apicommon.ReadOnly, searchProject)
+			// 查看索引分支配置和索引进度
+			group.POST("/getCfg", apicommon.ReadOnly, getIndexCfg)
+			// 编辑索引分支
+			group.POST("/updateCfg", updateIndexCfg)
+		}
+	})
+}
+
+func searchRepo(c *gin.Context) {
+	var req SearchRepoReqVO
+	if util.ShouldBindJSON(&req, c) {
+		respDTO, err := codesearchsrv.SearchRepo(c.Request.Context(), codesearchsrv.SearchRepoReqDTO{
+			RepoId: req.RepoId,
+			Branch: req.Branch,
+			SearchOpts: codesearchsrv.SearchOpts{
+				Keyword:  req.Keyword,
+				PathGlob: req.PathGlob,
+				Language: req.Language,
+				Offset:   req.Offset,
+				Limit:    req.Limit,
+			},
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, searchResp2Vo(respDTO))
+	}
+}
+
+func searchProject(c *gin.Context) {
+	var req SearchProjectReqVO
+	if util.ShouldBindJSON(&req, c) {
+		respDTO, err := codesearchsrv.SearchProject(c.Request.Context(), codesearchsrv.SearchProjectReqDTO{
+			ProjectId: req.ProjectId,
+			SearchOpts: codesearchsrv.SearchOpts{
+				Keyword:  req.Keyword,
+				PathGlob: req.PathGlob,
+				Language: req.Language,
+				Offset:   req.Offset,
+				Limit:    req.Limit,
+			},
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, searchResp2Vo(respDTO))
+	}
+}
+
+func searchResp2Vo(respDTO codesearchsrv.SearchRespDTO) SearchRespVO {
+	ret := SearchRespVO{
+		BaseResp: ginutil.DefaultSuccessResp,
+		Total:    respDTO.Total,
+	}
+	ret.Hits, _ = listutil.Map(respDTO.Hits, func(t codesearchsrv.SearchHitDTO) (SearchHitVO, error) {
+		hit := SearchHitVO{
+			RepoId:   t.RepoId,
+			RepoName: t.RepoName,
+			Branch:   t.Branch,
+			Path:     t.Path,
+			Language: t.Language,
+		}
+		hit.Snippets, _ = listutil.Map(t.Snippets, func(s codesearchsrv.SnippetDTO) (SnippetVO, error) {
+			return SnippetVO{
+				LineNo: s.LineNo,
+				Line:   s.Line,
+			}, nil
+		})
+		return hit, nil
+	})
+	return ret
+}
+
+func getIndexCfg(c *gin.Context) {
+	var req GetIndexCfgReqVO
+	if util.ShouldBindJSON(&req, c) {
+		cfg, err := codesearchsrv.GetIndexCfg(c.Request.Context(), codesearchsrv.GetIndexCfgReqDTO{
+			RepoId:   req.RepoId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, GetIndexCfgRespVO{
+			BaseResp:       ginutil.DefaultSuccessResp,
+			DefaultBranch:  cfg.DefaultBranch,
+			Branches:       cfg.Branches,
+			IndexedCommits: cfg.IndexedCommits,
+		})
+	}
+}
+
+func updateIndexCfg(c *gin.Context) {
+	var req UpdateIndexCfgReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := codesearchsrv.UpdateIndexCfg(c.Request.Context(), codesearchsrv.UpdateIndexCfgReqDTO{
+			RepoId:   req.RepoId,
+			Branches: req.Branches,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
@@ -0,0 +1,58 @@
+package codesearchapi
+
+import "github.com/LeeZXin/zsf-utils/ginutil"
+
+type GetIndexCfgReqVO struct {
+	RepoId string `json:"repoId"`
+}
+
+type GetIndexCfgRespVO struct {
+	ginutil.BaseResp
+	DefaultBranch  string            `json:"defaultBranch"`
+	Branches       []string          `json:"branches"`
+	IndexedCommits map[string]string `json:"indexedCommits"`
+}
+
+type UpdateIndexCfgReqVO struct {
+	RepoId   string   `json:"repoId"`
+	Branches []string `json:"branches"`
+}
+
+type SearchRepoReqVO struct {
+	RepoId   string `json:"repoId"`
+	Branch   string `json:"branch"`
+	Keyword  string `json:"keyword"`
+	PathGlob string `json:"pathGlob"`
+	Language string `json:"language"`
+	Offset   int    `json:"offset"`
+	Limit    int    `json:"limit"`
+}
+
+type SearchProjectReqVO struct {
+	ProjectId string `json:"projectId"`
+	Keyword   string `json:"keyword"`
+	PathGlob  string `json:"pathGlob"`
+	Language  string `json:"language"`
+	Offset    int    `json:"offset"`
+	Limit     int    `json:"limit"`
+}
+
+type SnippetVO struct {
+	LineNo int    `json:"lineNo"`
+	Line   string `json:"line"`
+}
+
+type SearchHitVO struct {
+	RepoId   string      `json:"repoId"`
+	RepoName string      `json:"repoName"`
+	Branch   string      `json:"branch"`
+	Path     string      `json:"path"`
+	Language string      `json:"language"`
+	Snippets []SnippetVO `json:"snippets"`
+}
+
+type SearchRespVO struct {
+	ginutil.BaseResp
+	Total uint64        `json:"total"`
+	Hits  []SearchHitVO `json:"hits"`
+}
@@ -0,0 +1,79 @@
+package commitstatusapi
+
+import (
+	"github.com/LeeZXin/zsf-utils/ginutil"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf-utils/timeutil"
+	"github.com/LeeZXin/zsf/http/httpserver"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"zgit/standalone/modules/api/apicommon"
+	"zgit/standalone/modules/service/commitstatussrv"
+	"zgit/util"
+)
+
+func InitApi() {
+	httpserver.AppendRegisterRouterFunc(func(e *gin.Engine) {
+		group := e.Group("/api/commitStatus", apicommon.CheckLogin)
+		{
+			// 上报提交状态
+			group.POST("/create", apicommon.RepoWrite, createCommitStatus)
+			// 提交状态列表
+			group.POST("/list", apicommon.ReadOnly, listCommitStatus)
+		}
+	})
+}
+
+func createCommitStatus(c *gin.Context) {
+	var req CreateCommitStatusReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := commitstatussrv.CreateCommitStatus(c.Request.Context(), commitstatussrv.CreateCommitStatusReqDTO{
+			RepoId:      req.RepoId,
+			CommitId:    req.CommitId,
+			Context:     req.Context,
+			State:       req.State,
+			TargetUrl:   req.TargetUrl,
+			Description: req.Description,
+			Operator:    apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func listCommitStatus(c *gin.Context) {
+	var req ListCommitStatusReqVO
+	if util.ShouldBindJSON(&req, c) {
+		respDTO, err := commitstatussrv.ListCommitStatus(c.Request.Context(), commitstatussrv.ListCommitStatusReqDTO{
+			RepoId:   req.RepoId,
+			CommitId: req.CommitId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		ret := ListCommitStatusRespVO{
+			BaseResp:  ginutil.DefaultSuccessResp,
+			CommitId:  respDTO.CommitId,
+			State:     respDTO.State.Int(),
+			StateName: respDTO.State.Readable(),
+		}
+		ret.StatusList, _ = listutil.Map(respDTO.StatusList, func(t commitstatussrv.CommitStatusDTO) (CommitStatusVO, error) {
+			return CommitStatusVO{
+				Context:     t.Context,
+				State:       t.State.Int(),
+				StateName:   t.State.Readable(),
+				TargetUrl:   t.TargetUrl,
+				Description: t.Description,
+				Creator:     t.Creator,
+				Created:     t.Created.Format(timeutil.DefaultTimeFormat),
+				Updated:     t.Updated.Format(timeutil.DefaultTimeFormat),
+			}, nil
+		})
+		c.JSON(http.StatusOK, ret)
+	}
+}
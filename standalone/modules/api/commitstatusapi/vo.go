@@ -0,0 +1,41 @@
+package commitstatusapi
+
+import (
+	"github.com/LeeZXin/zsf-utils/ginutil"
+	"zgit/standalone/modules/model/commitstatusmd"
+)
+
+type CreateCommitStatusReqVO struct {
+	RepoId   string `json:"repoId"`
+	CommitId string `json:"commitId"`
+	// 状态名称 如ci/build
+	Context string `json:"context"`
+	// 0等待中 1成功 2失败 3错误
+	State       commitstatusmd.State `json:"state"`
+	TargetUrl   string               `json:"targetUrl"`
+	Description string               `json:"description"`
+}
+
+type ListCommitStatusReqVO struct {
+	RepoId   string `json:"repoId"`
+	CommitId string `json:"commitId"`
+}
+
+type CommitStatusVO struct {
+	Context     string `json:"context"`
+	State       int    `json:"state"`
+	StateName   string `json:"stateName"`
+	TargetUrl   string `json:"targetUrl"`
+	Description string `json:"description"`
+	Creator     string `json:"creator"`
+	Created     string `json:"created"`
+	Updated     string `json:"updated"`
+}
+
+type ListCommitStatusRespVO struct {
+	ginutil.BaseResp
+	CommitId   string           `json:"commitId"`
+	State      int              `json:"state"`
+	StateName  string           `json:"stateName"`
+	StatusList []CommitStatusVO `json:"statusList"`
+}
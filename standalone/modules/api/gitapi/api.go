@@ -0,0 +1,176 @@
+package gitapi
+
+import (
+	"compress/gzip"
+	"fmt"
+	"github.com/LeeZXin/zsf-utils/bizerr"
+	"github.com/LeeZXin/zsf/http/httpserver"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"path/filepath"
+	"zgit/pkg/apicode"
+	"zgit/pkg/i18n"
+	"zgit/standalone/modules/model/accesstokenmd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/standalone/modules/service/accesstokensrv"
+	"zgit/standalone/modules/service/gitsrv"
+	"zgit/standalone/modules/service/usersrv"
+	"zgit/util"
+)
+
+func InitApi() {
+	// 注册smart http api
+	httpserver.AppendRegisterRouterFunc(func(e *gin.Engine) {
+		group := e.Group(":corpId/:repoName")
+		{
+			group.GET("/info/refs", infoRefs)
+			group.POST("/git-upload-pack", packService("git-upload-pack"), serviceRpc)
+			group.POST("/git-receive-pack", packService("git-receive-pack"), serviceRpc)
+		}
+	})
+}
+
+func packService(service string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("service", service)
+		c.Next()
+	}
+}
+
+func infoRefs(c *gin.Context) {
+	service := c.Query("service")
+	repo, operator, b := checkAccess(c, service)
+	if !b {
+		return
+	}
+	setNoCacheHeader(c)
+	c.Header("Content-Type", fmt.Sprintf("application/x-%s-advertisement", service))
+	c.Status(http.StatusOK)
+	err := gitsrv.HandleHttpInfoRefs(c.Request.Context(), gitsrv.HttpServiceReqDTO{
+		Repo:        repo,
+		Service:     service,
+		GitProtocol: c.GetHeader("Git-Protocol"),
+		Stdout:      c.Writer,
+		Operator:    operator,
+	})
+	if err != nil {
+		c.Abort()
+	}
+}
+
+func serviceRpc(c *gin.Context) {
+	service := c.GetString("service")
+	repo, operator, b := checkAccess(c, service)
+	if !b {
+		return
+	}
+	var reqBody io.Reader = c.Request.Body
+	defer c.Request.Body.Close()
+	if c.GetHeader("Content-Encoding") == "gzip" {
+		reader, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			c.String(http.StatusBadRequest, i18n.GetByKey(i18n.SystemInvalidArgs))
+			return
+		}
+		defer reader.Close()
+		reqBody = reader
+	}
+	setNoCacheHeader(c)
+	c.Header("Content-Type", fmt.Sprintf("application/x-%s-result", service))
+	c.Status(http.StatusOK)
+	err := gitsrv.HandleHttpServiceRpc(c.Request.Context(), gitsrv.HttpServiceReqDTO{
+		Repo:        repo,
+		Service:     service,
+		GitProtocol: c.GetHeader("Git-Protocol"),
+		Stdin:       reqBody,
+		Stdout:      c.Writer,
+		Operator:    operator,
+	})
+	if err != nil {
+		c.Abort()
+	}
+}
+
+// checkAccess 通过basic认证获取用户并校验仓库权限
+func checkAccess(c *gin.Context, service string) (repomd.Repo, usermd.UserInfo, bool) {
+	account, password, ok := c.Request.BasicAuth()
+	if !ok {
+		askForCredentials(c)
+		return repomd.Repo{}, usermd.UserInfo{}, false
+	}
+	ctx := c.Request.Context()
+	var (
+		operator usermd.UserInfo
+		err      error
+	)
+	if accesstokenmd.IsAccessToken(password) {
+		// 个人访问令牌
+		operator, err = checkAccessToken(c, password, service)
+	} else {
+		operator, err = usersrv.CheckAccountAndPassword(ctx, usersrv.LoginReqDTO{
+			Account:  account,
+			Password: password,
+		})
+	}
+	if err != nil {
+		handleErr(err, c)
+		return repomd.Repo{}, usermd.UserInfo{}, false
+	}
+	repo, err := gitsrv.CheckHttpAccess(ctx, gitsrv.CheckHttpAccessReqDTO{
+		RepoPath: filepath.Join(c.Param("corpId"), c.Param("repoName")),
+		Service:  service,
+		Operator: operator,
+	})
+	if err != nil {
+		handleErr(err, c)
+		return repomd.Repo{}, usermd.UserInfo{}, false
+	}
+	return repo, operator, true
+}
+
+func checkAccessToken(c *gin.Context, token, service string) (usermd.UserInfo, error) {
+	info, user, b, err := accesstokensrv.CheckAccessToken(c.Request.Context(), token)
+	if err != nil {
+		return usermd.UserInfo{}, err
+	}
+	if !b {
+		return usermd.UserInfo{}, util.UnauthorizedError()
+	}
+	if service == "git-receive-pack" {
+		if !info.CanWriteRepo() {
+			return usermd.UserInfo{}, util.UnauthorizedError()
+		}
+	} else if !info.CanReadRepo() {
+		return usermd.UserInfo{}, util.UnauthorizedError()
+	}
+	return user, nil
+}
+
+func handleErr(err error, c *gin.Context) {
+	berr, ok := err.(*bizerr.Err)
+	if !ok {
+		c.String(http.StatusInternalServerError, i18n.GetByKey(i18n.SystemInternalError))
+		return
+	}
+	switch berr.Code {
+	case apicode.UnauthorizedCode.Int():
+		askForCredentials(c)
+	case apicode.InvalidArgsCode.Int():
+		c.String(http.StatusNotFound, berr.Message)
+	default:
+		c.String(http.StatusInternalServerError, berr.Message)
+	}
+}
+
+func askForCredentials(c *gin.Context) {
+	c.Header("WWW-Authenticate", `Basic realm="zgit"`)
+	c.String(http.StatusUnauthorized, i18n.GetByKey(i18n.SystemUnauthorized))
+}
+
+func setNoCacheHeader(c *gin.Context) {
+	c.Header("Expires", "Fri, 01 Jan 1980 00:00:00 GMT")
+	c.Header("Pragma", "no-cache")
+	c.Header("Cache-Control", "no-cache, max-age=0, must-revalidate")
+}
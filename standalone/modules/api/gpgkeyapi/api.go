@@ -0,0 +1,90 @@
+package gpgkeyapi
+
+import (
+	"github.com/LeeZXin/zsf-utils/ginutil"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf-utils/timeutil"
+	"github.com/LeeZXin/zsf/http/httpserver"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"zgit/standalone/modules/api/apicommon"
+	"zgit/standalone/modules/service/gpgkeysrv"
+	"zgit/util"
+)
+
+func InitApi() {
+	httpserver.AppendRegisterRouterFunc(func(e *gin.Engine) {
+		group := e.Group("/api/gpgKey", apicommon.CheckLogin)
+		{
+			// 删除
+			group.POST("/delete", apicommon.ForbidAccessToken, deleteGpgKey)
+			// 插入
+			group.POST("/insert", apicommon.ForbidAccessToken, insertGpgKey)
+			// 列表展示
+			group.POST("/list", apicommon.ReadOnly, listGpgKey)
+		}
+	})
+}
+
+func insertGpgKey(c *gin.Context) {
+	var req InsertGpgKeyReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := gpgkeysrv.InsertGpgKey(c.Request.Context(), gpgkeysrv.InsertGpgKeyReqDTO{
+			Name:          req.Name,
+			PubKeyContent: req.PubKeyContent,
+			Operator:      apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func deleteGpgKey(c *gin.Context) {
+	var req DeleteGpgKeyReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := gpgkeysrv.DeleteGpgKey(c.Request.Context(), gpgkeysrv.DeleteGpgKeyReqDTO{
+			KeyId:    req.KeyId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func listGpgKey(c *gin.Context) {
+	var req ListGpgKeyReqVO
+	if util.ShouldBindJSON(&req, c) {
+		respDTO, err := gpgkeysrv.ListGpgKey(c.Request.Context(), gpgkeysrv.ListGpgKeyReqDTO{
+			Offset:   req.Offset,
+			Limit:    req.Limit,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		ret := ListGpgKeyRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+			Cursor:   respDTO.Cursor,
+		}
+		ret.Data, _ = listutil.Map(respDTO.KeyList, func(t gpgkeysrv.GpgKeyDTO) (GpgKeyVO, error) {
+			ret := GpgKeyVO{
+				KeyId:        t.KeyId,
+				Name:         t.Name,
+				PrimaryKeyId: t.PrimaryKeyId,
+				SubKeyIdList: t.SubKeyIdList,
+			}
+			if !t.Expiry.IsZero() {
+				ret.Expiry = t.Expiry.Format(timeutil.DefaultTimeFormat)
+			}
+			return ret, nil
+		})
+		c.JSON(http.StatusOK, ret)
+	}
+}
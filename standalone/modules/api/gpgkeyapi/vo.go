@@ -0,0 +1,31 @@
+package gpgkeyapi
+
+import "github.com/LeeZXin/zsf-utils/ginutil"
+
+type InsertGpgKeyReqVO struct {
+	Name          string `json:"name"`
+	PubKeyContent string `json:"pubKeyContent"`
+}
+
+type DeleteGpgKeyReqVO struct {
+	KeyId string `json:"keyId"`
+}
+
+type ListGpgKeyReqVO struct {
+	Offset int64 `json:"offset"`
+	Limit  int   `json:"limit"`
+}
+
+type GpgKeyVO struct {
+	KeyId        string   `json:"keyId"`
+	Name         string   `json:"name"`
+	PrimaryKeyId string   `json:"primaryKeyId"`
+	SubKeyIdList []string `json:"subKeyIdList"`
+	Expiry       string   `json:"expiry"`
+}
+
+type ListGpgKeyRespVO struct {
+	ginutil.BaseResp
+	Data   []GpgKeyVO `json:"data"`
+	Cursor int64      `json:"cursor"`
+}
@@ -17,9 +17,11 @@ import (
 	"zgit/pkg/git/lfs"
 	"zgit/pkg/i18n"
 	"zgit/setting"
+	"zgit/standalone/modules/model/accesstokenmd"
 	"zgit/standalone/modules/model/lfsmd"
 	"zgit/standalone/modules/model/repomd"
 	"zgit/standalone/modules/model/usermd"
+	"zgit/standalone/modules/service/accesstokensrv"
 	"zgit/standalone/modules/service/lfssrv"
 	"zgit/standalone/modules/service/reposrv"
 	"zgit/standalone/modules/service/usersrv"
@@ -76,27 +78,6 @@ func packRepoPath(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	token, err := jwt.ParseWithClaims(authorization, new(lfs.Claims), func(t *jwt.Token) (any, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return setting.LfsJwtSecretBytes(), nil
-	})
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, ErrVO{
-			Message: i18n.GetByKey(i18n.SystemUnauthorized),
-		})
-		c.Abort()
-		return
-	}
-	claims, ok := token.Claims.(*lfs.Claims)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, ErrVO{
-			Message: i18n.GetByKey(i18n.SystemUnauthorized),
-		})
-		c.Abort()
-		return
-	}
 	ctx := c.Request.Context()
 	repo, b, err := reposrv.GetInfoByPath(ctx, repoPath)
 	if err != nil {
@@ -113,20 +94,70 @@ func packRepoPath(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	userInfo, b, err := usersrv.GetUserInfoByAccount(ctx, claims.Account)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrVO{
-			Message: i18n.GetByKey(i18n.SystemInternalError),
-		})
-		c.Abort()
-		return
-	}
-	if !b {
-		c.JSON(http.StatusUnauthorized, ErrVO{
-			Message: i18n.GetByKey(i18n.SystemInvalidArgs),
+	var (
+		userInfo usermd.UserInfo
+		claims   *lfs.Claims
+	)
+	if accessToken, isAccessToken := getAccessToken(c, authorization); isAccessToken {
+		// 个人访问令牌
+		var info accesstokenmd.TokenInfo
+		info, userInfo, b, err = accesstokensrv.CheckAccessToken(ctx, accessToken)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrVO{
+				Message: i18n.GetByKey(i18n.SystemInternalError),
+			})
+			c.Abort()
+			return
+		}
+		if !b || !info.HasScope(accesstokenmd.LfsScope) {
+			c.JSON(http.StatusUnauthorized, ErrVO{
+				Message: i18n.GetByKey(i18n.SystemUnauthorized),
+			})
+			c.Abort()
+			return
+		}
+		claims = &lfs.Claims{
+			RepoId:  repoPath,
+			Account: userInfo.Account,
+		}
+	} else {
+		token, err := jwt.ParseWithClaims(authorization, new(lfs.Claims), func(t *jwt.Token) (any, error) {
+			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+			}
+			return setting.LfsJwtSecretBytes(), nil
 		})
-		c.Abort()
-		return
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, ErrVO{
+				Message: i18n.GetByKey(i18n.SystemUnauthorized),
+			})
+			c.Abort()
+			return
+		}
+		var ok bool
+		claims, ok = token.Claims.(*lfs.Claims)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, ErrVO{
+				Message: i18n.GetByKey(i18n.SystemUnauthorized),
+			})
+			c.Abort()
+			return
+		}
+		userInfo, b, err = usersrv.GetUserInfoByAccount(ctx, claims.Account)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrVO{
+				Message: i18n.GetByKey(i18n.SystemInternalError),
+			})
+			c.Abort()
+			return
+		}
+		if !b {
+			c.JSON(http.StatusUnauthorized, ErrVO{
+				Message: i18n.GetByKey(i18n.SystemInvalidArgs),
+			})
+			c.Abort()
+			return
+		}
 	}
 	c.Set("operator", userInfo)
 	c.Set("claims", claims)
@@ -135,6 +166,15 @@ func packRepoPath(c *gin.Context) {
 	c.Next()
 }
 
+// getAccessToken 从basic认证或Bearer头中获取个人访问令牌
+func getAccessToken(c *gin.Context, authorization string) (string, bool) {
+	if _, password, ok := c.Request.BasicAuth(); ok {
+		return password, accesstokenmd.IsAccessToken(password)
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	return token, accesstokenmd.IsAccessToken(token)
+}
+
 func checkMediaType(c *gin.Context) {
 	header := c.GetHeader("Accept")
 	accepts := strings.Split(header, ";")
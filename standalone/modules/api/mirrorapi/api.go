@@ -0,0 +1,230 @@
+package mirrorapi
+
+import (
+	"github.com/LeeZXin/zsf-utils/ginutil"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf-utils/timeutil"
+	"github.com/LeeZXin/zsf/http/httpserver"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+	"zgit/standalone/modules/api/apicommon"
+	"zgit/standalone/modules/service/mirrorsrv"
+	"zgit/util"
+)
+
+func InitApi() {
+	httpserver.AppendRegisterRouterFunc(func(e *gin.Engine) {
+		// 镜像仓库
+		group := e.Group("/api/pullMirror", apicommon.CheckLogin)
+		{
+			// 查看镜像配置和同步状态
+			group.POST("/get", apicommon.ReadOnly, getPullMirror)
+			// 编辑镜像配置
+			group.POST("/update", updatePullMirror)
+			// 立即同步
+			group.POST("/sync", syncPullMirror)
+			// 取消镜像
+			group.POST("/delete", deletePullMirror)
+		}
+		// 推送镜像
+		group = e.Group("/api/pushMirror", apicommon.CheckLogin)
+		{
+			// 新增推送镜像
+			group.POST("/insert", insertPushMirror)
+			// 编辑推送镜像
+			group.POST("/update", updatePushMirror)
+			// 删除推送镜像
+			group.POST("/delete", deletePushMirror)
+			// 推送镜像列表及同步状态
+			group.POST("/list", apicommon.ReadOnly, listPushMirror)
+			// 立即推送
+			group.POST("/sync", syncPushMirror)
+		}
+	})
+}
+
+func getPullMirror(c *gin.Context) {
+	var req GetPullMirrorReqVO
+	if util.ShouldBindJSON(&req, c) {
+		mirror, b, err := mirrorsrv.GetPullMirror(c.Request.Context(), mirrorsrv.GetPullMirrorReqDTO{
+			RepoId:   req.RepoId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, GetPullMirrorRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+			Exists:   b,
+			Data: PullMirrorVO{
+				RepoId:       mirror.RepoId,
+				RemoteUrl:    mirror.RemoteUrl,
+				Username:     mirror.Username,
+				HasPassword:  mirror.HasPassword,
+				SyncInterval: mirror.SyncInterval,
+				LastSyncTime: formatMilli(mirror.LastSyncTime),
+				LastSyncErr:  mirror.LastSyncErr,
+				NextSyncTime: formatMilli(mirror.NextSyncTime),
+				IsSyncing:    mirror.IsSyncing,
+			},
+		})
+	}
+}
+
+func updatePullMirror(c *gin.Context) {
+	var req UpdatePullMirrorReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := mirrorsrv.UpdatePullMirror(c.Request.Context(), mirrorsrv.UpdatePullMirrorReqDTO{
+			RepoId:       req.RepoId,
+			RemoteUrl:    req.RemoteUrl,
+			Username:     req.Username,
+			Password:     req.Password,
+			SyncInterval: req.SyncInterval,
+			Operator:     apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func syncPullMirror(c *gin.Context) {
+	var req SyncPullMirrorReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := mirrorsrv.SyncPullMirror(c.Request.Context(), mirrorsrv.SyncPullMirrorReqDTO{
+			RepoId:   req.RepoId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func deletePullMirror(c *gin.Context) {
+	var req DeletePullMirrorReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := mirrorsrv.DeletePullMirror(c.Request.Context(), mirrorsrv.DeletePullMirrorReqDTO{
+			RepoId:   req.RepoId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func insertPushMirror(c *gin.Context) {
+	var req InsertPushMirrorReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := mirrorsrv.InsertPushMirror(c.Request.Context(), mirrorsrv.InsertPushMirrorReqDTO{
+			RepoId:       req.RepoId,
+			RemoteUrl:    req.RemoteUrl,
+			Username:     req.Username,
+			Password:     req.Password,
+			SyncInterval: req.SyncInterval,
+			Operator:     apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func updatePushMirror(c *gin.Context) {
+	var req UpdatePushMirrorReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := mirrorsrv.UpdatePushMirror(c.Request.Context(), mirrorsrv.UpdatePushMirrorReqDTO{
+			MirrorId:     req.MirrorId,
+			RemoteUrl:    req.RemoteUrl,
+			Username:     req.Username,
+			Password:     req.Password,
+			SyncInterval: req.SyncInterval,
+			Operator:     apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func deletePushMirror(c *gin.Context) {
+	var req DeletePushMirrorReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := mirrorsrv.DeletePushMirror(c.Request.Context(), mirrorsrv.DeletePushMirrorReqDTO{
+			MirrorId: req.MirrorId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func listPushMirror(c *gin.Context) {
+	var req ListPushMirrorReqVO
+	if util.ShouldBindJSON(&req, c) {
+		mirrors, err := mirrorsrv.ListPushMirror(c.Request.Context(), mirrorsrv.ListPushMirrorReqDTO{
+			RepoId:   req.RepoId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		data, _ := listutil.Map(mirrors, func(m mirrorsrv.PushMirrorDTO) (PushMirrorVO, error) {
+			return PushMirrorVO{
+				MirrorId:     m.MirrorId,
+				RepoId:       m.RepoId,
+				RemoteUrl:    m.RemoteUrl,
+				Username:     m.Username,
+				HasPassword:  m.HasPassword,
+				SyncInterval: m.SyncInterval,
+				LastSyncTime: formatMilli(m.LastSyncTime),
+				LastSyncErr:  m.LastSyncErr,
+				NextSyncTime: formatMilli(m.NextSyncTime),
+				IsSyncing:    m.IsSyncing,
+			}, nil
+		})
+		c.JSON(http.StatusOK, ListPushMirrorRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+			Data:     data,
+		})
+	}
+}
+
+func syncPushMirror(c *gin.Context) {
+	var req SyncPushMirrorReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := mirrorsrv.SyncPushMirror(c.Request.Context(), mirrorsrv.SyncPushMirrorReqDTO{
+			MirrorId: req.MirrorId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func formatMilli(t int64) string {
+	if t <= 0 {
+		return ""
+	}
+	return time.UnixMilli(t).Format(timeutil.DefaultTimeFormat)
+}
@@ -0,0 +1,87 @@
+package mirrorapi
+
+import "github.com/LeeZXin/zsf-utils/ginutil"
+
+type GetPullMirrorReqVO struct {
+	RepoId string `json:"repoId"`
+}
+
+type UpdatePullMirrorReqVO struct {
+	RepoId       string `json:"repoId"`
+	RemoteUrl    string `json:"remoteUrl"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	SyncInterval int    `json:"syncInterval"`
+}
+
+type SyncPullMirrorReqVO struct {
+	RepoId string `json:"repoId"`
+}
+
+type DeletePullMirrorReqVO struct {
+	RepoId string `json:"repoId"`
+}
+
+type PullMirrorVO struct {
+	RepoId       string `json:"repoId"`
+	RemoteUrl    string `json:"remoteUrl"`
+	Username     string `json:"username"`
+	HasPassword  bool   `json:"hasPassword"`
+	SyncInterval int    `json:"syncInterval"`
+	LastSyncTime string `json:"lastSyncTime"`
+	LastSyncErr  string `json:"lastSyncErr"`
+	NextSyncTime string `json:"nextSyncTime"`
+	IsSyncing    bool   `json:"isSyncing"`
+}
+
+type GetPullMirrorRespVO struct {
+	ginutil.BaseResp
+	Exists bool         `json:"exists"`
+	Data   PullMirrorVO `json:"data"`
+}
+
+type InsertPushMirrorReqVO struct {
+	RepoId       string `json:"repoId"`
+	RemoteUrl    string `json:"remoteUrl"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	SyncInterval int    `json:"syncInterval"`
+}
+
+type UpdatePushMirrorReqVO struct {
+	MirrorId     string `json:"mirrorId"`
+	RemoteUrl    string `json:"remoteUrl"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	SyncInterval int    `json:"syncInterval"`
+}
+
+type DeletePushMirrorReqVO struct {
+	MirrorId string `json:"mirrorId"`
+}
+
+type SyncPushMirrorReqVO struct {
+	MirrorId string `json:"mirrorId"`
+}
+
+type ListPushMirrorReqVO struct {
+	RepoId string `json:"repoId"`
+}
+
+type PushMirrorVO struct {
+	MirrorId     string `json:"mirrorId"`
+	RepoId       string `json:"repoId"`
+	RemoteUrl    string `json:"remoteUrl"`
+	Username     string `json:"username"`
+	HasPassword  bool   `json:"hasPassword"`
+	SyncInterval int    `json:"syncInterval"`
+	LastSyncTime string `json:"lastSyncTime"`
+	LastSyncErr  string `json:"lastSyncErr"`
+	NextSyncTime string `json:"nextSyncTime"`
+	IsSyncing    bool   `json:"isSyncing"`
+}
+
+type ListPushMirrorRespVO struct {
+	ginutil.BaseResp
+	Data []PushMirrorVO `json:"data"`
+}
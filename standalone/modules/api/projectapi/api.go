@@ -17,7 +17,7 @@ func InitApi() {
 		group := e.Group("/api/project", apicommon.CheckLogin)
 		{
 			group.POST("/insert", insertProject)
-			group.POST("/list", listProject)
+			group.POST("/list", apicommon.ReadOnly, listProject)
 			group.POST("/delete", deleteProject)
 			group.POST("/update", updateProject)
 		}
@@ -25,14 +25,14 @@ func InitApi() {
 		group = e.Group("/api/projectUser", apicommon.CheckLogin)
 		{
 			group.POST("/upsert", upsertProjectUser)
-			group.POST("/list", listProjectUser)
+			group.POST("/list", apicommon.ReadOnly, listProjectUser)
 			group.POST("/delete", deleteProjectUser)
 		}
 		// 项目用户组
 		group = e.Group("/api/projectUserGroup", apicommon.CheckLogin)
 		{
 			group.POST("/insert", insertProjectUserGroup)
-			group.POST("/list", listProjectUserGroup)
+			group.POST("/list", apicommon.ReadOnly, listProjectUserGroup)
 			group.POST("/updateName", updateProjectUserGroupName)
 			group.POST("/updatePerm", updateProjectUserGroupPerm)
 			group.POST("/delete", deleteProjectUserGroup)
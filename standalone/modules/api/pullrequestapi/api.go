@@ -2,9 +2,13 @@ package pullrequestapi
 
 import (
 	"github.com/LeeZXin/zsf-utils/ginutil"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf-utils/timeutil"
 	"github.com/LeeZXin/zsf/http/httpserver"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"path"
+	"zgit/pkg/git"
 	"zgit/standalone/modules/api/apicommon"
 	"zgit/standalone/modules/service/pullrequestsrv"
 	"zgit/util"
@@ -15,13 +19,27 @@ func InitApi() {
 		group := e.Group("/api/pullRequest", apicommon.CheckLogin)
 		{
 			// 创建合并请求
-			group.POST("/submit", submitPullRequest)
+			group.POST("/submit", apicommon.RepoWrite, submitPullRequest)
 			// 关闭合并请求
-			group.POST("/close", closePullRequest)
+			group.POST("/close", apicommon.RepoWrite, closePullRequest)
 			// merge合并请求
-			group.POST("/merge", mergePullRequest)
+			group.POST("/merge", apicommon.RepoWrite, mergePullRequest)
 			// review
-			group.POST("/review", reviewPullRequest)
+			group.POST("/review", apicommon.RepoWrite, reviewPullRequest)
+			// 仓库合并请求列表
+			group.POST("/list", apicommon.ReadOnly, listPullRequest)
+			// 项目合并请求列表
+			group.POST("/listByProject", apicommon.ReadOnly, listProjectPullRequest)
+			// 合并请求详情
+			group.POST("/detail", apicommon.ReadOnly, getPullRequestDetail)
+			// 添加代码行评论
+			group.POST("/comment/add", apicommon.RepoWrite, addComment)
+			// 代码行评论列表
+			group.POST("/comment/list", apicommon.ReadOnly, listComment)
+			// 解决评论串
+			group.POST("/comment/resolve", apicommon.RepoWrite, resolveCommentThread(true))
+			// 重新打开评论串
+			group.POST("/comment/unresolve", apicommon.RepoWrite, resolveCommentThread(false))
 		}
 	})
 }
@@ -29,8 +47,9 @@ func InitApi() {
 func submitPullRequest(c *gin.Context) {
 	var req SubmitPullRequestReqVO
 	if util.ShouldBindJSON(&req, c) {
-		err := pullrequestsrv.SubmitPullRequest(c.Request.Context(), pullrequestsrv.SubmitPullRequestReqDTO{
+		respDTO, err := pullrequestsrv.SubmitPullRequest(c.Request.Context(), pullrequestsrv.SubmitPullRequestReqDTO{
 			RepoId:   req.RepoId,
+			Title:    req.Title,
 			Target:   req.Target,
 			Head:     req.Head,
 			Operator: apicommon.MustGetLoginUser(c),
@@ -39,7 +58,12 @@ func submitPullRequest(c *gin.Context) {
 			util.HandleApiErr(err, c)
 			return
 		}
-		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+		ret := SubmitPullRequestRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+			PrId:     respDTO.PrId,
+		}
+		ret.CodeOwnerList, _ = listutil.Map(respDTO.CodeOwnerList, codeOwnerApproval2Vo)
+		c.JSON(http.StatusOK, ret)
 	}
 }
 
@@ -63,6 +87,7 @@ func mergePullRequest(c *gin.Context) {
 	if util.ShouldBindJSON(&req, c) {
 		err := pullrequestsrv.MergePullRequest(c.Request.Context(), pullrequestsrv.MergePullRequestReqDTO{
 			PrId:     req.PrId,
+			Strategy: req.Strategy,
 			Operator: apicommon.MustGetLoginUser(c),
 		})
 		if err != nil {
@@ -89,3 +114,245 @@ func reviewPullRequest(c *gin.Context) {
 		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
 	}
 }
+
+func listPullRequest(c *gin.Context) {
+	var req ListPullRequestReqVO
+	if util.ShouldBindJSON(&req, c) {
+		respDTO, err := pullrequestsrv.ListPullRequest(c.Request.Context(), pullrequestsrv.ListPullRequestReqDTO{
+			RepoId:   req.RepoId,
+			PrStatus: req.PrStatus,
+			CreateBy: req.CreateBy,
+			Reviewer: req.Reviewer,
+			Target:   req.Target,
+			Head:     req.Head,
+			Title:    req.Title,
+			Offset:   req.Offset,
+			Limit:    req.Limit,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, listRespDto2Vo(respDTO))
+	}
+}
+
+func listProjectPullRequest(c *gin.Context) {
+	var req ListProjectPullRequestReqVO
+	if util.ShouldBindJSON(&req, c) {
+		respDTO, err := pullrequestsrv.ListProjectPullRequest(c.Request.Context(), pullrequestsrv.ListProjectPullRequestReqDTO{
+			ProjectId: req.ProjectId,
+			PrStatus:  req.PrStatus,
+			CreateBy:  req.CreateBy,
+			Reviewer:  req.Reviewer,
+			Target:    req.Target,
+			Head:      req.Head,
+			Title:     req.Title,
+			Offset:    req.Offset,
+			Limit:     req.Limit,
+			Operator:  apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, listRespDto2Vo(respDTO))
+	}
+}
+
+func getPullRequestDetail(c *gin.Context) {
+	var req GetPullRequestDetailReqVO
+	if util.ShouldBindJSON(&req, c) {
+		respDTO, err := pullrequestsrv.GetPullRequestDetail(c.Request.Context(), pullrequestsrv.GetPullRequestDetailReqDTO{
+			PrId:     req.PrId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		ret := GetPullRequestDetailRespVO{
+			BaseResp:    ginutil.DefaultSuccessResp,
+			PullRequest: prDto2Vo(respDTO.PullRequest),
+			CanMerge:    respDTO.CanMerge,
+		}
+		ret.ReviewList, _ = listutil.Map(respDTO.ReviewList, func(t pullrequestsrv.ReviewDTO) (ReviewVO, error) {
+			return ReviewVO{
+				Rid:              t.Rid,
+				Reviewer:         t.Reviewer,
+				ReviewMsg:        t.ReviewMsg,
+				ReviewStatus:     t.ReviewStatus.Int(),
+				ReviewStatusName: t.ReviewStatus.Readable(),
+				IsDismissed:      t.IsDismissed,
+				Created:          t.Created.Format(timeutil.DefaultTimeFormat),
+				Updated:          t.Updated.Format(timeutil.DefaultTimeFormat),
+			}, nil
+		})
+		if respDTO.HasDiffInfo {
+			ret.DiffInfo = diffInfo2Vo(respDTO.DiffInfo)
+		}
+		ret.CodeOwnerList, _ = listutil.Map(respDTO.CodeOwnerList, codeOwnerApproval2Vo)
+		c.JSON(http.StatusOK, ret)
+	}
+}
+
+func addComment(c *gin.Context) {
+	var req AddCommentReqVO
+	if util.ShouldBindJSON(&req, c) {
+		comment, err := pullrequestsrv.AddComment(c.Request.Context(), pullrequestsrv.AddCommentReqDTO{
+			PrId:     req.PrId,
+			ReplyTo:  req.ReplyTo,
+			FilePath: req.FilePath,
+			Side:     req.Side,
+			LineNo:   req.LineNo,
+			Content:  req.Content,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, AddCommentRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+			Comment:  commentDto2Vo(comment),
+		})
+	}
+}
+
+func listComment(c *gin.Context) {
+	var req ListCommentReqVO
+	if util.ShouldBindJSON(&req, c) {
+		comments, err := pullrequestsrv.ListComment(c.Request.Context(), pullrequestsrv.ListCommentReqDTO{
+			PrId:     req.PrId,
+			FilePath: req.FilePath,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		ret := ListCommentRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+		}
+		ret.CommentList, _ = listutil.Map(comments, func(t pullrequestsrv.CommentDTO) (CommentVO, error) {
+			return commentDto2Vo(t), nil
+		})
+		c.JSON(http.StatusOK, ret)
+	}
+}
+
+func resolveCommentThread(isResolved bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ResolveCommentThreadReqVO
+		if util.ShouldBindJSON(&req, c) {
+			err := pullrequestsrv.ResolveCommentThread(c.Request.Context(), pullrequestsrv.ResolveCommentThreadReqDTO{
+				ThreadId:   req.ThreadId,
+				IsResolved: isResolved,
+				Operator:   apicommon.MustGetLoginUser(c),
+			})
+			if err != nil {
+				util.HandleApiErr(err, c)
+				return
+			}
+			c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+		}
+	}
+}
+
+func commentDto2Vo(dto pullrequestsrv.CommentDTO) CommentVO {
+	return CommentVO{
+		Cid:        dto.Cid,
+		ThreadId:   dto.ThreadId,
+		FilePath:   dto.FilePath,
+		Side:       dto.Side.Int(),
+		LineNo:     dto.LineNo,
+		LineText:   dto.LineText,
+		CommitId:   dto.CommitId,
+		Commenter:  dto.Commenter,
+		Content:    dto.Content,
+		IsResolved: dto.IsResolved,
+		ResolvedBy: dto.ResolvedBy,
+		IsOutdated: dto.IsOutdated,
+		Created:    dto.Created.Format(timeutil.DefaultTimeFormat),
+	}
+}
+
+func listRespDto2Vo(respDTO pullrequestsrv.ListPullRequestRespDTO) ListPullRequestRespVO {
+	ret := ListPullRequestRespVO{
+		BaseResp: ginutil.DefaultSuccessResp,
+		Cursor:   respDTO.Cursor,
+	}
+	ret.PrList, _ = listutil.Map(respDTO.PrList, func(t pullrequestsrv.PullRequestDTO) (PullRequestVO, error) {
+		return prDto2Vo(t), nil
+	})
+	return ret
+}
+
+func prDto2Vo(dto pullrequestsrv.PullRequestDTO) PullRequestVO {
+	return PullRequestVO{
+		PrId:           dto.PrId,
+		RepoId:         dto.RepoId,
+		Title:          dto.Title,
+		Target:         dto.Target,
+		TargetCommitId: dto.TargetCommitId,
+		Head:           dto.Head,
+		HeadCommitId:   dto.HeadCommitId,
+		PrStatus:       dto.PrStatus.Int(),
+		PrStatusName:   dto.PrStatus.Readable(),
+		IsMergeAble:    dto.IsMergeAble,
+		HasConflict:    dto.HasConflict,
+		CreateBy:       dto.CreateBy,
+		Created:        dto.Created.Format(timeutil.DefaultTimeFormat),
+		Updated:        dto.Updated.Format(timeutil.DefaultTimeFormat),
+	}
+}
+
+func diffInfo2Vo(info git.DiffCommitsInfo) *DiffCommitsVO {
+	ret := &DiffCommitsVO{
+		Target:       info.Target,
+		Head:         info.Head,
+		TargetCommit: commit2Vo(info.TargetCommit),
+		HeadCommit:   commit2Vo(info.HeadCommit),
+		NumFiles:     info.NumFiles,
+		DiffNumsStats: DiffNumsStatInfoVO{
+			FileChangeNums: info.DiffNumsStats.FileChangeNums,
+			InsertNums:     info.DiffNumsStats.InsertNums,
+			DeleteNums:     info.DiffNumsStats.DeleteNums,
+		},
+		ConflictFiles: info.ConflictFiles,
+	}
+	ret.Commits, _ = listutil.Map(info.Commits, func(t git.Commit) (CommitVO, error) {
+		return commit2Vo(t), nil
+	})
+	ret.DiffNumsStats.Stats, _ = listutil.Map(info.DiffNumsStats.Stats, func(t git.DiffNumsStat) (DiffNumsStatVO, error) {
+		return DiffNumsStatVO{
+			RawPath:    t.Path,
+			Path:       path.Base(t.Path),
+			TotalNums:  t.TotalNums,
+			InsertNums: t.InsertNums,
+			DeleteNums: t.DeleteNums,
+		}, nil
+	})
+	return ret
+}
+
+func commit2Vo(commit git.Commit) CommitVO {
+	return CommitVO{
+		Author:        commit.Author,
+		Committer:     commit.Committer,
+		AuthoredDate:  util.ReadableTimeComparingNow(commit.AuthorSigTime),
+		CommittedDate: util.ReadableTimeComparingNow(commit.CommitSigTime),
+		CommitMsg:     commit.CommitMsg,
+		CommitId:      commit.Id,
+		ShortId:       util.LongCommitId2ShortId(commit.Id),
+	}
+}
+
+func codeOwnerApproval2Vo(t pullrequestsrv.CodeOwnerApprovalDTO) (CodeOwnerApprovalVO, error) {
+	return CodeOwnerApprovalVO{
+		Pattern:  t.Pattern,
+		Owners:   t.Owners,
+		Approved: t.Approved,
+	}, nil
+}
@@ -1,19 +1,33 @@
 package pullrequestapi
 
-import "zgit/standalone/modules/model/pullrequestmd"
+import (
+	"github.com/LeeZXin/zsf-utils/ginutil"
+	"zgit/pkg/git"
+	"zgit/standalone/modules/model/pullrequestmd"
+)
 
 type SubmitPullRequestReqVO struct {
 	RepoId string `json:"repoId"`
+	Title  string `json:"title"`
 	Target string `json:"target"`
 	Head   string `json:"head"`
 }
 
+type SubmitPullRequestRespVO struct {
+	ginutil.BaseResp
+	PrId string `json:"prId"`
+	// 需要审批的代码所有者
+	CodeOwnerList []CodeOwnerApprovalVO `json:"codeOwnerList"`
+}
+
 type ClosePullRequestReqVO struct {
 	PrId string `json:"prId"`
 }
 
 type MergePullRequestReqVO struct {
 	PrId string `json:"prId"`
+	// 合并方式 merge/squash/rebase/fastForwardOnly
+	Strategy git.MergeStrategy `json:"strategy"`
 }
 
 type ReviewPullRequestReqVO struct {
@@ -21,3 +35,164 @@ type ReviewPullRequestReqVO struct {
 	Status    pullrequestmd.ReviewStatus `json:"status"`
 	ReviewMsg string                     `json:"reviewMsg"`
 }
+
+type ListPullRequestReqVO struct {
+	RepoId string `json:"repoId"`
+	// 为空不过滤状态
+	PrStatus *pullrequestmd.PrStatus `json:"prStatus"`
+	CreateBy string                  `json:"createBy"`
+	Reviewer string                  `json:"reviewer"`
+	Target   string                  `json:"target"`
+	Head     string                  `json:"head"`
+	Title    string                  `json:"title"`
+	Offset   int64                   `json:"offset"`
+	Limit    int                     `json:"limit"`
+}
+
+type ListProjectPullRequestReqVO struct {
+	ProjectId string `json:"projectId"`
+	// 为空不过滤状态
+	PrStatus *pullrequestmd.PrStatus `json:"prStatus"`
+	CreateBy string                  `json:"createBy"`
+	Reviewer string                  `json:"reviewer"`
+	Target   string                  `json:"target"`
+	Head     string                  `json:"head"`
+	Title    string                  `json:"title"`
+	Offset   int64                   `json:"offset"`
+	Limit    int                     `json:"limit"`
+}
+
+type PullRequestVO struct {
+	PrId           string `json:"prId"`
+	RepoId         string `json:"repoId"`
+	Title          string `json:"title"`
+	Target         string `json:"target"`
+	TargetCommitId string `json:"targetCommitId"`
+	Head           string `json:"head"`
+	HeadCommitId   string `json:"headCommitId"`
+	PrStatus       int    `json:"prStatus"`
+	PrStatusName   string `json:"prStatusName"`
+	IsMergeAble    bool   `json:"isMergeAble"`
+	HasConflict    bool   `json:"hasConflict"`
+	CreateBy       string `json:"createBy"`
+	Created        string `json:"created"`
+	Updated        string `json:"updated"`
+}
+
+type ListPullRequestRespVO struct {
+	ginutil.BaseResp
+	PrList []PullRequestVO `json:"prList"`
+	Cursor int64           `json:"cursor"`
+}
+
+type GetPullRequestDetailReqVO struct {
+	PrId string `json:"prId"`
+}
+
+type ReviewVO struct {
+	Rid              string `json:"rid"`
+	Reviewer         string `json:"reviewer"`
+	ReviewMsg        string `json:"reviewMsg"`
+	ReviewStatus     int    `json:"reviewStatus"`
+	ReviewStatusName string `json:"reviewStatusName"`
+	IsDismissed      bool   `json:"isDismissed"`
+	Created          string `json:"created"`
+	Updated          string `json:"updated"`
+}
+
+type CommitVO struct {
+	Author        git.User `json:"author"`
+	Committer     git.User `json:"committer"`
+	AuthoredDate  string   `json:"authoredDate"`
+	CommittedDate string   `json:"committedDate"`
+	CommitMsg     string   `json:"commitMsg"`
+	CommitId      string   `json:"commitId"`
+	ShortId       string   `json:"shortId"`
+}
+
+type DiffNumsStatVO struct {
+	RawPath    string `json:"rawPath"`
+	Path       string `json:"path"`
+	TotalNums  int    `json:"totalNums"`
+	InsertNums int    `json:"insertNums"`
+	DeleteNums int    `json:"deleteNums"`
+}
+
+type DiffNumsStatInfoVO struct {
+	FileChangeNums int              `json:"fileChangeNums"`
+	InsertNums     int              `json:"insertNums"`
+	DeleteNums     int              `json:"deleteNums"`
+	Stats          []DiffNumsStatVO `json:"stats"`
+}
+
+type DiffCommitsVO struct {
+	Target        string             `json:"target"`
+	Head          string             `json:"head"`
+	TargetCommit  CommitVO           `json:"targetCommit"`
+	HeadCommit    CommitVO           `json:"headCommit"`
+	Commits       []CommitVO         `json:"commits"`
+	NumFiles      int                `json:"numFiles"`
+	DiffNumsStats DiffNumsStatInfoVO `json:"diffNumsStats"`
+	ConflictFiles []string           `json:"conflictFiles"`
+}
+
+type GetPullRequestDetailRespVO struct {
+	ginutil.BaseResp
+	PullRequest PullRequestVO  `json:"pullRequest"`
+	ReviewList  []ReviewVO     `json:"reviewList"`
+	DiffInfo    *DiffCommitsVO `json:"diffInfo,omitempty"`
+	CanMerge    bool           `json:"canMerge"`
+	// 变更文件对应的代码所有者审批情况
+	CodeOwnerList []CodeOwnerApprovalVO `json:"codeOwnerList"`
+}
+
+type CodeOwnerApprovalVO struct {
+	Pattern  string   `json:"pattern"`
+	Owners   []string `json:"owners"`
+	Approved bool     `json:"approved"`
+}
+
+type AddCommentReqVO struct {
+	PrId string `json:"prId"`
+	// 回复的评论cid 为空则新建评论串
+	ReplyTo  string                    `json:"replyTo"`
+	FilePath string                    `json:"filePath"`
+	Side     pullrequestmd.CommentSide `json:"side"`
+	LineNo   int                       `json:"lineNo"`
+	Content  string                    `json:"content"`
+}
+
+type CommentVO struct {
+	Cid        string `json:"cid"`
+	ThreadId   string `json:"threadId"`
+	FilePath   string `json:"filePath"`
+	Side       int    `json:"side"`
+	LineNo     int    `json:"lineNo"`
+	LineText   string `json:"lineText"`
+	CommitId   string `json:"commitId"`
+	Commenter  string `json:"commenter"`
+	Content    string `json:"content"`
+	IsResolved bool   `json:"isResolved"`
+	ResolvedBy string `json:"resolvedBy"`
+	IsOutdated bool   `json:"isOutdated"`
+	Created    string `json:"created"`
+}
+
+type AddCommentRespVO struct {
+	ginutil.BaseResp
+	Comment CommentVO `json:"comment"`
+}
+
+type ListCommentReqVO struct {
+	PrId     string `json:"prId"`
+	FilePath string `json:"filePath"`
+}
+
+type ListCommentRespVO struct {
+	ginutil.BaseResp
+	CommentList []CommentVO `json:"commentList"`
+}
+
+type ResolveCommentThreadReqVO struct {
+	ThreadId string `json:"threadId"`
+}
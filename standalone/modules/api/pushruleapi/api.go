@@ -0,0 +1,83 @@
+package pushruleapi
+
+import (
+	"github.com/LeeZXin/zsf-utils/ginutil"
+	"github.com/LeeZXin/zsf/http/httpserver"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"zgit/standalone/modules/api/apicommon"
+	"zgit/standalone/modules/service/pushrulesrv"
+	"zgit/util"
+)
+
+func InitApi() {
+	httpserver.AppendRegisterRouterFunc(func(e *gin.Engine) {
+		// 推送规则 repoId为空表示项目级别
+		group := e.Group("/api/pushRule", apicommon.CheckLogin)
+		{
+			// 新增或更新
+			group.POST("/save", savePushRule)
+			// 查看
+			group.POST("/get", apicommon.ReadOnly, getPushRule)
+			// 删除
+			group.POST("/delete", deletePushRule)
+		}
+	})
+}
+
+func savePushRule(c *gin.Context) {
+	var req SavePushRuleReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := pushrulesrv.SavePushRule(c.Request.Context(), pushrulesrv.SavePushRuleReqDTO{
+			ProjectId: req.ProjectId,
+			RepoId:    req.RepoId,
+			Cfg:       req.Cfg,
+			Operator:  apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func getPushRule(c *gin.Context) {
+	var req GetPushRuleReqVO
+	if util.ShouldBindJSON(&req, c) {
+		rule, b, err := pushrulesrv.GetPushRule(c.Request.Context(), pushrulesrv.GetPushRuleReqDTO{
+			ProjectId: req.ProjectId,
+			RepoId:    req.RepoId,
+			Operator:  apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, GetPushRuleRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+			Exists:   b,
+			Data: PushRuleVO{
+				RuleId:    rule.RuleId,
+				ProjectId: rule.ProjectId,
+				RepoId:    rule.RepoId,
+				Cfg:       rule.Cfg,
+			},
+		})
+	}
+}
+
+func deletePushRule(c *gin.Context) {
+	var req DeletePushRuleReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := pushrulesrv.DeletePushRule(c.Request.Context(), pushrulesrv.DeletePushRuleReqDTO{
+			RuleId:   req.RuleId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
@@ -0,0 +1,34 @@
+package pushruleapi
+
+import (
+	"github.com/LeeZXin/zsf-utils/ginutil"
+	"zgit/standalone/modules/model/pushrulemd"
+)
+
+type SavePushRuleReqVO struct {
+	ProjectId string                 `json:"projectId"`
+	RepoId    string                 `json:"repoId"`
+	Cfg       pushrulemd.PushRuleCfg `json:"cfg"`
+}
+
+type GetPushRuleReqVO struct {
+	ProjectId string `json:"projectId"`
+	RepoId    string `json:"repoId"`
+}
+
+type DeletePushRuleReqVO struct {
+	RuleId string `json:"ruleId"`
+}
+
+type PushRuleVO struct {
+	RuleId    string                 `json:"ruleId"`
+	ProjectId string                 `json:"projectId"`
+	RepoId    string                 `json:"repoId"`
+	Cfg       pushrulemd.PushRuleCfg `json:"cfg"`
+}
+
+type GetPushRuleRespVO struct {
+	ginutil.BaseResp
+	Exists bool       `json:"exists"`
+	Data   PushRuleVO `json:"data"`
+}
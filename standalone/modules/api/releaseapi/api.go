@@ -0,0 +1,258 @@
+package releaseapi
+
+import (
+	"fmt"
+	"github.com/LeeZXin/zsf-utils/ginutil"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/http/httpserver"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"zgit/standalone/modules/api/apicommon"
+	"zgit/standalone/modules/service/releasesrv"
+	"zgit/util"
+)
+
+func InitApi() {
+	httpserver.AppendRegisterRouterFunc(func(e *gin.Engine) {
+		// 发布版本
+		group := e.Group("/api/release", apicommon.CheckLogin)
+		{
+			// 创建发布版本
+			group.POST("/create", apicommon.RepoWrite, createRelease)
+			// 编辑发布版本
+			group.POST("/update", apicommon.RepoWrite, updateRelease)
+			// 删除发布版本
+			group.POST("/delete", apicommon.RepoWrite, deleteRelease)
+			// 发布版本详情
+			group.POST("/get", apicommon.ReadOnly, getRelease)
+			// 发布版本列表
+			group.POST("/list", apicommon.ReadOnly, listRelease)
+			// 生成变更记录
+			group.POST("/changelog", apicommon.ReadOnly, generateChangelog)
+			// 上传附件 multipart表单 releaseId和file
+			group.POST("/asset/upload", apicommon.RepoWrite, uploadAsset)
+			// 删除附件
+			group.POST("/asset/delete", apicommon.RepoWrite, deleteAsset)
+			// 下载附件 ?assetId=
+			group.GET("/asset/download", apicommon.ReadOnly, downloadAsset)
+		}
+	})
+}
+
+func createRelease(c *gin.Context) {
+	var req CreateReleaseReqVO
+	if util.ShouldBindJSON(&req, c) {
+		release, err := releasesrv.CreateRelease(c.Request.Context(), releasesrv.CreateReleaseReqDTO{
+			RepoId:            req.RepoId,
+			TagName:           req.TagName,
+			Target:            req.Target,
+			Title:             req.Title,
+			Note:              req.Note,
+			IsDraft:           req.IsDraft,
+			IsPrerelease:      req.IsPrerelease,
+			GenerateChangelog: req.GenerateChangelog,
+			Operator:          apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ReleaseRespVO{
+			BaseResp:  ginutil.DefaultSuccessResp,
+			ReleaseVO: releaseDto2Vo(release),
+		})
+	}
+}
+
+func updateRelease(c *gin.Context) {
+	var req UpdateReleaseReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := releasesrv.UpdateRelease(c.Request.Context(), releasesrv.UpdateReleaseReqDTO{
+			ReleaseId:    req.ReleaseId,
+			Title:        req.Title,
+			Note:         req.Note,
+			IsDraft:      req.IsDraft,
+			IsPrerelease: req.IsPrerelease,
+			Operator:     apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func deleteRelease(c *gin.Context) {
+	var req DeleteReleaseReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := releasesrv.DeleteRelease(c.Request.Context(), releasesrv.DeleteReleaseReqDTO{
+			ReleaseId: req.ReleaseId,
+			Operator:  apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func getRelease(c *gin.Context) {
+	var req GetReleaseReqVO
+	if util.ShouldBindJSON(&req, c) {
+		release, err := releasesrv.GetRelease(c.Request.Context(), releasesrv.GetReleaseReqDTO{
+			ReleaseId: req.ReleaseId,
+			Operator:  apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ReleaseRespVO{
+			BaseResp:  ginutil.DefaultSuccessResp,
+			ReleaseVO: releaseDto2Vo(release),
+		})
+	}
+}
+
+func listRelease(c *gin.Context) {
+	var req ListReleaseReqVO
+	if util.ShouldBindJSON(&req, c) {
+		respDTO, err := releasesrv.ListRelease(c.Request.Context(), releasesrv.ListReleaseReqDTO{
+			RepoId:   req.RepoId,
+			Cursor:   req.Cursor,
+			Limit:    req.Limit,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		ret := ListReleaseRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+			Cursor:   respDTO.Cursor,
+		}
+		ret.Releases, _ = listutil.Map(respDTO.Releases, func(t releasesrv.ReleaseDTO) (ReleaseVO, error) {
+			return releaseDto2Vo(t), nil
+		})
+		c.JSON(http.StatusOK, ret)
+	}
+}
+
+func generateChangelog(c *gin.Context) {
+	var req GenerateChangelogReqVO
+	if util.ShouldBindJSON(&req, c) {
+		changelog, err := releasesrv.GenerateChangelog(c.Request.Context(), releasesrv.GenerateChangelogReqDTO{
+			RepoId:   req.RepoId,
+			TagName:  req.TagName,
+			Target:   req.Target,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, GenerateChangelogRespVO{
+			BaseResp:  ginutil.DefaultSuccessResp,
+			Changelog: changelog,
+		})
+	}
+}
+
+func uploadAsset(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		util.HandleApiErr(util.InvalidArgsError(), c)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		util.HandleApiErr(util.InvalidArgsError(), c)
+		return
+	}
+	defer file.Close()
+	// 未指定名称时使用上传的文件名
+	name := c.PostForm("name")
+	if name == "" {
+		name = fileHeader.Filename
+	}
+	asset, err := releasesrv.UploadAsset(c.Request.Context(), releasesrv.UploadAssetReqDTO{
+		ReleaseId: c.PostForm("releaseId"),
+		Name:      name,
+		Size:      fileHeader.Size,
+		Body:      file,
+		Operator:  apicommon.MustGetLoginUser(c),
+	})
+	if err != nil {
+		util.HandleApiErr(err, c)
+		return
+	}
+	c.JSON(http.StatusOK, AssetRespVO{
+		BaseResp: ginutil.DefaultSuccessResp,
+		AssetVO:  assetDto2Vo(asset),
+	})
+}
+
+func deleteAsset(c *gin.Context) {
+	var req DeleteAssetReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := releasesrv.DeleteAsset(c.Request.Context(), releasesrv.DeleteAssetReqDTO{
+			AssetId:  req.AssetId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func downloadAsset(c *gin.Context) {
+	respDTO, err := releasesrv.DownloadAsset(c.Request.Context(), releasesrv.DownloadAssetReqDTO{
+		AssetId:  c.Query("assetId"),
+		Operator: apicommon.MustGetLoginUser(c),
+	})
+	if err != nil {
+		util.HandleApiErr(err, c)
+		return
+	}
+	defer respDTO.Close()
+	c.DataFromReader(http.StatusOK, respDTO.Size, "application/octet-stream", respDTO, map[string]string{
+		"Content-Disposition":           fmt.Sprintf("attachment; filename=\"%s\"", respDTO.Name),
+		"Access-Control-Expose-Headers": "Content-Disposition",
+	})
+}
+
+func releaseDto2Vo(dto releasesrv.ReleaseDTO) ReleaseVO {
+	ret := ReleaseVO{
+		ReleaseId:    dto.ReleaseId,
+		RepoId:       dto.RepoId,
+		TagName:      dto.TagName,
+		Target:       dto.Target,
+		Title:        dto.Title,
+		Note:         dto.Note,
+		NoteHtml:     dto.NoteHtml,
+		IsDraft:      dto.IsDraft,
+		IsPrerelease: dto.IsPrerelease,
+		CommitId:     dto.CommitId,
+		ShortId:      dto.ShortId,
+		CreateBy:     dto.CreateBy,
+		Created:      util.ReadableTimeComparingNow(dto.Created),
+	}
+	ret.Assets, _ = listutil.Map(dto.Assets, func(t releasesrv.AssetDTO) (AssetVO, error) {
+		return assetDto2Vo(t), nil
+	})
+	return ret
+}
+
+func assetDto2Vo(dto releasesrv.AssetDTO) AssetVO {
+	return AssetVO{
+		AssetId:  dto.AssetId,
+		Name:     dto.Name,
+		Size:     dto.Size,
+		CreateBy: dto.CreateBy,
+		Created:  util.ReadableTimeComparingNow(dto.Created),
+	}
+}
@@ -0,0 +1,92 @@
+package releaseapi
+
+import "github.com/LeeZXin/zsf-utils/ginutil"
+
+type CreateReleaseReqVO struct {
+	RepoId            string `json:"repoId"`
+	TagName           string `json:"tagName"`
+	Target            string `json:"target"`
+	Title             string `json:"title"`
+	Note              string `json:"note"`
+	IsDraft           bool   `json:"isDraft"`
+	IsPrerelease      bool   `json:"isPrerelease"`
+	GenerateChangelog bool   `json:"generateChangelog"`
+}
+
+type UpdateReleaseReqVO struct {
+	ReleaseId    string `json:"releaseId"`
+	Title        string `json:"title"`
+	Note         string `json:"note"`
+	IsDraft      bool   `json:"isDraft"`
+	IsPrerelease bool   `json:"isPrerelease"`
+}
+
+type DeleteReleaseReqVO struct {
+	ReleaseId string `json:"releaseId"`
+}
+
+type GetReleaseReqVO struct {
+	ReleaseId string `json:"releaseId"`
+}
+
+type ListReleaseReqVO struct {
+	RepoId string `json:"repoId"`
+	Cursor int64  `json:"cursor"`
+	Limit  int    `json:"limit"`
+}
+
+type ListReleaseRespVO struct {
+	ginutil.BaseResp
+	Releases []ReleaseVO `json:"releases"`
+	Cursor   int64       `json:"cursor"`
+}
+
+type GenerateChangelogReqVO struct {
+	RepoId  string `json:"repoId"`
+	TagName string `json:"tagName"`
+	Target  string `json:"target"`
+}
+
+type GenerateChangelogRespVO struct {
+	ginutil.BaseResp
+	Changelog string `json:"changelog"`
+}
+
+type DeleteAssetReqVO struct {
+	AssetId string `json:"assetId"`
+}
+
+type ReleaseVO struct {
+	ReleaseId    string    `json:"releaseId"`
+	RepoId       string    `json:"repoId"`
+	TagName      string    `json:"tagName"`
+	Target       string    `json:"target"`
+	Title        string    `json:"title"`
+	Note         string    `json:"note"`
+	NoteHtml     string    `json:"noteHtml"`
+	IsDraft      bool      `json:"isDraft"`
+	IsPrerelease bool      `json:"isPrerelease"`
+	CommitId     string    `json:"commitId"`
+	ShortId      string    `json:"shortId"`
+	Assets       []AssetVO `json:"assets"`
+	CreateBy     string    `json:"createBy"`
+	Created      string    `json:"created"`
+}
+
+type ReleaseRespVO struct {
+	ginutil.BaseResp
+	ReleaseVO
+}
+
+type AssetVO struct {
+	AssetId  string `json:"assetId"`
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	CreateBy string `json:"createBy"`
+	Created  string `json:"created"`
+}
+
+type AssetRespVO struct {
+	ginutil.BaseResp
+	AssetVO
+}
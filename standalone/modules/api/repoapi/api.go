@@ -7,6 +7,8 @@ import (
 	"github.com/LeeZXin/zsf/http/httpserver"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strings"
+	"zgit/pkg/git"
 	"zgit/standalone/modules/api/apicommon"
 	"zgit/standalone/modules/model/repomd"
 	"zgit/standalone/modules/service/reposrv"
@@ -18,33 +20,61 @@ func InitApi() {
 		group := e.Group("/api/repo", apicommon.CheckLogin)
 		{
 			// 获取模版列表
-			group.GET("/allGitIgnoreTemplateList", allGitIgnoreTemplateList)
+			group.GET("/allGitIgnoreTemplateList", apicommon.ReadOnly, allGitIgnoreTemplateList)
 			// 获取仓库类型列表
-			group.GET("/allTypeList", allTypeList)
+			group.GET("/allTypeList", apicommon.ReadOnly, allTypeList)
 			// 初始化仓库
-			group.POST("/init", initRepo)
+			group.POST("/init", apicommon.RepoWrite, initRepo)
+			// 从远端导入仓库
+			group.POST("/import", apicommon.RepoWrite, importRepo)
 			// 删除仓库
-			group.POST("/delete", deleteRepo)
+			group.POST("/delete", apicommon.RepoWrite, deleteRepo)
 			// 展示仓库列表
-			group.POST("/list", listRepo)
+			group.POST("/list", apicommon.ReadOnly, listRepo)
 			// 展示仓库主页
-			group.POST("/tree", treeRepo)
+			group.POST("/tree", apicommon.ReadOnly, treeRepo)
 			// 展示更多文件列表
-			group.POST("/entries", entriesRepo)
+			group.POST("/entries", apicommon.ReadOnly, entriesRepo)
 			// 展示单个文件内容
-			group.POST("/catFile", catFile)
+			group.POST("/catFile", apicommon.ReadOnly, catFile)
 			// 展示仓库所有分支
-			group.POST("/allBranches", allBranches)
+			group.POST("/allBranches", apicommon.ReadOnly, allBranches)
+			// 分页展示分支 带最后一次提交和领先落后数
+			group.POST("/listBranches", apicommon.ReadOnly, listBranches)
+			// 创建分支
+			group.POST("/createBranch", apicommon.RepoWrite, createBranch)
+			// 删除分支
+			group.POST("/deleteBranch", apicommon.RepoWrite, deleteBranch)
+			// 重命名分支
+			group.POST("/renameBranch", apicommon.RepoWrite, renameBranch)
+			// 比较分支
+			group.POST("/compareBranches", apicommon.ReadOnly, compareBranches)
 			// 展示仓库所有tag
-			group.POST("/allTags", allTags)
+			group.POST("/allTags", apicommon.ReadOnly, allTags)
+			// 创建标签
+			group.POST("/createTag", apicommon.RepoWrite, createTag)
+			// 删除标签
+			group.POST("/deleteTag", apicommon.RepoWrite, deleteTag)
+			// 标签详情
+			group.POST("/getTag", apicommon.ReadOnly, getTag)
 			// gc
-			group.POST("/gc", gc)
+			group.POST("/gc", apicommon.RepoWrite, gc)
 			// 提交差异
-			group.POST("/diffCommits", diffCommits)
+			group.POST("/diffCommits", apicommon.ReadOnly, diffCommits)
+			// 提交历史
+			group.POST("/history", apicommon.ReadOnly, historyCommits)
+			// 文件逐行追溯
+			group.POST("/blame", apicommon.ReadOnly, blame)
+			// 在线编辑文件
+			group.POST("/editFiles", apicommon.RepoWrite, editFiles)
 			// 展示提交文件差异
-			group.POST("/diffFile", diffFile)
+			group.POST("/diffFile", apicommon.ReadOnly, diffFile)
 			// 展示文件内容
-			group.POST("/showDiffTextContent", showDiffTextContent)
+			group.POST("/showDiffTextContent", apicommon.ReadOnly, showDiffTextContent)
+			// 下载代码压缩包 ?repoId=&ref=&format=&prefix=
+			group.GET("/archive", apicommon.ReadOnly, archive)
+			// 下载代码压缩包固定地址 如/api/repo/archive/{repoId}/v1.0.tar.gz
+			group.GET("/archive/:repoId/*file", apicommon.ReadOnly, archiveByPath)
 		}
 		// 仓库管理
 		group = e.Group("/api/repoManage", apicommon.CheckLogin)
@@ -74,6 +104,196 @@ func allBranches(c *gin.Context) {
 	}
 }
 
+func createTag(c *gin.Context) {
+	var req CreateTagReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := reposrv.CreateTag(c.Request.Context(), reposrv.CreateTagReqDTO{
+			RepoId:   req.RepoId,
+			Tag:      req.Tag,
+			StartRef: req.StartRef,
+			Message:  req.Message,
+			Sign:     req.Sign,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func deleteTag(c *gin.Context) {
+	var req DeleteTagReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := reposrv.DeleteTag(c.Request.Context(), reposrv.DeleteTagReqDTO{
+			RepoId:   req.RepoId,
+			Tag:      req.Tag,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func getTag(c *gin.Context) {
+	var req GetTagReqVO
+	if util.ShouldBindJSON(&req, c) {
+		tag, err := reposrv.GetTag(c.Request.Context(), reposrv.GetTagReqDTO{
+			RepoId:   req.RepoId,
+			Tag:      req.Tag,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		ret := GetTagRespVO{
+			BaseResp:    ginutil.DefaultSuccessResp,
+			Name:        tag.Name,
+			IsAnnotated: tag.IsAnnotated,
+			Tagger:      tag.Tagger,
+			Message:     tag.Message,
+			IsSigned:    tag.IsSigned,
+			Commit:      commitDto2Vo(tag.Commit),
+		}
+		if tag.IsAnnotated {
+			ret.TaggedDate = util.ReadableTimeComparingNow(tag.TaggedDate)
+		}
+		c.JSON(http.StatusOK, ret)
+	}
+}
+
+func listBranches(c *gin.Context) {
+	var req ListBranchesReqVO
+	if util.ShouldBindJSON(&req, c) {
+		respDTO, err := reposrv.ListBranches(c.Request.Context(), reposrv.ListBranchesReqDTO{
+			RepoId:   req.RepoId,
+			Keyword:  req.Keyword,
+			Offset:   req.Offset,
+			Limit:    req.Limit,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		ret := ListBranchesRespVO{
+			BaseResp:      ginutil.DefaultSuccessResp,
+			DefaultBranch: respDTO.DefaultBranch,
+			Total:         respDTO.Total,
+		}
+		ret.Branches, _ = listutil.Map(respDTO.Branches, func(t reposrv.BranchDTO) (BranchVO, error) {
+			return BranchVO{
+				Name:        t.Name,
+				IsDefault:   t.IsDefault,
+				IsProtected: t.IsProtected,
+				LastCommit:  commitDto2Vo(t.LastCommit),
+				Ahead:       t.Ahead,
+				Behind:      t.Behind,
+			}, nil
+		})
+		c.JSON(http.StatusOK, ret)
+	}
+}
+
+func createBranch(c *gin.Context) {
+	var req CreateBranchReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := reposrv.CreateBranch(c.Request.Context(), reposrv.CreateBranchReqDTO{
+			RepoId:   req.RepoId,
+			Branch:   req.Branch,
+			StartRef: req.StartRef,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func deleteBranch(c *gin.Context) {
+	var req DeleteBranchReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := reposrv.DeleteBranch(c.Request.Context(), reposrv.DeleteBranchReqDTO{
+			RepoId:   req.RepoId,
+			Branch:   req.Branch,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func renameBranch(c *gin.Context) {
+	var req RenameBranchReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := reposrv.RenameBranch(c.Request.Context(), reposrv.RenameBranchReqDTO{
+			RepoId:    req.RepoId,
+			Branch:    req.Branch,
+			NewBranch: req.NewBranch,
+			Operator:  apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func compareBranches(c *gin.Context) {
+	var req CompareBranchesReqVO
+	if util.ShouldBindJSON(&req, c) {
+		respDTO, err := reposrv.CompareBranches(c.Request.Context(), reposrv.CompareBranchesReqDTO{
+			RepoId:   req.RepoId,
+			Base:     req.Base,
+			Head:     req.Head,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		respVO := CompareBranchesRespVO{
+			BaseResp:   ginutil.DefaultSuccessResp,
+			Base:       respDTO.Base,
+			Head:       respDTO.Head,
+			BaseCommit: commitDto2Vo(respDTO.BaseCommit),
+			HeadCommit: commitDto2Vo(respDTO.HeadCommit),
+			MergeBase:  respDTO.MergeBase,
+			Ahead:      respDTO.Ahead,
+			Behind:     respDTO.Behind,
+			DiffNumsStats: DiffNumsStatInfoVO{
+				FileChangeNums: respDTO.DiffNumsStats.FileChangeNums,
+				InsertNums:     respDTO.DiffNumsStats.InsertNums,
+				DeleteNums:     respDTO.DiffNumsStats.DeleteNums,
+			},
+		}
+		respVO.Commits, _ = listutil.Map(respDTO.Commits, func(t reposrv.CommitDTO) (CommitVO, error) {
+			return commitDto2Vo(t), nil
+		})
+		respVO.DiffNumsStats.Stats, _ = listutil.Map(respDTO.DiffNumsStats.Stats, func(t reposrv.DiffNumsStatDTO) (DiffNumsStatVO, error) {
+			return DiffNumsStatVO{
+				RawPath:    t.RawPath,
+				Path:       t.Path,
+				TotalNums:  t.TotalNums,
+				InsertNums: t.InsertNums,
+				DeleteNums: t.DeleteNums,
+			}, nil
+		})
+		c.JSON(http.StatusOK, respVO)
+	}
+}
+
 func allTags(c *gin.Context) {
 	var req AllTagsReqVO
 	if util.ShouldBindJSON(&req, c) {
@@ -228,6 +448,57 @@ func initRepo(c *gin.Context) {
 	}
 }
 
+func importRepo(c *gin.Context) {
+	var req ImportRepoReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := reposrv.ImportRepo(c.Request.Context(), reposrv.ImportRepoReqDTO{
+			Operator:     apicommon.MustGetLoginUser(c),
+			ProjectId:    req.ProjectId,
+			Name:         req.Name,
+			Desc:         req.Desc,
+			RepoType:     repomd.RepoType(req.RepoType),
+			RemoteUrl:    req.RemoteUrl,
+			Username:     req.Username,
+			Password:     req.Password,
+			IsMirror:     req.IsMirror,
+			SyncInterval: req.SyncInterval,
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func archive(c *gin.Context) {
+	doArchive(c, c.Query("repoId"), c.Query("ref"), git.ArchiveFormat(c.Query("format")))
+}
+
+func archiveByPath(c *gin.Context) {
+	ref, format, b := git.GetArchiveFormatBySuffix(strings.TrimPrefix(c.Param("file"), "/"))
+	if !b {
+		util.HandleApiErr(util.InvalidArgsError(), c)
+		return
+	}
+	doArchive(c, c.Param("repoId"), ref, format)
+}
+
+func doArchive(c *gin.Context, repoId, ref string, format git.ArchiveFormat) {
+	respDTO, err := reposrv.Archive(c.Request.Context(), reposrv.ArchiveReqDTO{
+		RepoId:   repoId,
+		RefName:  ref,
+		Format:   format,
+		Prefix:   c.Query("prefix"),
+		Operator: apicommon.MustGetLoginUser(c),
+	})
+	if err != nil {
+		util.HandleApiErr(err, c)
+		return
+	}
+	c.FileAttachment(respDTO.FilePath, respDTO.FileName)
+}
+
 func deleteRepo(c *gin.Context) {
 	var req DeleteRepoReqVO
 	if util.ShouldBindJSON(&req, c) {
@@ -381,6 +652,119 @@ func diffCommits(c *gin.Context) {
 	}
 }
 
+func historyCommits(c *gin.Context) {
+	var req HistoryCommitsReqVO
+	if util.ShouldBindJSON(&req, c) {
+		respDTO, err := reposrv.HistoryCommits(c.Request.Context(), reposrv.HistoryCommitsReqDTO{
+			RepoId:   req.RepoId,
+			RefName:  req.RefName,
+			Path:     req.Path,
+			Follow:   req.Follow,
+			Author:   req.Author,
+			Grep:     req.Grep,
+			Since:    req.Since,
+			Until:    req.Until,
+			Cursor:   req.Cursor,
+			Limit:    req.Limit,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		ret := HistoryCommitsRespVO{
+			BaseResp:     ginutil.DefaultSuccessResp,
+			HeadCommitId: respDTO.HeadCommitId,
+			Cursor:       respDTO.Cursor,
+			HasMore:      respDTO.HasMore,
+		}
+		ret.Commits, _ = listutil.Map(respDTO.Commits, func(t reposrv.HistoryCommitDTO) (HistoryCommitVO, error) {
+			return HistoryCommitVO{
+				CommitVO: commitDto2Vo(t.CommitDTO),
+				Parent:   t.Parent,
+				Signature: CommitSignatureVO{
+					Status:   string(t.Signature.Status),
+					SignType: t.Signature.SignType,
+					Account:  t.Signature.Account,
+					KeyId:    t.Signature.KeyId,
+				},
+			}, nil
+		})
+		c.JSON(http.StatusOK, ret)
+	}
+}
+
+func blame(c *gin.Context) {
+	var req BlameReqVO
+	if util.ShouldBindJSON(&req, c) {
+		respDTO, err := reposrv.Blame(c.Request.Context(), reposrv.BlameReqDTO{
+			RepoId:    req.RepoId,
+			RefName:   req.RefName,
+			FilePath:  req.FilePath,
+			StartLine: req.StartLine,
+			EndLine:   req.EndLine,
+			Operator:  apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		ret := BlameRespVO{
+			BaseResp:  ginutil.DefaultSuccessResp,
+			CommitId:  respDTO.CommitId,
+			StartLine: respDTO.StartLine,
+			EndLine:   respDTO.EndLine,
+			HasMore:   respDTO.HasMore,
+		}
+		ret.Parts, _ = listutil.Map(respDTO.Parts, func(t reposrv.BlamePartDTO) (BlamePartVO, error) {
+			return BlamePartVO{
+				Commit:    commitDto2Vo(t.Commit),
+				FilePath:  t.FilePath,
+				StartLine: t.StartLine,
+				EndLine:   t.EndLine,
+				Lines:     t.Lines,
+			}, nil
+		})
+		c.JSON(http.StatusOK, ret)
+	}
+}
+
+func editFiles(c *gin.Context) {
+	var req EditFilesReqVO
+	if util.ShouldBindJSON(&req, c) {
+		files, _ := listutil.Map(req.Files, func(t EditFileVO) (reposrv.EditFileDTO, error) {
+			return reposrv.EditFileDTO{
+				Action:   git.FileAction(t.Action),
+				FilePath: t.FilePath,
+				PrevPath: t.PrevPath,
+				Content:  t.Content,
+			}, nil
+		})
+		respDTO, err := reposrv.EditFiles(c.Request.Context(), reposrv.EditFilesReqDTO{
+			RepoId:        req.RepoId,
+			Branch:        req.Branch,
+			StartCommitId: req.StartCommitId,
+			NewBranch:     req.NewBranch,
+			Message:       req.Message,
+			Files:         files,
+			CreatePr:      req.CreatePr,
+			PrTitle:       req.PrTitle,
+			Operator:      apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, EditFilesRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+			CommitId: respDTO.CommitId,
+			Branch:   respDTO.Branch,
+			PrId:     respDTO.PrId,
+			PrErrMsg: respDTO.PrErrMsg,
+		})
+	}
+}
+
 func showDiffTextContent(c *gin.Context) {
 	var req ShowDiffTextContentReqVO
 	if util.ShouldBindJSON(&req, c) {
@@ -20,6 +20,18 @@ type InitRepoReqVO struct {
 	DefaultBranch string `json:"defaultBranch"`
 }
 
+type ImportRepoReqVO struct {
+	ProjectId    string `json:"projectId"`
+	Name         string `json:"name"`
+	Desc         string `json:"desc"`
+	RepoType     int    `json:"repoType"`
+	RemoteUrl    string `json:"remoteUrl"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	IsMirror     bool   `json:"isMirror"`
+	SyncInterval int    `json:"syncInterval"`
+}
+
 type DeleteRepoReqVO struct {
 	RepoId string `json:"repoId"`
 }
@@ -128,6 +140,94 @@ type AllBranchesRespVO struct {
 	Data []string `json:"data"`
 }
 
+type CreateTagReqVO struct {
+	RepoId   string `json:"repoId"`
+	Tag      string `json:"tag"`
+	StartRef string `json:"startRef"`
+	Message  string `json:"message"`
+	Sign     bool   `json:"sign"`
+}
+
+type DeleteTagReqVO struct {
+	RepoId string `json:"repoId"`
+	Tag    string `json:"tag"`
+}
+
+type GetTagReqVO struct {
+	RepoId string `json:"repoId"`
+	Tag    string `json:"tag"`
+}
+
+type GetTagRespVO struct {
+	ginutil.BaseResp
+	Name        string   `json:"name"`
+	IsAnnotated bool     `json:"isAnnotated"`
+	Tagger      git.User `json:"tagger"`
+	TaggedDate  string   `json:"taggedDate"`
+	Message     string   `json:"message"`
+	IsSigned    bool     `json:"isSigned"`
+	Commit      CommitVO `json:"commit"`
+}
+
+type ListBranchesReqVO struct {
+	RepoId  string `json:"repoId"`
+	Keyword string `json:"keyword"`
+	Offset  int    `json:"offset"`
+	Limit   int    `json:"limit"`
+}
+
+type BranchVO struct {
+	Name        string   `json:"name"`
+	IsDefault   bool     `json:"isDefault"`
+	IsProtected bool     `json:"isProtected"`
+	LastCommit  CommitVO `json:"lastCommit"`
+	Ahead       int      `json:"ahead"`
+	Behind      int      `json:"behind"`
+}
+
+type ListBranchesRespVO struct {
+	ginutil.BaseResp
+	DefaultBranch string     `json:"defaultBranch"`
+	Branches      []BranchVO `json:"branches"`
+	Total         int        `json:"total"`
+}
+
+type CreateBranchReqVO struct {
+	RepoId   string `json:"repoId"`
+	Branch   string `json:"branch"`
+	StartRef string `json:"startRef"`
+}
+
+type DeleteBranchReqVO struct {
+	RepoId string `json:"repoId"`
+	Branch string `json:"branch"`
+}
+
+type RenameBranchReqVO struct {
+	RepoId    string `json:"repoId"`
+	Branch    string `json:"branch"`
+	NewBranch string `json:"newBranch"`
+}
+
+type CompareBranchesReqVO struct {
+	RepoId string `json:"repoId"`
+	Base   string `json:"base"`
+	Head   string `json:"head"`
+}
+
+type CompareBranchesRespVO struct {
+	ginutil.BaseResp
+	Base          string             `json:"base"`
+	Head          string             `json:"head"`
+	BaseCommit    CommitVO           `json:"baseCommit"`
+	HeadCommit    CommitVO           `json:"headCommit"`
+	MergeBase     string             `json:"mergeBase"`
+	Ahead         int                `json:"ahead"`
+	Behind        int                `json:"behind"`
+	Commits       []CommitVO         `json:"commits"`
+	DiffNumsStats DiffNumsStatInfoVO `json:"diffNumsStats"`
+}
+
 type AllTagsReqVO struct {
 	RepoId string `json:"repoId"`
 }
@@ -147,6 +247,92 @@ type PrepareMergeReqVO struct {
 	Head   string `json:"head"`
 }
 
+type HistoryCommitsReqVO struct {
+	RepoId  string `json:"repoId"`
+	RefName string `json:"refName"`
+	Path    string `json:"path"`
+	Follow  bool   `json:"follow"`
+	Author  string `json:"author"`
+	Grep    string `json:"grep"`
+	Since   int64  `json:"since"`
+	Until   int64  `json:"until"`
+	Cursor  int64  `json:"cursor"`
+	Limit   int    `json:"limit"`
+}
+
+type CommitSignatureVO struct {
+	Status   string `json:"status"`
+	SignType string `json:"signType"`
+	Account  string `json:"account"`
+	KeyId    string `json:"keyId"`
+}
+
+type HistoryCommitVO struct {
+	CommitVO
+	Parent    []string          `json:"parent"`
+	Signature CommitSignatureVO `json:"signature"`
+}
+
+type HistoryCommitsRespVO struct {
+	ginutil.BaseResp
+	HeadCommitId string            `json:"headCommitId"`
+	Commits      []HistoryCommitVO `json:"commits"`
+	Cursor       int64             `json:"cursor"`
+	HasMore      bool              `json:"hasMore"`
+}
+
+type BlameReqVO struct {
+	RepoId    string `json:"repoId"`
+	RefName   string `json:"refName"`
+	FilePath  string `json:"filePath"`
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+}
+
+type BlamePartVO struct {
+	Commit    CommitVO `json:"commit"`
+	FilePath  string   `json:"filePath"`
+	StartLine int      `json:"startLine"`
+	EndLine   int      `json:"endLine"`
+	Lines     []string `json:"lines"`
+}
+
+type BlameRespVO struct {
+	ginutil.BaseResp
+	CommitId  string        `json:"commitId"`
+	StartLine int           `json:"startLine"`
+	EndLine   int           `json:"endLine"`
+	Parts     []BlamePartVO `json:"parts"`
+	HasMore   bool          `json:"hasMore"`
+}
+
+type EditFileVO struct {
+	Action   string `json:"action"`
+	FilePath string `json:"filePath"`
+	PrevPath string `json:"prevPath"`
+	Content  string `json:"content"`
+}
+
+type EditFilesReqVO struct {
+	RepoId        string       `json:"repoId"`
+	Branch        string       `json:"branch"`
+	StartCommitId string       `json:"startCommitId"`
+	NewBranch     string       `json:"newBranch"`
+	Message       string       `json:"message"`
+	Files         []EditFileVO `json:"files"`
+	CreatePr      bool         `json:"createPr"`
+	PrTitle       string       `json:"prTitle"`
+}
+
+type EditFilesRespVO struct {
+	ginutil.BaseResp
+	CommitId string `json:"commitId"`
+	Branch   string `json:"branch"`
+	PrId     string `json:"prId,omitempty"`
+	// 合并请求创建失败原因
+	PrErrMsg string `json:"prErrMsg,omitempty"`
+}
+
 type DiffFileReqVO struct {
 	RepoId   string `json:"repoId"`
 	Target   string `json:"target"`
@@ -16,15 +16,15 @@ func InitApi() {
 		group := e.Group("/api/sshKey", apicommon.CheckLogin)
 		{
 			// 删除
-			group.POST("/delete", deleteSshKey)
+			group.POST("/delete", apicommon.ForbidAccessToken, deleteSshKey)
 			// 插入
-			group.POST("/insert", insertSshKey)
+			group.POST("/insert", apicommon.ForbidAccessToken, insertSshKey)
 			// 列表展示
-			group.POST("/list", listSshKey)
+			group.POST("/list", apicommon.ReadOnly, listSshKey)
 			// 校验
-			group.POST("/verify", verifySshKey)
+			group.POST("/verify", apicommon.ForbidAccessToken, verifySshKey)
 			// 获取校验token
-			group.POST("/getToken", getToken)
+			group.POST("/getToken", apicommon.ForbidAccessToken, getToken)
 		}
 	})
 }
@@ -0,0 +1,84 @@
+package tagapi
+
+import (
+	"github.com/LeeZXin/zsf-utils/ginutil"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/http/httpserver"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"zgit/standalone/modules/api/apicommon"
+	"zgit/standalone/modules/service/tagsrv"
+	"zgit/util"
+)
+
+func InitApi() {
+	httpserver.AppendRegisterRouterFunc(func(e *gin.Engine) {
+		// 保护标签
+		group := e.Group("/api/protectedTag", apicommon.CheckLogin)
+		{
+			// 新增保护标签
+			group.POST("/insert", insertProtectedTag)
+			// 删除保护标签
+			group.POST("/delete", deleteProtectedTag)
+			// 保护标签列表
+			group.POST("/list", apicommon.ReadOnly, listProtectedTag)
+		}
+	})
+}
+
+func insertProtectedTag(c *gin.Context) {
+	var req InsertProtectedTagReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := tagsrv.InsertProtectedTag(c.Request.Context(), tagsrv.InsertProtectedTagReqDTO{
+			RepoId:   req.RepoId,
+			Tag:      req.Tag,
+			Cfg:      req.Cfg,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func deleteProtectedTag(c *gin.Context) {
+	var req DeleteProtectedTagReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := tagsrv.DeleteProtectedTag(c.Request.Context(), tagsrv.DeleteProtectedTagReqDTO{
+			Tid:      req.Tid,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func listProtectedTag(c *gin.Context) {
+	var req ListProtectedTagReqVO
+	if util.ShouldBindJSON(&req, c) {
+		tagList, err := tagsrv.ListProtectedTag(c.Request.Context(), tagsrv.ListProtectedTagReqDTO{
+			RepoId:   req.RepoId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		respVO := ListProtectedTagRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+		}
+		respVO.Tags, _ = listutil.Map(tagList, func(t tagsrv.ProtectedTagDTO) (ProtectedTagVO, error) {
+			return ProtectedTagVO{
+				Tid: t.Tid,
+				Tag: t.Tag,
+				Cfg: t.Cfg,
+			}, nil
+		})
+		c.JSON(http.StatusOK, respVO)
+	}
+}
@@ -0,0 +1,31 @@
+package tagapi
+
+import (
+	"github.com/LeeZXin/zsf-utils/ginutil"
+	"zgit/standalone/modules/model/tagmd"
+)
+
+type InsertProtectedTagReqVO struct {
+	RepoId string                `json:"repoId"`
+	Tag    string                `json:"tag"`
+	Cfg    tagmd.ProtectedTagCfg `json:"cfg"`
+}
+
+type DeleteProtectedTagReqVO struct {
+	Tid string `json:"tid"`
+}
+
+type ListProtectedTagReqVO struct {
+	RepoId string `json:"repoId"`
+}
+
+type ProtectedTagVO struct {
+	Tid string                `json:"tid"`
+	Tag string                `json:"tag"`
+	Cfg tagmd.ProtectedTagCfg `json:"cfg"`
+}
+
+type ListProtectedTagRespVO struct {
+	ginutil.BaseResp
+	Tags []ProtectedTagVO `json:"tags"`
+}
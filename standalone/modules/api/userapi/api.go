@@ -34,7 +34,7 @@ func InitApi() {
 			// 展示用户列表
 			group.POST("/list", listUser)
 			// 更新密码
-			group.POST("/updatePassword", updatePassword)
+			group.POST("/updatePassword", apicommon.ForbidAccessToken, updatePassword)
 			// 系统管理员设置
 			group.POST("/setAdmin", updateAdmin)
 		}
@@ -0,0 +1,167 @@
+package webhookapi
+
+import (
+	"github.com/LeeZXin/zsf-utils/ginutil"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf-utils/timeutil"
+	"github.com/LeeZXin/zsf/http/httpserver"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"zgit/standalone/modules/api/apicommon"
+	"zgit/standalone/modules/service/webhooksrv"
+	"zgit/util"
+)
+
+func InitApi() {
+	httpserver.AppendRegisterRouterFunc(func(e *gin.Engine) {
+		// webhook repoId为空表示项目级别
+		group := e.Group("/api/webhook", apicommon.CheckLogin)
+		{
+			// 新增
+			group.POST("/insert", insertWebhook)
+			// 编辑
+			group.POST("/update", updateWebhook)
+			// 删除
+			group.POST("/delete", deleteWebhook)
+			// 列表
+			group.POST("/list", apicommon.ReadOnly, listWebhook)
+			// 投递记录
+			group.POST("/listDelivery", apicommon.ReadOnly, listDelivery)
+			// 重新投递
+			group.POST("/redeliver", redeliver)
+		}
+	})
+}
+
+func insertWebhook(c *gin.Context) {
+	var req InsertWebhookReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := webhooksrv.InsertWebhook(c.Request.Context(), webhooksrv.InsertWebhookReqDTO{
+			ProjectId: req.ProjectId,
+			RepoId:    req.RepoId,
+			Cfg:       req.Cfg,
+			Operator:  apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func updateWebhook(c *gin.Context) {
+	var req UpdateWebhookReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := webhooksrv.UpdateWebhook(c.Request.Context(), webhooksrv.UpdateWebhookReqDTO{
+			HookId:   req.HookId,
+			Cfg:      req.Cfg,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func deleteWebhook(c *gin.Context) {
+	var req DeleteWebhookReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := webhooksrv.DeleteWebhook(c.Request.Context(), webhooksrv.DeleteWebhookReqDTO{
+			HookId:   req.HookId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func listWebhook(c *gin.Context) {
+	var req ListWebhookReqVO
+	if util.ShouldBindJSON(&req, c) {
+		hooks, err := webhooksrv.ListWebhook(c.Request.Context(), webhooksrv.ListWebhookReqDTO{
+			ProjectId: req.ProjectId,
+			RepoId:    req.RepoId,
+			Operator:  apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		data, _ := listutil.Map(hooks, func(h webhooksrv.WebhookDTO) (WebhookVO, error) {
+			return WebhookVO{
+				HookId:      h.HookId,
+				ProjectId:   h.ProjectId,
+				RepoId:      h.RepoId,
+				Url:         h.Url,
+				ContentType: h.ContentType,
+				Events:      h.Events,
+				IsActive:    h.IsActive,
+				HasSecret:   h.HasSecret,
+			}, nil
+		})
+		c.JSON(http.StatusOK, ListWebhookRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+			Data:     data,
+		})
+	}
+}
+
+func listDelivery(c *gin.Context) {
+	var req ListDeliveryReqVO
+	if util.ShouldBindJSON(&req, c) {
+		respDTO, err := webhooksrv.ListDelivery(c.Request.Context(), webhooksrv.ListDeliveryReqDTO{
+			HookId:   req.HookId,
+			Offset:   req.Offset,
+			Limit:    req.Limit,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		data, _ := listutil.Map(respDTO.DeliveryList, func(d webhooksrv.DeliveryDTO) (DeliveryVO, error) {
+			return DeliveryVO{
+				DeliveryId:     d.DeliveryId,
+				HookId:         d.HookId,
+				Event:          d.Event,
+				RequestUrl:     d.RequestUrl,
+				RequestHeader:  d.RequestHeader,
+				RequestBody:    d.RequestBody,
+				ResponseStatus: d.ResponseStatus,
+				ResponseHeader: d.ResponseHeader,
+				ResponseBody:   d.ResponseBody,
+				ErrMsg:         d.ErrMsg,
+				Latency:        d.Latency,
+				Attempts:       d.Attempts,
+				IsSuccess:      d.IsSuccess,
+				Created:        d.Created.Format(timeutil.DefaultTimeFormat),
+			}, nil
+		})
+		c.JSON(http.StatusOK, ListDeliveryRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+			Data:     data,
+			Cursor:   respDTO.Cursor,
+		})
+	}
+}
+
+func redeliver(c *gin.Context) {
+	var req RedeliverReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := webhooksrv.Redeliver(c.Request.Context(), webhooksrv.RedeliverReqDTO{
+			DeliveryId: req.DeliveryId,
+			Operator:   apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
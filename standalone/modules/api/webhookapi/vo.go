@@ -0,0 +1,76 @@
+package webhookapi
+
+import (
+	"github.com/LeeZXin/zsf-utils/ginutil"
+	"zgit/pkg/webhook"
+	"zgit/standalone/modules/model/webhookmd"
+)
+
+type InsertWebhookReqVO struct {
+	ProjectId string               `json:"projectId"`
+	RepoId    string               `json:"repoId"`
+	Cfg       webhookmd.WebhookCfg `json:"cfg"`
+}
+
+type UpdateWebhookReqVO struct {
+	HookId string               `json:"hookId"`
+	Cfg    webhookmd.WebhookCfg `json:"cfg"`
+}
+
+type DeleteWebhookReqVO struct {
+	HookId string `json:"hookId"`
+}
+
+type ListWebhookReqVO struct {
+	ProjectId string `json:"projectId"`
+	RepoId    string `json:"repoId"`
+}
+
+type WebhookVO struct {
+	HookId      string          `json:"hookId"`
+	ProjectId   string          `json:"projectId"`
+	RepoId      string          `json:"repoId"`
+	Url         string          `json:"url"`
+	ContentType string          `json:"contentType"`
+	Events      []webhook.Event `json:"events"`
+	IsActive    bool            `json:"isActive"`
+	HasSecret   bool            `json:"hasSecret"`
+}
+
+type ListWebhookRespVO struct {
+	ginutil.BaseResp
+	Data []WebhookVO `json:"data"`
+}
+
+type ListDeliveryReqVO struct {
+	HookId string `json:"hookId"`
+	Offset int64  `json:"offset"`
+	Limit  int    `json:"limit"`
+}
+
+type DeliveryVO struct {
+	DeliveryId     string            `json:"deliveryId"`
+	HookId         string            `json:"hookId"`
+	Event          string            `json:"event"`
+	RequestUrl     string            `json:"requestUrl"`
+	RequestHeader  map[string]string `json:"requestHeader"`
+	RequestBody    string            `json:"requestBody"`
+	ResponseStatus int               `json:"responseStatus"`
+	ResponseHeader string            `json:"responseHeader"`
+	ResponseBody   string            `json:"responseBody"`
+	ErrMsg         string            `json:"errMsg"`
+	Latency        int64             `json:"latency"`
+	Attempts       int               `json:"attempts"`
+	IsSuccess      bool              `json:"isSuccess"`
+	Created        string            `json:"created"`
+}
+
+type ListDeliveryRespVO struct {
+	ginutil.BaseResp
+	Data   []DeliveryVO `json:"data"`
+	Cursor int64        `json:"cursor"`
+}
+
+type RedeliverReqVO struct {
+	DeliveryId string `json:"deliveryId"`
+}
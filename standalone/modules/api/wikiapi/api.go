@@ -0,0 +1,184 @@
+package wikiapi
+
+import (
+	"github.com/LeeZXin/zsf-utils/ginutil"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/http/httpserver"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"zgit/standalone/modules/api/apicommon"
+	"zgit/standalone/modules/service/wikisrv"
+	"zgit/util"
+)
+
+func InitApi() {
+	httpserver.AppendRegisterRouterFunc(func(e *gin.Engine) {
+		// 仓库wiki
+		group := e.Group("/api/wiki", apicommon.CheckLogin)
+		{
+			// 页面列表
+			group.POST("/list", apicommon.ReadOnly, listPages)
+			// 查看页面
+			group.POST("/get", apicommon.ReadOnly, getPage)
+			// 新建或编辑页面
+			group.POST("/save", apicommon.RepoWrite, savePage)
+			// 删除页面
+			group.POST("/delete", apicommon.RepoWrite, deletePage)
+			// 页面修改历史
+			group.POST("/history", apicommon.ReadOnly, pageHistory)
+			// 页面版本差异
+			group.POST("/diff", apicommon.ReadOnly, diffPage)
+		}
+	})
+}
+
+func listPages(c *gin.Context) {
+	var req ListPagesReqVO
+	if util.ShouldBindJSON(&req, c) {
+		pages, err := wikisrv.ListPages(c.Request.Context(), wikisrv.ListPagesReqDTO{
+			RepoId:   req.RepoId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		data, _ := listutil.Map(pages, func(t wikisrv.PageDTO) (PageVO, error) {
+			return PageVO{
+				Name: t.Name,
+				Size: t.Size,
+			}, nil
+		})
+		c.JSON(http.StatusOK, ListPagesRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+			Data:     data,
+		})
+	}
+}
+
+func getPage(c *gin.Context) {
+	var req GetPageReqVO
+	if util.ShouldBindJSON(&req, c) {
+		page, err := wikisrv.GetPage(c.Request.Context(), wikisrv.GetPageReqDTO{
+			RepoId:   req.RepoId,
+			PageName: req.PageName,
+			CommitId: req.CommitId,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, GetPageRespVO{
+			BaseResp:   ginutil.DefaultSuccessResp,
+			Name:       page.Name,
+			Content:    page.Content,
+			Html:       page.Html,
+			LastCommit: commitDto2Vo(page.LastCommit),
+		})
+	}
+}
+
+func savePage(c *gin.Context) {
+	var req SavePageReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := wikisrv.SavePage(c.Request.Context(), wikisrv.SavePageReqDTO{
+			RepoId:   req.RepoId,
+			PageName: req.PageName,
+			Content:  req.Content,
+			Message:  req.Message,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func deletePage(c *gin.Context) {
+	var req DeletePageReqVO
+	if util.ShouldBindJSON(&req, c) {
+		err := wikisrv.DeletePage(c.Request.Context(), wikisrv.DeletePageReqDTO{
+			RepoId:   req.RepoId,
+			PageName: req.PageName,
+			Message:  req.Message,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		c.JSON(http.StatusOK, ginutil.DefaultSuccessResp)
+	}
+}
+
+func pageHistory(c *gin.Context) {
+	var req PageHistoryReqVO
+	if util.ShouldBindJSON(&req, c) {
+		respDTO, err := wikisrv.PageHistory(c.Request.Context(), wikisrv.PageHistoryReqDTO{
+			RepoId:   req.RepoId,
+			PageName: req.PageName,
+			Cursor:   req.Cursor,
+			Limit:    req.Limit,
+			Operator: apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		ret := PageHistoryRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+			Cursor:   respDTO.Cursor,
+			HasMore:  respDTO.HasMore,
+		}
+		ret.Commits, _ = listutil.Map(respDTO.Commits, func(t wikisrv.CommitDTO) (CommitVO, error) {
+			return commitDto2Vo(t), nil
+		})
+		c.JSON(http.StatusOK, ret)
+	}
+}
+
+func diffPage(c *gin.Context) {
+	var req DiffPageReqVO
+	if util.ShouldBindJSON(&req, c) {
+		respDTO, err := wikisrv.DiffPage(c.Request.Context(), wikisrv.DiffPageReqDTO{
+			RepoId:      req.RepoId,
+			PageName:    req.PageName,
+			OldCommitId: req.OldCommitId,
+			NewCommitId: req.NewCommitId,
+			Operator:    apicommon.MustGetLoginUser(c),
+		})
+		if err != nil {
+			util.HandleApiErr(err, c)
+			return
+		}
+		ret := DiffPageRespVO{
+			BaseResp: ginutil.DefaultSuccessResp,
+			FileType: respDTO.FileType.String(),
+		}
+		ret.Lines, _ = listutil.Map(respDTO.Lines, func(t wikisrv.DiffLineDTO) (DiffLineVO, error) {
+			return DiffLineVO{
+				Index:   t.Index,
+				LeftNo:  t.LeftNo,
+				Prefix:  t.Prefix,
+				RightNo: t.RightNo,
+				Text:    t.Text,
+			}, nil
+		})
+		c.JSON(http.StatusOK, ret)
+	}
+}
+
+func commitDto2Vo(dto wikisrv.CommitDTO) CommitVO {
+	return CommitVO{
+		Author:        dto.Author,
+		Committer:     dto.Committer,
+		AuthoredDate:  util.ReadableTimeComparingNow(dto.AuthoredDate),
+		CommittedDate: util.ReadableTimeComparingNow(dto.CommittedDate),
+		CommitMsg:     dto.CommitMsg,
+		CommitId:      dto.CommitId,
+		ShortId:       dto.ShortId,
+	}
+}
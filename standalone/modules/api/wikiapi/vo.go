@@ -0,0 +1,92 @@
+package wikiapi
+
+import (
+	"github.com/LeeZXin/zsf-utils/ginutil"
+	"zgit/pkg/git"
+)
+
+type ListPagesReqVO struct {
+	RepoId string `json:"repoId"`
+}
+
+type PageVO struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+type ListPagesRespVO struct {
+	ginutil.BaseResp
+	Data []PageVO `json:"data"`
+}
+
+type GetPageReqVO struct {
+	RepoId   string `json:"repoId"`
+	PageName string `json:"pageName"`
+	CommitId string `json:"commitId"`
+}
+
+type GetPageRespVO struct {
+	ginutil.BaseResp
+	Name       string   `json:"name"`
+	Content    string   `json:"content"`
+	Html       string   `json:"html"`
+	LastCommit CommitVO `json:"lastCommit"`
+}
+
+type SavePageReqVO struct {
+	RepoId   string `json:"repoId"`
+	PageName string `json:"pageName"`
+	Content  string `json:"content"`
+	Message  string `json:"message"`
+}
+
+type DeletePageReqVO struct {
+	RepoId   string `json:"repoId"`
+	PageName string `json:"pageName"`
+	Message  string `json:"message"`
+}
+
+type PageHistoryReqVO struct {
+	RepoId   string `json:"repoId"`
+	PageName string `json:"pageName"`
+	Cursor   int64  `json:"cursor"`
+	Limit    int    `json:"limit"`
+}
+
+type PageHistoryRespVO struct {
+	ginutil.BaseResp
+	Commits []CommitVO `json:"commits"`
+	Cursor  int64      `json:"cursor"`
+	HasMore bool       `json:"hasMore"`
+}
+
+type DiffPageReqVO struct {
+	RepoId      string `json:"repoId"`
+	PageName    string `json:"pageName"`
+	OldCommitId string `json:"oldCommitId"`
+	NewCommitId string `json:"newCommitId"`
+}
+
+type DiffPageRespVO struct {
+	ginutil.BaseResp
+	FileType string       `json:"fileType"`
+	Lines    []DiffLineVO `json:"lines"`
+}
+
+type DiffLineVO struct {
+	Index   int    `json:"index"`
+	LeftNo  int    `json:"leftNo"`
+	Prefix  string `json:"prefix"`
+	RightNo int    `json:"rightNo"`
+	Text    string `json:"text"`
+}
+
+type CommitVO struct {
+	Author        git.User `json:"author"`
+	Committer     git.User `json:"committer"`
+	AuthoredDate  string   `json:"authoredDate"`
+	CommittedDate string   `json:"committedDate"`
+	CommitMsg     string   `json:"commitMsg"`
+	CommitId      string   `json:"commitId"`
+	ShortId       string   `json:"shortId"`
+}
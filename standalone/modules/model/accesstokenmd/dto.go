@@ -0,0 +1,15 @@
+package accesstokenmd
+
+type InsertAccessTokenReqDTO struct {
+	Account   string
+	Name      string
+	TokenHash string
+	Scopes    []Scope
+	ExpireAt  int64
+}
+
+type ListAccessTokenReqDTO struct {
+	Account string
+	Offset  int64
+	Limit   int
+}
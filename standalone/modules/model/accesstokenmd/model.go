@@ -0,0 +1,103 @@
+package accesstokenmd
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	AccessTokenTableName = "access_token"
+)
+
+type Scope string
+
+const (
+	// RepoReadScope 仓库读权限
+	RepoReadScope Scope = "repo:read"
+	// RepoWriteScope 仓库写权限
+	RepoWriteScope Scope = "repo:write"
+	// AdminScope 系统管理员权限
+	AdminScope Scope = "admin"
+	// LfsScope lfs权限
+	LfsScope Scope = "lfs"
+)
+
+func (s Scope) IsValid() bool {
+	switch s {
+	case RepoReadScope, RepoWriteScope, AdminScope, LfsScope:
+		return true
+	default:
+		return false
+	}
+}
+
+type TokenInfo struct {
+	TokenId string  `json:"tokenId"`
+	Account string  `json:"account"`
+	Scopes  []Scope `json:"scopes"`
+	// 过期时间 0为永不过期
+	ExpireAt int64 `json:"expireAt"`
+}
+
+func (t *TokenInfo) HasScope(scope Scope) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CanWriteRepo admin包含仓库写权限
+func (t *TokenInfo) CanWriteRepo() bool {
+	return t.HasScope(RepoWriteScope) || t.HasScope(AdminScope)
+}
+
+// CanReadRepo 仓库写权限包含读权限
+func (t *TokenInfo) CanReadRepo() bool {
+	return t.CanWriteRepo() || t.HasScope(RepoReadScope)
+}
+
+func (t *TokenInfo) IsExpired() bool {
+	return t.ExpireAt > 0 && t.ExpireAt < time.Now().UnixMilli()
+}
+
+type AccessToken struct {
+	Id      int64  `json:"id" xorm:"pk autoincr"`
+	TokenId string `json:"tokenId"`
+	Account string `json:"account"`
+	Name    string `json:"name"`
+	// token sha256
+	TokenHash string `json:"tokenHash"`
+	// 逗号分隔
+	Scopes string `json:"scopes"`
+	// 过期时间 0为永不过期
+	ExpireAt int64 `json:"expireAt"`
+	// 最后使用时间
+	LastUsed int64     `json:"lastUsed"`
+	Created  time.Time `json:"created" xorm:"created"`
+	Updated  time.Time `json:"updated" xorm:"updated"`
+}
+
+func (*AccessToken) TableName() string {
+	return AccessTokenTableName
+}
+
+func (t *AccessToken) GetScopes() []Scope {
+	ret := make([]Scope, 0)
+	for _, s := range strings.Split(t.Scopes, ",") {
+		if s != "" {
+			ret = append(ret, Scope(s))
+		}
+	}
+	return ret
+}
+
+func (t *AccessToken) ToTokenInfo() TokenInfo {
+	return TokenInfo{
+		TokenId:  t.TokenId,
+		Account:  t.Account,
+		Scopes:   t.GetScopes(),
+		ExpireAt: t.ExpireAt,
+	}
+}
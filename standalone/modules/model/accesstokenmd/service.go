@@ -0,0 +1,114 @@
+package accesstokenmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/LeeZXin/zsf-utils/idutil"
+	"github.com/LeeZXin/zsf/xorm/xormutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// TokenPrefix token前缀 便于识别
+	TokenPrefix = "zgt_"
+)
+
+func GenTokenId() string {
+	return idutil.RandomUuid()
+}
+
+func IsTokenIdValid(tokenId string) bool {
+	return len(tokenId) == 32
+}
+
+// GenToken 生成token明文
+func GenToken() string {
+	h := sha256.New()
+	h.Write([]byte(idutil.RandomUuid() + strconv.FormatInt(time.Now().UnixNano(), 10)))
+	return TokenPrefix + hex.EncodeToString(h.Sum(nil))[:40]
+}
+
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, TokenPrefix)
+}
+
+// HashToken 数据库只存储token的hash
+func HashToken(token string) string {
+	h := sha256.New()
+	h.Write([]byte(token))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func InsertAccessToken(ctx context.Context, reqDTO InsertAccessTokenReqDTO) (AccessToken, error) {
+	scopes := make([]string, 0, len(reqDTO.Scopes))
+	for _, s := range reqDTO.Scopes {
+		scopes = append(scopes, string(s))
+	}
+	ret := AccessToken{
+		TokenId:   GenTokenId(),
+		Account:   reqDTO.Account,
+		Name:      reqDTO.Name,
+		TokenHash: reqDTO.TokenHash,
+		Scopes:    strings.Join(scopes, ","),
+		ExpireAt:  reqDTO.ExpireAt,
+	}
+	_, err := xormutil.MustGetXormSession(ctx).Insert(&ret)
+	return ret, err
+}
+
+func GetByTokenHash(ctx context.Context, tokenHash string) (AccessToken, bool, error) {
+	var ret AccessToken
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("token_hash = ?", tokenHash).
+		Get(&ret)
+	return ret, b, err
+}
+
+func GetByTokenId(ctx context.Context, tokenId string) (AccessToken, bool, error) {
+	var ret AccessToken
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("token_id = ?", tokenId).
+		Get(&ret)
+	return ret, b, err
+}
+
+func DeleteAccessToken(ctx context.Context, tokenId string) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("token_id = ?", tokenId).
+		Limit(1).
+		Delete(new(AccessToken))
+	return rows == 1, err
+}
+
+func DeleteAccessTokenByAccount(ctx context.Context, account string) error {
+	_, err := xormutil.MustGetXormSession(ctx).
+		Where("account = ?", account).
+		Delete(new(AccessToken))
+	return err
+}
+
+func ListAccessToken(ctx context.Context, reqDTO ListAccessTokenReqDTO) ([]AccessToken, error) {
+	ret := make([]AccessToken, 0)
+	session := xormutil.MustGetXormSession(ctx).Where("account = ?", reqDTO.Account)
+	if reqDTO.Offset > 0 {
+		session.And("id > ?", reqDTO.Offset)
+	}
+	if reqDTO.Limit > 0 {
+		session.Limit(reqDTO.Limit)
+	}
+	return ret, session.OrderBy("id asc").Find(&ret)
+}
+
+func UpdateLastUsed(ctx context.Context, tokenId string, lastUsed int64) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("token_id = ?", tokenId).
+		Cols("last_used").
+		Limit(1).
+		Update(&AccessToken{
+			LastUsed: lastUsed,
+		})
+	return rows == 1, err
+}
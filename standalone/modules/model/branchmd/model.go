@@ -3,6 +3,7 @@ package branchmd
 import (
 	"encoding/json"
 	"time"
+	"zgit/pkg/git"
 )
 
 const (
@@ -30,6 +31,29 @@ type ProtectedBranchCfg struct {
 	ReviewerList []string `json:"reviewerList"`
 	// 可直接推送名单
 	DirectPushList []string `json:"directPushList"`
+	// 允许的合并方式 为空不限制
+	AllowedMergeStrategies []git.MergeStrategy `json:"allowedMergeStrategies"`
+	// 合并请求有新提交时取消已同意的评审
+	DismissStaleReviews bool `json:"dismissStaleReviews"`
+	// 合并前必须成功的提交状态名称
+	RequiredStatusContexts []string `json:"requiredStatusContexts"`
+	// 合并前需要CODEOWNERS中的代码所有者同意
+	RequireCodeOwnerReview bool `json:"requireCodeOwnerReview"`
+	// 推送的提交必须有已登记公钥的有效签名
+	RequireSignedCommits bool `json:"requireSignedCommits"`
+}
+
+// IsMergeStrategyAllowed 是否允许该合并方式
+func (c *ProtectedBranchCfg) IsMergeStrategyAllowed(strategy git.MergeStrategy) bool {
+	if len(c.AllowedMergeStrategies) == 0 {
+		return true
+	}
+	for _, s := range c.AllowedMergeStrategies {
+		if s == strategy {
+			return true
+		}
+	}
+	return false
 }
 
 func (c *ProtectedBranchCfg) ToString() string {
@@ -0,0 +1,16 @@
+package codeindexmd
+
+type InsertCodeIndexReqDTO struct {
+	RepoId string
+	Cfg    CodeIndexCfg
+}
+
+type UpdateCodeIndexCfgReqDTO struct {
+	RepoId string
+	Cfg    CodeIndexCfg
+}
+
+type CodeIndexDTO struct {
+	RepoId string
+	Cfg    CodeIndexCfg
+}
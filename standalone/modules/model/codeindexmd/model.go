@@ -0,0 +1,38 @@
+package codeindexmd
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	CodeIndexTableName = "code_index"
+)
+
+type CodeIndex struct {
+	Id      int64     `json:"id" xorm:"pk autoincr"`
+	RepoId  string    `json:"repoId"`
+	Cfg     string    `json:"cfg"`
+	Created time.Time `json:"created" xorm:"created"`
+	Updated time.Time `json:"updated" xorm:"updated"`
+}
+
+func (*CodeIndex) TableName() string {
+	return CodeIndexTableName
+}
+
+func (c *CodeIndex) GetCfg() CodeIndexCfg {
+	var ret CodeIndexCfg
+	_ = json.Unmarshal([]byte(c.Cfg), &ret)
+	return ret
+}
+
+type CodeIndexCfg struct {
+	// 除默认分支外需要索引的分支
+	Branches []string `json:"branches"`
+}
+
+func (c *CodeIndexCfg) ToString() string {
+	m, _ := json.Marshal(c)
+	return string(m)
+}
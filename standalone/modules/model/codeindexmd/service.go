@@ -0,0 +1,43 @@
+package codeindexmd
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf/xorm/xormutil"
+)
+
+func GetCodeIndex(ctx context.Context, repoId string) (CodeIndexDTO, bool, error) {
+	ret := CodeIndex{}
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", repoId).
+		Get(&ret)
+	return CodeIndexDTO{
+		RepoId: ret.RepoId,
+		Cfg:    ret.GetCfg(),
+	}, b, err
+}
+
+func InsertCodeIndex(ctx context.Context, reqDTO InsertCodeIndexReqDTO) error {
+	_, err := xormutil.MustGetXormSession(ctx).Insert(&CodeIndex{
+		RepoId: reqDTO.RepoId,
+		Cfg:    reqDTO.Cfg.ToString(),
+	})
+	return err
+}
+
+func UpdateCodeIndexCfg(ctx context.Context, reqDTO UpdateCodeIndexCfgReqDTO) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", reqDTO.RepoId).
+		Cols("cfg").
+		Limit(1).
+		Update(&CodeIndex{
+			Cfg: reqDTO.Cfg.ToString(),
+		})
+	return rows == 1, err
+}
+
+func DeleteCodeIndex(ctx context.Context, repoId string) error {
+	_, err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", repoId).
+		Delete(new(CodeIndex))
+	return err
+}
@@ -0,0 +1,19 @@
+package commitstatusmd
+
+type InsertCommitStatusReqDTO struct {
+	RepoId      string
+	CommitId    string
+	Context     string
+	State       State
+	TargetUrl   string
+	Description string
+	Creator     string
+}
+
+type UpdateCommitStatusReqDTO struct {
+	Sid         string
+	State       State
+	TargetUrl   string
+	Description string
+	Creator     string
+}
@@ -0,0 +1,66 @@
+package commitstatusmd
+
+import (
+	"time"
+	"zgit/pkg/i18n"
+)
+
+const (
+	CommitStatusTableName = "commit_status"
+)
+
+type State int
+
+const (
+	PendingState State = iota
+	SuccessState
+	FailureState
+	ErrorState
+)
+
+func (s State) Int() int {
+	return int(s)
+}
+
+func (s State) IsValid() bool {
+	switch s {
+	case PendingState, SuccessState, FailureState, ErrorState:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s State) Readable() string {
+	switch s {
+	case PendingState:
+		return i18n.GetByKey(i18n.CommitStatusPendingState)
+	case SuccessState:
+		return i18n.GetByKey(i18n.CommitStatusSuccessState)
+	case FailureState:
+		return i18n.GetByKey(i18n.CommitStatusFailureState)
+	case ErrorState:
+		return i18n.GetByKey(i18n.CommitStatusErrorState)
+	default:
+		return i18n.GetByKey(i18n.CommitStatusUnknownState)
+	}
+}
+
+type CommitStatus struct {
+	Id       int64  `json:"id" xorm:"pk autoincr"`
+	Sid      string `json:"sid"`
+	RepoId   string `json:"repoId" xorm:"unique(uq_commit_status)"`
+	CommitId string `json:"commitId" xorm:"unique(uq_commit_status)"`
+	// 状态名称 如ci/build 同一提交同一名称唯一
+	Context     string    `json:"context" xorm:"unique(uq_commit_status)"`
+	State       State     `json:"state"`
+	TargetUrl   string    `json:"targetUrl"`
+	Description string    `json:"description"`
+	Creator     string    `json:"creator"`
+	Created     time.Time `json:"created" xorm:"created"`
+	Updated     time.Time `json:"updated" xorm:"updated"`
+}
+
+func (*CommitStatus) TableName() string {
+	return CommitStatusTableName
+}
@@ -0,0 +1,77 @@
+package commitstatusmd
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf-utils/idutil"
+	"github.com/LeeZXin/zsf/xorm/xormutil"
+)
+
+func GenSid() string {
+	return idutil.RandomUuid()
+}
+
+func InsertCommitStatus(ctx context.Context, reqDTO InsertCommitStatusReqDTO) (CommitStatus, error) {
+	ret := CommitStatus{
+		Sid:         GenSid(),
+		RepoId:      reqDTO.RepoId,
+		CommitId:    reqDTO.CommitId,
+		Context:     reqDTO.Context,
+		State:       reqDTO.State,
+		TargetUrl:   reqDTO.TargetUrl,
+		Description: reqDTO.Description,
+		Creator:     reqDTO.Creator,
+	}
+	_, err := xormutil.MustGetXormSession(ctx).Insert(&ret)
+	return ret, err
+}
+
+func UpdateCommitStatus(ctx context.Context, reqDTO UpdateCommitStatusReqDTO) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("sid = ?", reqDTO.Sid).
+		Cols("state", "target_url", "description", "creator").
+		Limit(1).
+		Update(&CommitStatus{
+			State:       reqDTO.State,
+			TargetUrl:   reqDTO.TargetUrl,
+			Description: reqDTO.Description,
+			Creator:     reqDTO.Creator,
+		})
+	return rows == 1, err
+}
+
+func GetCommitStatus(ctx context.Context, repoId, commitId, statusContext string) (CommitStatus, bool, error) {
+	var ret CommitStatus
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", repoId).
+		And("commit_id = ?", commitId).
+		And("context = ?", statusContext).
+		Get(&ret)
+	return ret, b, err
+}
+
+func ListCommitStatus(ctx context.Context, repoId, commitId string) ([]CommitStatus, error) {
+	ret := make([]CommitStatus, 0)
+	err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", repoId).
+		And("commit_id = ?", commitId).
+		OrderBy("id asc").
+		Find(&ret)
+	return ret, err
+}
+
+// CombineState 合并多个状态 有失败即失败 有等待即等待 全部成功才成功
+func CombineState(statusList []CommitStatus) State {
+	if len(statusList) == 0 {
+		return PendingState
+	}
+	ret := SuccessState
+	for _, status := range statusList {
+		switch status.State {
+		case FailureState, ErrorState:
+			return FailureState
+		case PendingState:
+			ret = PendingState
+		}
+	}
+	return ret
+}
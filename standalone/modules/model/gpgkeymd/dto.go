@@ -0,0 +1,18 @@
+package gpgkeymd
+
+import "time"
+
+type InsertGpgKeyReqDTO struct {
+	Account      string
+	Name         string
+	PrimaryKeyId string
+	SubKeyIdList []string
+	Content      string
+	Expiry       time.Time
+}
+
+type ListGpgKeyReqDTO struct {
+	Offset  int64
+	Limit   int
+	Account string
+}
@@ -0,0 +1,41 @@
+package gpgkeymd
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	GpgKeyTableName = "gpg_key"
+)
+
+type GpgKey struct {
+	Id           int64  `xorm:"pk autoincr"`
+	KeyId        string `json:"keyId"`
+	Account      string `json:"account"`
+	Name         string `json:"name"`
+	PrimaryKeyId string `json:"primaryKeyId"`
+	// 子密钥id 逗号分隔
+	SubKeyIds string    `json:"subKeyIds"`
+	Content   string    `json:"content"`
+	Expiry    time.Time `json:"expiry"`
+	Created   time.Time `json:"created" xorm:"created"`
+	Updated   time.Time `json:"updated" xorm:"updated"`
+}
+
+func (*GpgKey) TableName() string {
+	return GpgKeyTableName
+}
+
+// GetSubKeyIdList 子密钥id列表
+func (k *GpgKey) GetSubKeyIdList() []string {
+	if k.SubKeyIds == "" {
+		return []string{}
+	}
+	return strings.Split(k.SubKeyIds, ",")
+}
+
+// IsExpired 是否过期
+func (k *GpgKey) IsExpired() bool {
+	return !k.Expiry.IsZero() && k.Expiry.Before(time.Now())
+}
@@ -0,0 +1,67 @@
+package gpgkeymd
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf-utils/idutil"
+	"github.com/LeeZXin/zsf/xorm/xormutil"
+	"strings"
+)
+
+func GenKeyId() string {
+	return idutil.RandomUuid()
+}
+
+func IsKeyIdValid(keyId string) bool {
+	return len(keyId) == 32
+}
+
+// SearchByGpgKeyId 通过主密钥或子密钥id查找
+func SearchByGpgKeyId(ctx context.Context, gpgKeyId string) (GpgKey, bool, error) {
+	var ret GpgKey
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("primary_key_id = ?", gpgKeyId).
+		Or("sub_key_ids like ?", "%"+gpgKeyId+"%").
+		Get(&ret)
+	return ret, b, err
+}
+
+func GetByKeyId(ctx context.Context, keyId string) (GpgKey, bool, error) {
+	var ret GpgKey
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("key_id = ?", keyId).
+		Get(&ret)
+	return ret, b, err
+}
+
+func DeleteGpgKey(ctx context.Context, key GpgKey) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("key_id = ?", key.KeyId).
+		Delete(new(GpgKey))
+	return rows == 1, err
+}
+
+func InsertGpgKey(ctx context.Context, reqDTO InsertGpgKeyReqDTO) (GpgKey, error) {
+	p := GpgKey{
+		KeyId:        GenKeyId(),
+		Account:      reqDTO.Account,
+		Name:         reqDTO.Name,
+		PrimaryKeyId: reqDTO.PrimaryKeyId,
+		SubKeyIds:    strings.Join(reqDTO.SubKeyIdList, ","),
+		Content:      reqDTO.Content,
+		Expiry:       reqDTO.Expiry,
+	}
+	_, err := xormutil.MustGetXormSession(ctx).Insert(&p)
+	return p, err
+}
+
+func ListGpgKey(ctx context.Context, reqDTO ListGpgKeyReqDTO) ([]GpgKey, error) {
+	ret := make([]GpgKey, 0)
+	session := xormutil.MustGetXormSession(ctx).Where("account = ?", reqDTO.Account)
+	if reqDTO.Offset > 0 {
+		session.And("id > ?", reqDTO.Offset)
+	}
+	if reqDTO.Limit > 0 {
+		session.Limit(reqDTO.Limit)
+	}
+	return ret, session.OrderBy("id asc").Find(&ret)
+}
@@ -0,0 +1,49 @@
+package mirrormd
+
+type InsertPullMirrorReqDTO struct {
+	RepoId       string
+	RemoteUrl    string
+	Username     string
+	Password     string
+	SyncInterval int
+	NextSyncTime int64
+}
+
+type UpdatePullMirrorCfgReqDTO struct {
+	RepoId       string
+	RemoteUrl    string
+	Username     string
+	Password     string
+	SyncInterval int
+}
+
+type UpdateSyncResultReqDTO struct {
+	RepoId       string
+	LastSyncTime int64
+	LastSyncErr  string
+	NextSyncTime int64
+}
+
+type InsertPushMirrorReqDTO struct {
+	RepoId       string
+	RemoteUrl    string
+	Username     string
+	Password     string
+	SyncInterval int
+	NextSyncTime int64
+}
+
+type UpdatePushMirrorCfgReqDTO struct {
+	MirrorId     string
+	RemoteUrl    string
+	Username     string
+	Password     string
+	SyncInterval int
+}
+
+type UpdatePushMirrorSyncResultReqDTO struct {
+	MirrorId     string
+	LastSyncTime int64
+	LastSyncErr  string
+	NextSyncTime int64
+}
@@ -0,0 +1,55 @@
+package mirrormd
+
+import "time"
+
+const (
+	PullMirrorTableName = "pull_mirror"
+	PushMirrorTableName = "push_mirror"
+)
+
+type PullMirror struct {
+	Id        int64  `xorm:"pk autoincr"`
+	RepoId    string `json:"repoId"`
+	RemoteUrl string `json:"remoteUrl"`
+	Username  string `json:"username"`
+	// 加密后的密码或令牌
+	Password string `json:"password"`
+	// 同步间隔 分钟
+	SyncInterval int `json:"syncInterval"`
+	// 上次同步时间 毫秒时间戳
+	LastSyncTime int64 `json:"lastSyncTime"`
+	// 上次同步错误信息 为空表示成功
+	LastSyncErr string `json:"lastSyncErr"`
+	// 下次同步时间 毫秒时间戳
+	NextSyncTime int64     `json:"nextSyncTime"`
+	Created      time.Time `json:"created" xorm:"created"`
+	Updated      time.Time `json:"updated" xorm:"updated"`
+}
+
+func (*PullMirror) TableName() string {
+	return PullMirrorTableName
+}
+
+type PushMirror struct {
+	Id        int64  `xorm:"pk autoincr"`
+	MirrorId  string `json:"mirrorId"`
+	RepoId    string `json:"repoId"`
+	RemoteUrl string `json:"remoteUrl"`
+	Username  string `json:"username"`
+	// 加密后的密码或令牌
+	Password string `json:"password"`
+	// 定时同步间隔 分钟
+	SyncInterval int `json:"syncInterval"`
+	// 上次同步时间 毫秒时间戳
+	LastSyncTime int64 `json:"lastSyncTime"`
+	// 上次同步错误信息 为空表示成功
+	LastSyncErr string `json:"lastSyncErr"`
+	// 下次同步时间 毫秒时间戳
+	NextSyncTime int64     `json:"nextSyncTime"`
+	Created      time.Time `json:"created" xorm:"created"`
+	Updated      time.Time `json:"updated" xorm:"updated"`
+}
+
+func (*PushMirror) TableName() string {
+	return PushMirrorTableName
+}
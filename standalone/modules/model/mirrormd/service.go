@@ -0,0 +1,194 @@
+package mirrormd
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf-utils/idutil"
+	"github.com/LeeZXin/zsf/xorm/xormutil"
+	"net/url"
+)
+
+func InsertPullMirror(ctx context.Context, reqDTO InsertPullMirrorReqDTO) error {
+	_, err := xormutil.MustGetXormSession(ctx).Insert(&PullMirror{
+		RepoId:       reqDTO.RepoId,
+		RemoteUrl:    reqDTO.RemoteUrl,
+		Username:     reqDTO.Username,
+		Password:     reqDTO.Password,
+		SyncInterval: reqDTO.SyncInterval,
+		NextSyncTime: reqDTO.NextSyncTime,
+	})
+	return err
+}
+
+func GetPullMirrorByRepoId(ctx context.Context, repoId string) (PullMirror, bool, error) {
+	var ret PullMirror
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", repoId).
+		Get(&ret)
+	return ret, b, err
+}
+
+func ExistsPullMirror(ctx context.Context, repoId string) (bool, error) {
+	return xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", repoId).
+		Exist(new(PullMirror))
+}
+
+func UpdatePullMirrorCfg(ctx context.Context, reqDTO UpdatePullMirrorCfgReqDTO) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", reqDTO.RepoId).
+		Cols("remote_url", "username", "password", "sync_interval").
+		Limit(1).
+		Update(&PullMirror{
+			RemoteUrl:    reqDTO.RemoteUrl,
+			Username:     reqDTO.Username,
+			Password:     reqDTO.Password,
+			SyncInterval: reqDTO.SyncInterval,
+		})
+	return rows == 1, err
+}
+
+func UpdateSyncResult(ctx context.Context, reqDTO UpdateSyncResultReqDTO) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", reqDTO.RepoId).
+		Cols("last_sync_time", "last_sync_err", "next_sync_time").
+		Limit(1).
+		Update(&PullMirror{
+			LastSyncTime: reqDTO.LastSyncTime,
+			LastSyncErr:  reqDTO.LastSyncErr,
+			NextSyncTime: reqDTO.NextSyncTime,
+		})
+	return rows == 1, err
+}
+
+func DeletePullMirror(ctx context.Context, repoId string) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", repoId).
+		Limit(1).
+		Delete(new(PullMirror))
+	return rows == 1, err
+}
+
+// ListDuePullMirror 获取到达同步时间的镜像
+func ListDuePullMirror(ctx context.Context, now int64, limit int) ([]PullMirror, error) {
+	ret := make([]PullMirror, 0)
+	err := xormutil.MustGetXormSession(ctx).
+		Where("next_sync_time <= ?", now).
+		OrderBy("next_sync_time asc").
+		Limit(limit).
+		Find(&ret)
+	return ret, err
+}
+
+func GenMirrorId() string {
+	return idutil.RandomUuid()
+}
+
+func IsMirrorIdValid(mirrorId string) bool {
+	return len(mirrorId) == 32
+}
+
+func InsertPushMirror(ctx context.Context, reqDTO InsertPushMirrorReqDTO) error {
+	_, err := xormutil.MustGetXormSession(ctx).Insert(&PushMirror{
+		MirrorId:     GenMirrorId(),
+		RepoId:       reqDTO.RepoId,
+		RemoteUrl:    reqDTO.RemoteUrl,
+		Username:     reqDTO.Username,
+		Password:     reqDTO.Password,
+		SyncInterval: reqDTO.SyncInterval,
+		NextSyncTime: reqDTO.NextSyncTime,
+	})
+	return err
+}
+
+func GetPushMirrorByMirrorId(ctx context.Context, mirrorId string) (PushMirror, bool, error) {
+	var ret PushMirror
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("mirror_id = ?", mirrorId).
+		Get(&ret)
+	return ret, b, err
+}
+
+func ListPushMirror(ctx context.Context, repoId string) ([]PushMirror, error) {
+	ret := make([]PushMirror, 0)
+	err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", repoId).
+		OrderBy("id asc").
+		Find(&ret)
+	return ret, err
+}
+
+func CountPushMirror(ctx context.Context, repoId string) (int64, error) {
+	return xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", repoId).
+		Count(new(PushMirror))
+}
+
+func UpdatePushMirrorCfg(ctx context.Context, reqDTO UpdatePushMirrorCfgReqDTO) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("mirror_id = ?", reqDTO.MirrorId).
+		Cols("remote_url", "username", "password", "sync_interval").
+		Limit(1).
+		Update(&PushMirror{
+			RemoteUrl:    reqDTO.RemoteUrl,
+			Username:     reqDTO.Username,
+			Password:     reqDTO.Password,
+			SyncInterval: reqDTO.SyncInterval,
+		})
+	return rows == 1, err
+}
+
+func UpdatePushMirrorSyncResult(ctx context.Context, reqDTO UpdatePushMirrorSyncResultReqDTO) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("mirror_id = ?", reqDTO.MirrorId).
+		Cols("last_sync_time", "last_sync_err", "next_sync_time").
+		Limit(1).
+		Update(&PushMirror{
+			LastSyncTime: reqDTO.LastSyncTime,
+			LastSyncErr:  reqDTO.LastSyncErr,
+			NextSyncTime: reqDTO.NextSyncTime,
+		})
+	return rows == 1, err
+}
+
+func DeletePushMirror(ctx context.Context, mirrorId string) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("mirror_id = ?", mirrorId).
+		Limit(1).
+		Delete(new(PushMirror))
+	return rows == 1, err
+}
+
+func DeletePushMirrorByRepoId(ctx context.Context, repoId string) error {
+	_, err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", repoId).
+		Delete(new(PushMirror))
+	return err
+}
+
+// ListDuePushMirror 获取到达同步时间的推送镜像
+func ListDuePushMirror(ctx context.Context, now int64, limit int) ([]PushMirror, error) {
+	ret := make([]PushMirror, 0)
+	err := xormutil.MustGetXormSession(ctx).
+		Where("next_sync_time <= ?", now).
+		OrderBy("next_sync_time asc").
+		Limit(limit).
+		Find(&ret)
+	return ret, err
+}
+
+// IsRemoteUrlValid 只支持http(s)远端 凭证需单独填写
+func IsRemoteUrlValid(remoteUrl string) bool {
+	if len(remoteUrl) == 0 || len(remoteUrl) > 1024 {
+		return false
+	}
+	parsed, err := url.Parse(remoteUrl)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "" && parsed.User == nil
+}
+
+// IsSyncIntervalValid 同步间隔5分钟至30天
+func IsSyncIntervalValid(interval int) bool {
+	return interval >= 5 && interval <= 43200
+}
@@ -1,11 +1,15 @@
 package pullrequestmd
 
 type InsertPullRequestReqDTO struct {
-	RepoId   string
-	Target   string
-	Head     string
-	CreateBy string
-	PrStatus PrStatus
+	RepoId         string
+	Title          string
+	Target         string
+	TargetCommitId string
+	Head           string
+	HeadCommitId   string
+	IsMergeAble    bool
+	CreateBy       string
+	PrStatus       PrStatus
 }
 
 type InsertReviewReqDTO struct {
@@ -19,3 +23,37 @@ type UpdateReviewReqDTO struct {
 	Rid    string
 	Status ReviewStatus
 }
+
+type ListPullRequestReqDTO struct {
+	RepoIdList []string
+	// 为空不过滤状态
+	PrStatus *PrStatus
+	CreateBy string
+	Reviewer string
+	Target   string
+	Head     string
+	// 标题模糊搜索
+	Title  string
+	Offset int64
+	Limit  int
+}
+
+type InsertCommentReqDTO struct {
+	PrId      string
+	ThreadId  string
+	FilePath  string
+	Side      CommentSide
+	LineNo    int
+	LineText  string
+	CommitId  string
+	Commenter string
+	Content   string
+}
+
+type UpdatePrSyncInfoReqDTO struct {
+	PrId           string
+	TargetCommitId string
+	HeadCommitId   string
+	IsMergeAble    bool
+	HasConflict    bool
+}
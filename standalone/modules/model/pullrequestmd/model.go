@@ -8,6 +8,7 @@ import (
 const (
 	PullRequestTableName = "pull_request"
 	ReviewTableName      = "pull_request_review"
+	CommentTableName     = "pull_request_comment"
 )
 
 type PrStatus int
@@ -22,6 +23,15 @@ func (s PrStatus) Int() int {
 	return int(s)
 }
 
+func (s PrStatus) IsValid() bool {
+	switch s {
+	case PrOpenStatus, PrClosedStatus, PrMergedStatus:
+		return true
+	default:
+		return false
+	}
+}
+
 func (s PrStatus) Readable() string {
 	switch s {
 	case PrOpenStatus:
@@ -67,17 +77,21 @@ func (s ReviewStatus) IsValid() bool {
 }
 
 type PullRequest struct {
-	Id             int64     `json:"id" xorm:"pk autoincr"`
-	PrId           string    `json:"prId"`
-	RepoId         string    `json:"repoId"`
-	Target         string    `json:"target"`
-	TargetCommitId string    `json:"targetCommitId"`
-	Head           string    `json:"head"`
-	HeadCommitId   string    `json:"headCommitId"`
-	PrStatus       PrStatus  `json:"prStatus"`
-	CreateBy       string    `json:"createBy"`
-	Created        time.Time `json:"created" xorm:"created"`
-	Updated        time.Time `json:"updated" xorm:"updated"`
+	Id             int64    `json:"id" xorm:"pk autoincr"`
+	PrId           string   `json:"prId"`
+	RepoId         string   `json:"repoId"`
+	Title          string   `json:"title"`
+	Target         string   `json:"target"`
+	TargetCommitId string   `json:"targetCommitId"`
+	Head           string   `json:"head"`
+	HeadCommitId   string   `json:"headCommitId"`
+	PrStatus       PrStatus `json:"prStatus"`
+	// 推送后同步的合并状态
+	IsMergeAble bool      `json:"isMergeAble"`
+	HasConflict bool      `json:"hasConflict"`
+	CreateBy    string    `json:"createBy"`
+	Created     time.Time `json:"created" xorm:"created"`
+	Updated     time.Time `json:"updated" xorm:"updated"`
 }
 
 func (*PullRequest) TableName() string {
@@ -91,10 +105,60 @@ type Review struct {
 	Reviewer     string       `json:"reviewer"`
 	ReviewMsg    string       `json:"reviewMsg"`
 	ReviewStatus ReviewStatus `json:"reviewStatus"`
-	Created      time.Time    `json:"created" xorm:"created"`
-	Updated      time.Time    `json:"updated" xorm:"updated"`
+	// 源分支有新推送时同意评审失效 保留记录
+	IsDismissed bool      `json:"isDismissed"`
+	Created     time.Time `json:"created" xorm:"created"`
+	Updated     time.Time `json:"updated" xorm:"updated"`
 }
 
 func (*Review) TableName() string {
 	return ReviewTableName
 }
+
+type CommentSide int
+
+const (
+	// LeftSide 对应DiffLine的LeftNo
+	LeftSide CommentSide = iota
+	// RightSide 对应DiffLine的RightNo
+	RightSide
+)
+
+func (s CommentSide) Int() int {
+	return int(s)
+}
+
+func (s CommentSide) IsValid() bool {
+	switch s {
+	case LeftSide, RightSide:
+		return true
+	default:
+		return false
+	}
+}
+
+// Comment 代码行评论
+type Comment struct {
+	Id   int64  `json:"id" xorm:"pk autoincr"`
+	Cid  string `json:"cid"`
+	PrId string `json:"prId"`
+	// 评论串id 首条评论的cid
+	ThreadId string      `json:"threadId"`
+	FilePath string      `json:"filePath"`
+	Side     CommentSide `json:"side"`
+	LineNo   int         `json:"lineNo"`
+	// 评论时该行的内容 用于判断是否过时
+	LineText   string    `json:"lineText"`
+	CommitId   string    `json:"commitId"`
+	Commenter  string    `json:"commenter"`
+	Content    string    `json:"content"`
+	IsResolved bool      `json:"isResolved"`
+	ResolvedBy string    `json:"resolvedBy"`
+	IsOutdated bool      `json:"isOutdated"`
+	Created    time.Time `json:"created" xorm:"created"`
+	Updated    time.Time `json:"updated" xorm:"updated"`
+}
+
+func (*Comment) TableName() string {
+	return CommentTableName
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"github.com/LeeZXin/zsf-utils/idutil"
 	"github.com/LeeZXin/zsf/xorm/xormutil"
+	"strings"
+	"zgit/util"
 )
 
 func GenPrId() string {
@@ -22,14 +24,26 @@ func IsRidValid(rid string) bool {
 	return len(rid) == 32
 }
 
+func GenCid() string {
+	return idutil.RandomUuid()
+}
+
+func IsCidValid(cid string) bool {
+	return len(cid) == 32
+}
+
 func InsertPullRequest(ctx context.Context, reqDTO InsertPullRequestReqDTO) (PullRequest, error) {
 	ret := PullRequest{
-		PrId:     GenPrId(),
-		RepoId:   reqDTO.RepoId,
-		Target:   reqDTO.Target,
-		Head:     reqDTO.Head,
-		PrStatus: reqDTO.PrStatus,
-		CreateBy: reqDTO.CreateBy,
+		PrId:           GenPrId(),
+		RepoId:         reqDTO.RepoId,
+		Title:          reqDTO.Title,
+		Target:         reqDTO.Target,
+		TargetCommitId: reqDTO.TargetCommitId,
+		Head:           reqDTO.Head,
+		HeadCommitId:   reqDTO.HeadCommitId,
+		IsMergeAble:    reqDTO.IsMergeAble,
+		PrStatus:       reqDTO.PrStatus,
+		CreateBy:       reqDTO.CreateBy,
 	}
 	_, err := xormutil.MustGetXormSession(ctx).Insert(&ret)
 	return ret, err
@@ -59,12 +73,80 @@ func UpdatePrStatusAndCommitId(ctx context.Context, prId string, oldStatus, newS
 	return rows == 1, err
 }
 
+// UpdatePrSyncInfo 更新打开状态合并请求的提交和合并状态
+func UpdatePrSyncInfo(ctx context.Context, reqDTO UpdatePrSyncInfoReqDTO) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("pr_id = ?", reqDTO.PrId).
+		And("pr_status = ?", PrOpenStatus.Int()).
+		Cols("target_commit_id", "head_commit_id", "is_merge_able", "has_conflict").
+		Update(&PullRequest{
+			TargetCommitId: reqDTO.TargetCommitId,
+			HeadCommitId:   reqDTO.HeadCommitId,
+			IsMergeAble:    reqDTO.IsMergeAble,
+			HasConflict:    reqDTO.HasConflict,
+		})
+	return rows == 1, err
+}
+
+// ListOpenPullRequestByBranch 查询分支相关的打开状态合并请求
+func ListOpenPullRequestByBranch(ctx context.Context, repoId string, branchList []string) ([]PullRequest, error) {
+	ret := make([]PullRequest, 0)
+	if len(branchList) == 0 {
+		return ret, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(branchList)), ",")
+	args := make([]any, 0, 2*len(branchList))
+	for i := 0; i < 2; i++ {
+		for _, branch := range branchList {
+			args = append(args, branch)
+		}
+	}
+	err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", repoId).
+		And("pr_status = ?", PrOpenStatus.Int()).
+		And("(target in ("+placeholders+") or head in ("+placeholders+"))", args...).
+		Find(&ret)
+	return ret, err
+}
+
 func GetByPrId(ctx context.Context, prId string) (PullRequest, bool, error) {
 	var ret PullRequest
 	b, err := xormutil.MustGetXormSession(ctx).Where("pr_id = ?", prId).Get(&ret)
 	return ret, b, err
 }
 
+// ListPullRequest 按id倒序分页查询合并请求
+func ListPullRequest(ctx context.Context, reqDTO ListPullRequestReqDTO) ([]PullRequest, error) {
+	ret := make([]PullRequest, 0)
+	session := xormutil.MustGetXormSession(ctx).In("repo_id", reqDTO.RepoIdList)
+	if reqDTO.PrStatus != nil {
+		session.And("pr_status = ?", reqDTO.PrStatus.Int())
+	}
+	if reqDTO.CreateBy != "" {
+		session.And("create_by = ?", reqDTO.CreateBy)
+	}
+	if reqDTO.Reviewer != "" {
+		session.And("pr_id in (select pr_id from "+ReviewTableName+" where reviewer = ?)", reqDTO.Reviewer)
+	}
+	if reqDTO.Target != "" {
+		session.And("target = ?", reqDTO.Target)
+	}
+	if reqDTO.Head != "" {
+		session.And("head = ?", reqDTO.Head)
+	}
+	if reqDTO.Title != "" {
+		// mysql字符串中'\\'即单个反斜杠
+		session.And("title like ? escape '\\\\'", "%"+util.EscapeLike(reqDTO.Title)+"%")
+	}
+	if reqDTO.Offset > 0 {
+		session.And("id < ?", reqDTO.Offset)
+	}
+	if reqDTO.Limit > 0 {
+		session.Limit(reqDTO.Limit)
+	}
+	return ret, session.OrderBy("id desc").Find(&ret)
+}
+
 func InsertReview(ctx context.Context, reqDTO InsertReviewReqDTO) error {
 	_, err := xormutil.MustGetXormSession(ctx).Insert(&Review{
 		Rid:          GenRid(),
@@ -89,14 +171,27 @@ func UpdateReview(ctx context.Context, reqDTO UpdateReviewReqDTO) (bool, error)
 
 func ListReview(ctx context.Context, prId string) ([]Review, error) {
 	ret := make([]Review, 0)
-	err := xormutil.MustGetXormSession(ctx).Where("pr_id = ?", prId).Find(&ret)
+	err := xormutil.MustGetXormSession(ctx).Where("pr_id = ?", prId).OrderBy("id asc").Find(&ret)
 	return ret, err
 }
 
+func DismissReviewByStatus(ctx context.Context, prId string, status ReviewStatus) error {
+	_, err := xormutil.MustGetXormSession(ctx).
+		Where("pr_id = ?", prId).
+		And("review_status = ?", status.Int()).
+		And("is_dismissed = ?", false).
+		Cols("is_dismissed").
+		Update(&Review{
+			IsDismissed: true,
+		})
+	return err
+}
+
 func CountReview(ctx context.Context, prId string, status ReviewStatus) (int, error) {
 	ret, err := xormutil.MustGetXormSession(ctx).
 		Where("pr_id = ?", prId).
 		And("review_status = ?", status.Int()).
+		And("is_dismissed = ?", false).
 		Count(new(Review))
 	return int(ret), err
 }
@@ -106,6 +201,75 @@ func GetReview(ctx context.Context, prId, reviewer string) (Review, bool, error)
 	b, err := xormutil.MustGetXormSession(ctx).
 		Where("pr_id = ?", prId).
 		And("reviewer = ?", reviewer).
+		And("is_dismissed = ?", false).
 		Get(&ret)
 	return ret, b, err
 }
+
+func InsertComment(ctx context.Context, reqDTO InsertCommentReqDTO) (Comment, error) {
+	ret := Comment{
+		Cid:       GenCid(),
+		PrId:      reqDTO.PrId,
+		ThreadId:  reqDTO.ThreadId,
+		FilePath:  reqDTO.FilePath,
+		Side:      reqDTO.Side,
+		LineNo:    reqDTO.LineNo,
+		LineText:  reqDTO.LineText,
+		CommitId:  reqDTO.CommitId,
+		Commenter: reqDTO.Commenter,
+		Content:   reqDTO.Content,
+	}
+	// 首条评论
+	if ret.ThreadId == "" {
+		ret.ThreadId = ret.Cid
+	}
+	_, err := xormutil.MustGetXormSession(ctx).Insert(&ret)
+	return ret, err
+}
+
+func GetByCid(ctx context.Context, cid string) (Comment, bool, error) {
+	var ret Comment
+	b, err := xormutil.MustGetXormSession(ctx).Where("cid = ?", cid).Get(&ret)
+	return ret, b, err
+}
+
+func ListComment(ctx context.Context, prId, filePath string) ([]Comment, error) {
+	ret := make([]Comment, 0)
+	session := xormutil.MustGetXormSession(ctx).Where("pr_id = ?", prId)
+	if filePath != "" {
+		session.And("file_path = ?", filePath)
+	}
+	return ret, session.OrderBy("id asc").Find(&ret)
+}
+
+// ListNotOutdatedThreadRootComment 查询未过时评论串的首条评论
+func ListNotOutdatedThreadRootComment(ctx context.Context, prId string) ([]Comment, error) {
+	ret := make([]Comment, 0)
+	err := xormutil.MustGetXormSession(ctx).
+		Where("pr_id = ?", prId).
+		And("cid = thread_id").
+		And("is_outdated = ?", false).
+		Find(&ret)
+	return ret, err
+}
+
+func UpdateThreadResolved(ctx context.Context, threadId string, isResolved bool, resolvedBy string) error {
+	_, err := xormutil.MustGetXormSession(ctx).
+		Where("thread_id = ?", threadId).
+		Cols("is_resolved", "resolved_by").
+		Update(&Comment{
+			IsResolved: isResolved,
+			ResolvedBy: resolvedBy,
+		})
+	return err
+}
+
+func UpdateThreadOutdated(ctx context.Context, threadIdList []string) error {
+	_, err := xormutil.MustGetXormSession(ctx).
+		In("thread_id", threadIdList).
+		Cols("is_outdated").
+		Update(&Comment{
+			IsOutdated: true,
+		})
+	return err
+}
@@ -0,0 +1,14 @@
+package pushrulemd
+
+type InsertPushRuleReqDTO struct {
+	ProjectId string
+	RepoId    string
+	Cfg       PushRuleCfg
+}
+
+type PushRuleDTO struct {
+	RuleId    string
+	ProjectId string
+	RepoId    string
+	Cfg       PushRuleCfg
+}
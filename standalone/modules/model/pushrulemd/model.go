@@ -0,0 +1,49 @@
+package pushrulemd
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	PushRuleTableName = "push_rule"
+)
+
+type PushRule struct {
+	Id        int64  `xorm:"pk autoincr"`
+	RuleId    string `json:"ruleId"`
+	ProjectId string `json:"projectId"`
+	// 为空表示项目级别规则
+	RepoId  string    `json:"repoId"`
+	Cfg     string    `json:"cfg"`
+	Created time.Time `json:"created" xorm:"created"`
+	Updated time.Time `json:"updated" xorm:"updated"`
+}
+
+func (*PushRule) TableName() string {
+	return PushRuleTableName
+}
+
+func (r *PushRule) GetCfg() PushRuleCfg {
+	var ret PushRuleCfg
+	_ = json.Unmarshal([]byte(r.Cfg), &ret)
+	return ret
+}
+
+type PushRuleCfg struct {
+	// 提交信息正则表达式 为空不限制
+	CommitMessagePattern string `json:"commitMessagePattern"`
+	// 提交作者和提交者邮箱需与推送人邮箱一致
+	CheckCommitterEmail bool `json:"checkCommitterEmail"`
+	// 单个文件大小限制 小于等于0不限制
+	MaxBlobSize int64 `json:"maxBlobSize"`
+	// 禁止推送的文件路径通配符 如*.pem
+	ForbiddenPathList []string `json:"forbiddenPathList"`
+	// 允许新建的分支名称通配符 为空不限制
+	AllowedNewBranchList []string `json:"allowedNewBranchList"`
+}
+
+func (c *PushRuleCfg) ToString() string {
+	m, _ := json.Marshal(c)
+	return string(m)
+}
@@ -0,0 +1,71 @@
+package pushrulemd
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf-utils/idutil"
+	"github.com/LeeZXin/zsf/xorm/xormutil"
+)
+
+func GenRuleId() string {
+	return idutil.RandomUuid()
+}
+
+func IsRuleIdValid(ruleId string) bool {
+	return len(ruleId) == 32
+}
+
+func InsertPushRule(ctx context.Context, reqDTO InsertPushRuleReqDTO) error {
+	_, err := xormutil.MustGetXormSession(ctx).Insert(&PushRule{
+		RuleId:    GenRuleId(),
+		ProjectId: reqDTO.ProjectId,
+		RepoId:    reqDTO.RepoId,
+		Cfg:       reqDTO.Cfg.ToString(),
+	})
+	return err
+}
+
+func UpdatePushRuleCfg(ctx context.Context, ruleId string, cfg PushRuleCfg) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("rule_id = ?", ruleId).
+		Cols("cfg").
+		Limit(1).
+		Update(&PushRule{
+			Cfg: cfg.ToString(),
+		})
+	return rows == 1, err
+}
+
+func DeletePushRule(ctx context.Context, ruleId string) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("rule_id = ?", ruleId).
+		Limit(1).
+		Delete(new(PushRule))
+	return rows == 1, err
+}
+
+func GetByRuleId(ctx context.Context, ruleId string) (PushRuleDTO, bool, error) {
+	var ret PushRule
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("rule_id = ?", ruleId).
+		Get(&ret)
+	return pushRule2DTO(ret), b, err
+}
+
+// GetPushRule repoId为空时获取项目级别规则
+func GetPushRule(ctx context.Context, projectId, repoId string) (PushRuleDTO, bool, error) {
+	var ret PushRule
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("project_id = ?", projectId).
+		And("repo_id = ?", repoId).
+		Get(&ret)
+	return pushRule2DTO(ret), b, err
+}
+
+func pushRule2DTO(r PushRule) PushRuleDTO {
+	return PushRuleDTO{
+		RuleId:    r.RuleId,
+		ProjectId: r.ProjectId,
+		RepoId:    r.RepoId,
+		Cfg:       r.GetCfg(),
+	}
+}
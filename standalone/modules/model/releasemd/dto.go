@@ -0,0 +1,37 @@
+package releasemd
+
+type InsertReleaseReqDTO struct {
+	RepoId       string
+	TagName      string
+	Target       string
+	Title        string
+	Note         string
+	IsDraft      bool
+	IsPrerelease bool
+	CreateBy     string
+}
+
+type UpdateReleaseReqDTO struct {
+	ReleaseId    string
+	Title        string
+	Note         string
+	IsDraft      bool
+	IsPrerelease bool
+}
+
+type ListReleaseReqDTO struct {
+	RepoId string
+	// 是否包含草稿
+	WithDraft bool
+	Offset    int64
+	Limit     int
+}
+
+type InsertAssetReqDTO struct {
+	AssetId   string
+	ReleaseId string
+	RepoId    string
+	Name      string
+	Size      int64
+	CreateBy  string
+}
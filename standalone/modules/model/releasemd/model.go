@@ -0,0 +1,44 @@
+package releasemd
+
+import "time"
+
+const (
+	// release是mysql关键字
+	ReleaseTableName = "repo_release"
+	AssetTableName   = "release_asset"
+)
+
+type Release struct {
+	Id        int64  `json:"id" xorm:"pk autoincr"`
+	ReleaseId string `json:"releaseId"`
+	RepoId    string `json:"repoId"`
+	TagName   string `json:"tagName"`
+	// Target 标签不存在时 发布后基于该分支或提交创建标签
+	Target       string    `json:"target"`
+	Title        string    `json:"title"`
+	Note         string    `json:"note"`
+	IsDraft      bool      `json:"isDraft"`
+	IsPrerelease bool      `json:"isPrerelease"`
+	CreateBy     string    `json:"createBy"`
+	Created      time.Time `json:"created" xorm:"created"`
+	Updated      time.Time `json:"updated" xorm:"updated"`
+}
+
+func (*Release) TableName() string {
+	return ReleaseTableName
+}
+
+type Asset struct {
+	Id        int64     `json:"id" xorm:"pk autoincr"`
+	AssetId   string    `json:"assetId"`
+	ReleaseId string    `json:"releaseId"`
+	RepoId    string    `json:"repoId"`
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreateBy  string    `json:"createBy"`
+	Created   time.Time `json:"created" xorm:"created"`
+}
+
+func (*Asset) TableName() string {
+	return AssetTableName
+}
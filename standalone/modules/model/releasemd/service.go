@@ -0,0 +1,157 @@
+package releasemd
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf-utils/idutil"
+	"github.com/LeeZXin/zsf/xorm/xormutil"
+)
+
+func GenReleaseId() string {
+	return idutil.RandomUuid()
+}
+
+func IsReleaseIdValid(releaseId string) bool {
+	return len(releaseId) == 32
+}
+
+func GenAssetId() string {
+	return idutil.RandomUuid()
+}
+
+func IsAssetIdValid(assetId string) bool {
+	return len(assetId) == 32
+}
+
+func InsertRelease(ctx context.Context, reqDTO InsertReleaseReqDTO) (Release, error) {
+	ret := Release{
+		ReleaseId:    GenReleaseId(),
+		RepoId:       reqDTO.RepoId,
+		TagName:      reqDTO.TagName,
+		Target:       reqDTO.Target,
+		Title:        reqDTO.Title,
+		Note:         reqDTO.Note,
+		IsDraft:      reqDTO.IsDraft,
+		IsPrerelease: reqDTO.IsPrerelease,
+		CreateBy:     reqDTO.CreateBy,
+	}
+	_, err := xormutil.MustGetXormSession(ctx).Insert(&ret)
+	return ret, err
+}
+
+func UpdateRelease(ctx context.Context, reqDTO UpdateReleaseReqDTO) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("release_id = ?", reqDTO.ReleaseId).
+		Cols("title", "note", "is_draft", "is_prerelease").
+		Limit(1).
+		Update(&Release{
+			Title:        reqDTO.Title,
+			Note:         reqDTO.Note,
+			IsDraft:      reqDTO.IsDraft,
+			IsPrerelease: reqDTO.IsPrerelease,
+		})
+	return rows == 1, err
+}
+
+func GetByReleaseId(ctx context.Context, releaseId string) (Release, bool, error) {
+	var ret Release
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("release_id = ?", releaseId).
+		Limit(1).
+		Get(&ret)
+	return ret, b, err
+}
+
+func GetByTagName(ctx context.Context, repoId, tagName string) (Release, bool, error) {
+	var ret Release
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", repoId).
+		And("tag_name = ?", tagName).
+		Limit(1).
+		Get(&ret)
+	return ret, b, err
+}
+
+func ListRelease(ctx context.Context, reqDTO ListReleaseReqDTO) ([]Release, error) {
+	ret := make([]Release, 0)
+	session := xormutil.MustGetXormSession(ctx).Where("repo_id = ?", reqDTO.RepoId)
+	if !reqDTO.WithDraft {
+		session.And("is_draft = ?", false)
+	}
+	if reqDTO.Offset > 0 {
+		session.And("id < ?", reqDTO.Offset)
+	}
+	if reqDTO.Limit > 0 {
+		session.Limit(reqDTO.Limit)
+	}
+	return ret, session.OrderBy("id desc").Find(&ret)
+}
+
+func DeleteRelease(ctx context.Context, releaseId string) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("release_id = ?", releaseId).
+		Limit(1).
+		Delete(new(Release))
+	return rows == 1, err
+}
+
+func DeleteReleaseByRepoId(ctx context.Context, repoId string) error {
+	_, err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", repoId).
+		Delete(new(Release))
+	return err
+}
+
+func InsertAsset(ctx context.Context, reqDTO InsertAssetReqDTO) (Asset, error) {
+	ret := Asset{
+		AssetId:   reqDTO.AssetId,
+		ReleaseId: reqDTO.ReleaseId,
+		RepoId:    reqDTO.RepoId,
+		Name:      reqDTO.Name,
+		Size:      reqDTO.Size,
+		CreateBy:  reqDTO.CreateBy,
+	}
+	_, err := xormutil.MustGetXormSession(ctx).Insert(&ret)
+	return ret, err
+}
+
+func GetAssetByAssetId(ctx context.Context, assetId string) (Asset, bool, error) {
+	var ret Asset
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("asset_id = ?", assetId).
+		Limit(1).
+		Get(&ret)
+	return ret, b, err
+}
+
+func ListAssetByReleaseIdList(ctx context.Context, releaseIdList []string) ([]Asset, error) {
+	ret := make([]Asset, 0)
+	if len(releaseIdList) == 0 {
+		return ret, nil
+	}
+	return ret, xormutil.MustGetXormSession(ctx).
+		In("release_id", releaseIdList).
+		OrderBy("id asc").
+		Find(&ret)
+}
+
+func DeleteAsset(ctx context.Context, assetId string) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("asset_id = ?", assetId).
+		Limit(1).
+		Delete(new(Asset))
+	return rows == 1, err
+}
+
+func DeleteAssetByReleaseId(ctx context.Context, releaseId string) error {
+	_, err := xormutil.MustGetXormSession(ctx).
+		Where("release_id = ?", releaseId).
+		Delete(new(Asset))
+	return err
+}
+
+func DeleteAssetByRepoId(ctx context.Context, repoId string) error {
+	_, err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", repoId).
+		Delete(new(Asset))
+	return err
+}
@@ -2,6 +2,7 @@ package repomd
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 )
 
@@ -40,6 +41,11 @@ func (r *Repo) GetCfg() RepoCfg {
 	return ret
 }
 
+// GetWikiPath wiki仓库相对路径 如xxx.git对应xxx.wiki
+func (r *Repo) GetWikiPath() string {
+	return strings.TrimSuffix(r.Path, ".git") + ".wiki"
+}
+
 func (r *Repo) ToRepoInfo() RepoInfo {
 	return RepoInfo{
 		RepoId:    r.RepoId,
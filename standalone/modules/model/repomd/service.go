@@ -37,6 +37,17 @@ func UpdateTotalAndGitSize(ctx context.Context, repoId string, totalSize, gitSiz
 	return err
 }
 
+func UpdateTotalAndWikiSize(ctx context.Context, repoId string, totalSize, wikiSize int64) error {
+	_, err := xormutil.MustGetXormSession(ctx).Where("repo_id = ?", repoId).
+		Cols("total_size", "wiki_size").
+		Limit(1).
+		Update(&Repo{
+			TotalSize: totalSize,
+			WikiSize:  wikiSize,
+		})
+	return err
+}
+
 func ListAllRepo(ctx context.Context, projectId string) ([]Repo, error) {
 	session := xormutil.MustGetXormSession(ctx).Where("project_id = ?", projectId)
 	ret := make([]Repo, 0)
@@ -85,3 +96,35 @@ func DeleteRepo(ctx context.Context, repo Repo) (bool, error) {
 	rows, err := xormutil.MustGetXormSession(ctx).Where("repo_id = ?", repo.RepoId).Delete(new(Repo))
 	return rows == 1, err
 }
+
+func IncrLfsSize(ctx context.Context, repoId string, size int64) error {
+	_, err := xormutil.MustGetXormSession(ctx).Where("repo_id = ?", repoId).
+		Incr("lfs_size", size).
+		Incr("total_size", size).
+		Limit(1).
+		Update(new(Repo))
+	return err
+}
+
+func CountRepo(ctx context.Context) (int64, error) {
+	return xormutil.MustGetXormSession(ctx).Count(new(Repo))
+}
+
+// SumGitAndLfsSize 所有仓库git和lfs大小之和 wiki大小计入git大小
+func SumGitAndLfsSize(ctx context.Context) (int64, int64, error) {
+	sums, err := xormutil.MustGetXormSession(ctx).SumsInt(new(Repo), "git_size", "lfs_size", "wiki_size")
+	if err != nil {
+		return 0, 0, err
+	}
+	return sums[0] + sums[2], sums[1], nil
+}
+
+func UpdateDefaultBranch(ctx context.Context, repoId, branch string) error {
+	_, err := xormutil.MustGetXormSession(ctx).Where("repo_id = ?", repoId).
+		Cols("default_branch").
+		Limit(1).
+		Update(&Repo{
+			DefaultBranch: branch,
+		})
+	return err
+}
@@ -0,0 +1,14 @@
+package tagmd
+
+type InsertProtectedTagReqDTO struct {
+	RepoId string
+	Tag    string
+	Cfg    ProtectedTagCfg
+}
+
+type ProtectedTagDTO struct {
+	Tid    string
+	RepoId string
+	Tag    string
+	Cfg    ProtectedTagCfg
+}
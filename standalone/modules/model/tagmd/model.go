@@ -0,0 +1,34 @@
+package tagmd
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	ProtectedTagTableName = "protected_tag"
+)
+
+type ProtectedTag struct {
+	Id      int64     `json:"id" xorm:"pk autoincr"`
+	Tid     string    `json:"tid"`
+	Tag     string    `json:"tag"`
+	RepoId  string    `json:"repoId"`
+	Cfg     string    `json:"cfg"`
+	Created time.Time `json:"created" xorm:"created"`
+	Updated time.Time `json:"updated" xorm:"updated"`
+}
+
+func (*ProtectedTag) TableName() string {
+	return ProtectedTagTableName
+}
+
+type ProtectedTagCfg struct {
+	// 可创建标签名单 为空不限制
+	CreatorList []string `json:"creatorList"`
+}
+
+func (c *ProtectedTagCfg) ToString() string {
+	m, _ := json.Marshal(c)
+	return string(m)
+}
@@ -0,0 +1,86 @@
+package tagmd
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/LeeZXin/zsf-utils/idutil"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/xorm/xormutil"
+	"regexp"
+)
+
+var (
+	validTagPattern = regexp.MustCompile(`^\S{1,32}$`)
+)
+
+func GenTid() string {
+	return idutil.RandomUuid()
+}
+
+func IsTidValid(tid string) bool {
+	return len(tid) == 32
+}
+
+func IsWildcardTagValid(tag string) bool {
+	return validTagPattern.MatchString(tag)
+}
+
+func InsertProtectedTag(ctx context.Context, reqDTO InsertProtectedTagReqDTO) error {
+	_, err := xormutil.MustGetXormSession(ctx).Insert(&ProtectedTag{
+		Tid:    GenTid(),
+		Tag:    reqDTO.Tag,
+		RepoId: reqDTO.RepoId,
+		Cfg:    reqDTO.Cfg.ToString(),
+	})
+	return err
+}
+
+func DeleteProtectedTag(ctx context.Context, tag ProtectedTagDTO) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", tag.RepoId).
+		And("tag = ?", tag.Tag).
+		Limit(1).
+		Delete(new(ProtectedTag))
+	return rows == 1, err
+}
+
+func GetProtectedTag(ctx context.Context, repoId, tag string) (ProtectedTagDTO, bool, error) {
+	ret := ProtectedTag{}
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("repo_id = ?", repoId).
+		And("tag = ?", tag).
+		Limit(1).
+		Get(&ret)
+	return protectedTag2DTO(ret), b, err
+}
+
+func GetProtectedTagByTid(ctx context.Context, tid string) (ProtectedTagDTO, bool, error) {
+	ret := ProtectedTag{}
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("tid = ?", tid).
+		Limit(1).
+		Get(&ret)
+	return protectedTag2DTO(ret), b, err
+}
+
+func ListProtectedTag(ctx context.Context, repoId string) ([]ProtectedTagDTO, error) {
+	session := xormutil.MustGetXormSession(ctx).Where("repo_id = ?", repoId)
+	ret := make([]ProtectedTag, 0)
+	if err := session.Find(&ret); err != nil {
+		return nil, err
+	}
+	return listutil.Map(ret, func(t ProtectedTag) (ProtectedTagDTO, error) {
+		return protectedTag2DTO(t), nil
+	})
+}
+
+func protectedTag2DTO(t ProtectedTag) ProtectedTagDTO {
+	var cfg ProtectedTagCfg
+	json.Unmarshal([]byte(t.Cfg), &cfg)
+	return ProtectedTagDTO{
+		Tid:    t.Tid,
+		RepoId: t.RepoId,
+		Tag:    t.Tag,
+		Cfg:    cfg,
+	}
+}
@@ -0,0 +1,59 @@
+package webhookmd
+
+import "time"
+
+type InsertWebhookReqDTO struct {
+	ProjectId string
+	RepoId    string
+	Cfg       WebhookCfg
+}
+
+type WebhookDTO struct {
+	HookId    string
+	ProjectId string
+	RepoId    string
+	Cfg       WebhookCfg
+}
+
+type InsertDeliveryReqDTO struct {
+	HookId        string
+	Event         string
+	RequestUrl    string
+	RequestHeader map[string]string
+	RequestBody   string
+}
+
+type UpdateDeliveryResultReqDTO struct {
+	DeliveryId     string
+	ResponseStatus int
+	ResponseHeader string
+	ResponseBody   string
+	ErrMsg         string
+	Latency        int64
+	Attempts       int
+	IsSuccess      bool
+}
+
+type ListDeliveryReqDTO struct {
+	HookId string
+	Offset int64
+	Limit  int
+}
+
+type DeliveryDTO struct {
+	Id             int64
+	DeliveryId     string
+	HookId         string
+	Event          string
+	RequestUrl     string
+	RequestHeader  map[string]string
+	RequestBody    string
+	ResponseStatus int
+	ResponseHeader string
+	ResponseBody   string
+	ErrMsg         string
+	Latency        int64
+	Attempts       int
+	IsSuccess      bool
+	Created        time.Time
+}
@@ -0,0 +1,89 @@
+package webhookmd
+
+import (
+	"encoding/json"
+	"time"
+	"zgit/pkg/webhook"
+)
+
+const (
+	WebhookTableName  = "webhook"
+	DeliveryTableName = "webhook_delivery"
+)
+
+type Webhook struct {
+	Id        int64  `xorm:"pk autoincr"`
+	HookId    string `json:"hookId"`
+	ProjectId string `json:"projectId"`
+	// 为空表示项目级别
+	RepoId  string    `json:"repoId"`
+	Cfg     string    `json:"cfg"`
+	Created time.Time `json:"created" xorm:"created"`
+	Updated time.Time `json:"updated" xorm:"updated"`
+}
+
+func (*Webhook) TableName() string {
+	return WebhookTableName
+}
+
+func (h *Webhook) GetCfg() WebhookCfg {
+	var ret WebhookCfg
+	_ = json.Unmarshal([]byte(h.Cfg), &ret)
+	return ret
+}
+
+type WebhookCfg struct {
+	Url         string `json:"url"`
+	Secret      string `json:"secret"`
+	ContentType string `json:"contentType"`
+	// 订阅的事件
+	Events   []webhook.Event `json:"events"`
+	IsActive bool            `json:"isActive"`
+}
+
+func (c *WebhookCfg) ToString() string {
+	m, _ := json.Marshal(c)
+	return string(m)
+}
+
+func (c *WebhookCfg) HasEvent(event webhook.Event) bool {
+	for _, e := range c.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+type Delivery struct {
+	Id         int64  `xorm:"pk autoincr"`
+	DeliveryId string `json:"deliveryId"`
+	HookId     string `json:"hookId"`
+	Event      string `json:"event"`
+	RequestUrl string `json:"requestUrl"`
+	// json格式的请求头
+	RequestHeader string `json:"requestHeader"`
+	RequestBody   string `json:"requestBody"`
+	// 0表示请求未发出
+	ResponseStatus int    `json:"responseStatus"`
+	ResponseHeader string `json:"responseHeader"`
+	ResponseBody   string `json:"responseBody"`
+	// 错误信息
+	ErrMsg string `json:"errMsg"`
+	// 耗时 毫秒
+	Latency   int64     `json:"latency"`
+	Attempts  int       `json:"attempts"`
+	IsSuccess bool      `json:"isSuccess"`
+	Created   time.Time `json:"created" xorm:"created"`
+	Updated   time.Time `json:"updated" xorm:"updated"`
+}
+
+func (*Delivery) TableName() string {
+	return DeliveryTableName
+}
+
+func (d *Delivery) GetRequestHeader() map[string]string {
+	ret := make(map[string]string)
+	_ = json.Unmarshal([]byte(d.RequestHeader), &ret)
+	return ret
+}
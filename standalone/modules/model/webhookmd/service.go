@@ -0,0 +1,191 @@
+package webhookmd
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/LeeZXin/zsf-utils/idutil"
+	"github.com/LeeZXin/zsf/xorm/xormutil"
+)
+
+func GenHookId() string {
+	return idutil.RandomUuid()
+}
+
+func IsHookIdValid(hookId string) bool {
+	return len(hookId) == 32
+}
+
+func GenDeliveryId() string {
+	return idutil.RandomUuid()
+}
+
+func IsDeliveryIdValid(deliveryId string) bool {
+	return len(deliveryId) == 32
+}
+
+func InsertWebhook(ctx context.Context, reqDTO InsertWebhookReqDTO) error {
+	_, err := xormutil.MustGetXormSession(ctx).Insert(&Webhook{
+		HookId:    GenHookId(),
+		ProjectId: reqDTO.ProjectId,
+		RepoId:    reqDTO.RepoId,
+		Cfg:       reqDTO.Cfg.ToString(),
+	})
+	return err
+}
+
+func UpdateWebhookCfg(ctx context.Context, hookId string, cfg WebhookCfg) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("hook_id = ?", hookId).
+		Cols("cfg").
+		Limit(1).
+		Update(&Webhook{
+			Cfg: cfg.ToString(),
+		})
+	return rows == 1, err
+}
+
+func DeleteWebhook(ctx context.Context, hookId string) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("hook_id = ?", hookId).
+		Limit(1).
+		Delete(new(Webhook))
+	return rows == 1, err
+}
+
+func DeleteDeliveryByHookId(ctx context.Context, hookId string) error {
+	_, err := xormutil.MustGetXormSession(ctx).
+		Where("hook_id = ?", hookId).
+		Delete(new(Delivery))
+	return err
+}
+
+func GetByHookId(ctx context.Context, hookId string) (WebhookDTO, bool, error) {
+	var ret Webhook
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("hook_id = ?", hookId).
+		Get(&ret)
+	return webhook2DTO(ret), b, err
+}
+
+// ListWebhook repoId为空时获取项目级别webhook
+func ListWebhook(ctx context.Context, projectId, repoId string) ([]WebhookDTO, error) {
+	ret := make([]Webhook, 0)
+	err := xormutil.MustGetXormSession(ctx).
+		Where("project_id = ?", projectId).
+		And("repo_id = ?", repoId).
+		OrderBy("id asc").
+		Find(&ret)
+	if err != nil {
+		return nil, err
+	}
+	return webhookList2DTO(ret), nil
+}
+
+// ListRepoEffectiveWebhook 获取仓库生效的webhook 包含项目级别
+func ListRepoEffectiveWebhook(ctx context.Context, projectId, repoId string) ([]WebhookDTO, error) {
+	ret := make([]Webhook, 0)
+	err := xormutil.MustGetXormSession(ctx).
+		Where("project_id = ?", projectId).
+		In("repo_id", repoId, "").
+		Find(&ret)
+	if err != nil {
+		return nil, err
+	}
+	return webhookList2DTO(ret), nil
+}
+
+func InsertDelivery(ctx context.Context, reqDTO InsertDeliveryReqDTO) (DeliveryDTO, error) {
+	header, _ := json.Marshal(reqDTO.RequestHeader)
+	ret := Delivery{
+		DeliveryId:    GenDeliveryId(),
+		HookId:        reqDTO.HookId,
+		Event:         reqDTO.Event,
+		RequestUrl:    reqDTO.RequestUrl,
+		RequestHeader: string(header),
+		RequestBody:   reqDTO.RequestBody,
+	}
+	_, err := xormutil.MustGetXormSession(ctx).Insert(&ret)
+	return delivery2DTO(ret), err
+}
+
+func UpdateDeliveryResult(ctx context.Context, reqDTO UpdateDeliveryResultReqDTO) (bool, error) {
+	rows, err := xormutil.MustGetXormSession(ctx).
+		Where("delivery_id = ?", reqDTO.DeliveryId).
+		Cols("response_status", "response_header", "response_body", "err_msg", "latency", "attempts", "is_success").
+		Limit(1).
+		Update(&Delivery{
+			ResponseStatus: reqDTO.ResponseStatus,
+			ResponseHeader: reqDTO.ResponseHeader,
+			ResponseBody:   reqDTO.ResponseBody,
+			ErrMsg:         reqDTO.ErrMsg,
+			Latency:        reqDTO.Latency,
+			Attempts:       reqDTO.Attempts,
+			IsSuccess:      reqDTO.IsSuccess,
+		})
+	return rows == 1, err
+}
+
+func GetByDeliveryId(ctx context.Context, deliveryId string) (DeliveryDTO, bool, error) {
+	var ret Delivery
+	b, err := xormutil.MustGetXormSession(ctx).
+		Where("delivery_id = ?", deliveryId).
+		Get(&ret)
+	return delivery2DTO(ret), b, err
+}
+
+func ListDelivery(ctx context.Context, reqDTO ListDeliveryReqDTO) ([]DeliveryDTO, error) {
+	ret := make([]Delivery, 0)
+	session := xormutil.MustGetXormSession(ctx).Where("hook_id = ?", reqDTO.HookId)
+	if reqDTO.Offset > 0 {
+		session.And("id < ?", reqDTO.Offset)
+	}
+	if reqDTO.Limit > 0 {
+		session.Limit(reqDTO.Limit)
+	}
+	err := session.OrderBy("id desc").Find(&ret)
+	if err != nil {
+		return nil, err
+	}
+	dtoList := make([]DeliveryDTO, 0, len(ret))
+	for _, d := range ret {
+		dtoList = append(dtoList, delivery2DTO(d))
+	}
+	return dtoList, nil
+}
+
+func webhookList2DTO(hooks []Webhook) []WebhookDTO {
+	ret := make([]WebhookDTO, 0, len(hooks))
+	for _, h := range hooks {
+		ret = append(ret, webhook2DTO(h))
+	}
+	return ret
+}
+
+func webhook2DTO(h Webhook) WebhookDTO {
+	return WebhookDTO{
+		HookId:    h.HookId,
+		ProjectId: h.ProjectId,
+		RepoId:    h.RepoId,
+		Cfg:       h.GetCfg(),
+	}
+}
+
+func delivery2DTO(d Delivery) DeliveryDTO {
+	return DeliveryDTO{
+		Id:             d.Id,
+		DeliveryId:     d.DeliveryId,
+		HookId:         d.HookId,
+		Event:          d.Event,
+		RequestUrl:     d.RequestUrl,
+		RequestHeader:  d.GetRequestHeader(),
+		RequestBody:    d.RequestBody,
+		ResponseStatus: d.ResponseStatus,
+		ResponseHeader: d.ResponseHeader,
+		ResponseBody:   d.ResponseBody,
+		ErrMsg:         d.ErrMsg,
+		Latency:        d.Latency,
+		Attempts:       d.Attempts,
+		IsSuccess:      d.IsSuccess,
+		Created:        d.Created,
+	}
+}
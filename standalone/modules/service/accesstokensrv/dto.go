@@ -0,0 +1,85 @@
+package accesstokensrv
+
+import (
+	"time"
+	"zgit/standalone/modules/model/accesstokenmd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+type InsertAccessTokenReqDTO struct {
+	Name   string
+	Scopes []accesstokenmd.Scope
+	// 过期时间 0为永不过期
+	ExpireAt int64
+	Operator usermd.UserInfo
+}
+
+func (r *InsertAccessTokenReqDTO) IsValid() error {
+	if len(r.Name) == 0 || len(r.Name) > 128 {
+		return util.InvalidArgsError()
+	}
+	if len(r.Scopes) == 0 {
+		return util.InvalidArgsError()
+	}
+	for _, scope := range r.Scopes {
+		if !scope.IsValid() {
+			return util.InvalidArgsError()
+		}
+	}
+	if r.ExpireAt < 0 || (r.ExpireAt > 0 && r.ExpireAt < time.Now().UnixMilli()) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type DeleteAccessTokenReqDTO struct {
+	TokenId  string
+	Operator usermd.UserInfo
+}
+
+func (r *DeleteAccessTokenReqDTO) IsValid() error {
+	if !accesstokenmd.IsTokenIdValid(r.TokenId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type ListAccessTokenReqDTO struct {
+	Offset   int64
+	Limit    int
+	Operator usermd.UserInfo
+}
+
+func (r *ListAccessTokenReqDTO) IsValid() error {
+	if r.Offset < 0 {
+		return util.InvalidArgsError()
+	}
+	if r.Limit <= 0 || r.Limit > 1000 {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type AccessTokenDTO struct {
+	TokenId  string
+	Name     string
+	Scopes   []accesstokenmd.Scope
+	ExpireAt int64
+	LastUsed int64
+	Created  time.Time
+}
+
+type ListAccessTokenRespDTO struct {
+	Cursor    int64
+	TokenList []AccessTokenDTO
+}
@@ -0,0 +1,181 @@
+package accesstokensrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"time"
+	"zgit/standalone/modules/model/accesstokenmd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/standalone/modules/service/usersrv"
+	"zgit/util"
+)
+
+const (
+	// 最后使用时间更新间隔
+	refreshLastUsedInterval = time.Minute
+)
+
+var (
+	tokenCache    = util.NewGoCache()
+	lastUsedCache = util.NewGoCache()
+)
+
+// InsertAccessToken 创建token 明文只在创建时返回一次
+func InsertAccessToken(ctx context.Context, reqDTO InsertAccessTokenReqDTO) (string, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return "", err
+	}
+	// 非系统管理员不能申请admin权限
+	if !reqDTO.Operator.IsAdmin {
+		for _, scope := range reqDTO.Scopes {
+			if scope == accesstokenmd.AdminScope {
+				return "", util.UnauthorizedError()
+			}
+		}
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	token := accesstokenmd.GenToken()
+	_, err := accesstokenmd.InsertAccessToken(ctx, accesstokenmd.InsertAccessTokenReqDTO{
+		Account:   reqDTO.Operator.Account,
+		Name:      reqDTO.Name,
+		TokenHash: accesstokenmd.HashToken(token),
+		Scopes:    reqDTO.Scopes,
+		ExpireAt:  reqDTO.ExpireAt,
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return "", util.InternalError()
+	}
+	return token, nil
+}
+
+func DeleteAccessToken(ctx context.Context, reqDTO DeleteAccessTokenReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	token, b, err := accesstokenmd.GetByTokenId(ctx, reqDTO.TokenId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if !b {
+		return util.InvalidArgsError()
+	}
+	// 只有拥有人才能删除token
+	if token.Account != reqDTO.Operator.Account {
+		return util.UnauthorizedError()
+	}
+	_, err = accesstokenmd.DeleteAccessToken(ctx, reqDTO.TokenId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	// 删除缓存
+	tokenCache.Delete(token.TokenHash)
+	return nil
+}
+
+func ListAccessToken(ctx context.Context, reqDTO ListAccessTokenReqDTO) (ListAccessTokenRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return ListAccessTokenRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	tokenList, err := accesstokenmd.ListAccessToken(ctx, accesstokenmd.ListAccessTokenReqDTO{
+		Account: reqDTO.Operator.Account,
+		Offset:  reqDTO.Offset,
+		Limit:   reqDTO.Limit,
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ListAccessTokenRespDTO{}, util.InternalError()
+	}
+	ret := ListAccessTokenRespDTO{}
+	ret.TokenList, _ = listutil.Map(tokenList, func(t accesstokenmd.AccessToken) (AccessTokenDTO, error) {
+		return AccessTokenDTO{
+			TokenId:  t.TokenId,
+			Name:     t.Name,
+			Scopes:   t.GetScopes(),
+			ExpireAt: t.ExpireAt,
+			LastUsed: t.LastUsed,
+			Created:  t.Created,
+		}, nil
+	})
+	if len(tokenList) > 0 {
+		ret.Cursor = tokenList[len(tokenList)-1].Id
+	}
+	return ret, nil
+}
+
+// CheckAccessToken 校验token 返回token信息和所属用户
+func CheckAccessToken(ctx context.Context, token string) (accesstokenmd.TokenInfo, usermd.UserInfo, bool, error) {
+	if !accesstokenmd.IsAccessToken(token) {
+		return accesstokenmd.TokenInfo{}, usermd.UserInfo{}, false, nil
+	}
+	tokenHash := accesstokenmd.HashToken(token)
+	info, b, err := getTokenInfo(ctx, tokenHash)
+	if err != nil || !b {
+		return accesstokenmd.TokenInfo{}, usermd.UserInfo{}, false, err
+	}
+	if info.IsExpired() {
+		return accesstokenmd.TokenInfo{}, usermd.UserInfo{}, false, nil
+	}
+	user, b, err := usersrv.GetUserInfoByAccount(ctx, info.Account)
+	if err != nil || !b {
+		return accesstokenmd.TokenInfo{}, usermd.UserInfo{}, false, err
+	}
+	if user.IsProhibited {
+		return accesstokenmd.TokenInfo{}, usermd.UserInfo{}, false, nil
+	}
+	// 没有admin权限的token 不享有系统管理员权限
+	if !info.HasScope(accesstokenmd.AdminScope) {
+		user.IsAdmin = false
+	}
+	refreshLastUsed(ctx, info.TokenId)
+	return info, user, true, nil
+}
+
+func getTokenInfo(ctx context.Context, tokenHash string) (accesstokenmd.TokenInfo, bool, error) {
+	v, b := tokenCache.Get(tokenHash)
+	if b {
+		ret := v.(accesstokenmd.TokenInfo)
+		// 来自空缓存
+		if ret.TokenId == "" {
+			return ret, false, nil
+		}
+		return ret, true, nil
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	token, b, err := accesstokenmd.GetByTokenHash(ctx, tokenHash)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return accesstokenmd.TokenInfo{}, false, util.InternalError()
+	}
+	if !b {
+		// 设置空缓存
+		tokenCache.Set(tokenHash, accesstokenmd.TokenInfo{}, time.Second)
+		return accesstokenmd.TokenInfo{}, false, nil
+	}
+	ret := token.ToTokenInfo()
+	tokenCache.Set(tokenHash, ret, time.Minute)
+	return ret, true, nil
+}
+
+// refreshLastUsed 更新最后使用时间 一分钟内只更新一次
+func refreshLastUsed(ctx context.Context, tokenId string) {
+	if _, b := lastUsedCache.Get(tokenId); b {
+		return
+	}
+	lastUsedCache.Set(tokenId, struct{}{}, refreshLastUsedInterval)
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	if _, err := accesstokenmd.UpdateLastUsed(ctx, tokenId, time.Now().UnixMilli()); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+	}
+}
@@ -35,6 +35,19 @@ func (r *InsertProtectedBranchReqDTO) IsValid() error {
 	if r.Cfg.ReviewCountWhenCreatePr < len(r.Cfg.ReviewerList) {
 		return util.NewBizErr(apicode.InvalidReviewCountWhenCreatePrCode, i18n.ProtectedBranchInvalidReviewCountWhenCreatePr)
 	}
+	for _, strategy := range r.Cfg.AllowedMergeStrategies {
+		if !strategy.IsValid() {
+			return util.InvalidArgsError()
+		}
+	}
+	if len(r.Cfg.RequiredStatusContexts) > 50 {
+		return util.InvalidArgsError()
+	}
+	for _, statusContext := range r.Cfg.RequiredStatusContexts {
+		if len(statusContext) == 0 || len(statusContext) > 255 {
+			return util.InvalidArgsError()
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,142 @@
+package codesearchsrv
+
+import (
+	"zgit/standalone/modules/model/projectmd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+const (
+	// 除默认分支外最多索引的分支数量
+	maxIndexBranches = 10
+	// 单页最多返回数量
+	maxSearchLimit = 50
+)
+
+type GetIndexCfgReqDTO struct {
+	RepoId   string
+	Operator usermd.UserInfo
+}
+
+func (r *GetIndexCfgReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type IndexCfgDTO struct {
+	DefaultBranch string
+	Branches      []string
+	// 各分支已索引到的提交
+	IndexedCommits map[string]string
+}
+
+type UpdateIndexCfgReqDTO struct {
+	RepoId   string
+	Branches []string
+	Operator usermd.UserInfo
+}
+
+func (r *UpdateIndexCfgReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if len(r.Branches) > maxIndexBranches {
+		return util.InvalidArgsError()
+	}
+	for _, branch := range r.Branches {
+		if !util.ValidateRef(branch) {
+			return util.InvalidArgsError()
+		}
+	}
+	return nil
+}
+
+type SearchOpts struct {
+	Keyword string
+	// 路径通配符 可选
+	PathGlob string
+	Language string
+	Offset   int
+	Limit    int
+}
+
+func (o *SearchOpts) IsValid() bool {
+	if len(o.Keyword) == 0 || len(o.Keyword) > 128 {
+		return false
+	}
+	if len(o.PathGlob) > 255 || len(o.Language) > 32 {
+		return false
+	}
+	return o.Offset >= 0 && o.Limit > 0 && o.Limit <= maxSearchLimit
+}
+
+type SearchRepoReqDTO struct {
+	RepoId string
+	// 为空搜索默认分支
+	Branch string
+	SearchOpts
+	Operator usermd.UserInfo
+}
+
+func (r *SearchRepoReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if r.Branch != "" && !util.ValidateRef(r.Branch) {
+		return util.InvalidArgsError()
+	}
+	if !r.SearchOpts.IsValid() {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type SearchProjectReqDTO struct {
+	ProjectId string
+	SearchOpts
+	Operator usermd.UserInfo
+}
+
+func (r *SearchProjectReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !projectmd.IsProjectIdValid(r.ProjectId) {
+		return util.InvalidArgsError()
+	}
+	if !r.SearchOpts.IsValid() {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type SnippetDTO struct {
+	LineNo int
+	Line   string
+}
+
+type SearchHitDTO struct {
+	RepoId   string
+	RepoName string
+	Branch   string
+	Path     string
+	Language string
+	Snippets []SnippetDTO
+}
+
+type SearchRespDTO struct {
+	Total uint64
+	Hits  []SearchHitDTO
+}
@@ -0,0 +1,234 @@
+package codesearchsrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"github.com/blevesearch/bleve"
+	"io"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+	"zgit/pkg/codesearch"
+	"zgit/pkg/git"
+	"zgit/setting"
+	"zgit/standalone/modules/model/codeindexmd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/util"
+)
+
+const (
+	// 超过该大小的文件不索引
+	maxIndexFileSize = 512 * 1024
+	indexTimeout     = 30 * time.Minute
+	// 最大并发索引数
+	maxConcurrentIndexes = 2
+)
+
+var (
+	// 已打开的索引 bleve同一目录只能打开一次
+	openedIndexes = make(map[string]bleve.Index)
+	indexMu       sync.Mutex
+	// 正在索引的仓库 值为索引期间是否有新的推送
+	indexingRepos sync.Map
+	indexSem      = make(chan struct{}, maxConcurrentIndexes)
+)
+
+func getIndex(repoId string) (bleve.Index, error) {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	if idx, b := openedIndexes[repoId]; b {
+		return idx, nil
+	}
+	idx, err := codesearch.OpenIndex(filepath.Join(setting.CodeIndexDir(), repoId))
+	if err != nil {
+		return nil, err
+	}
+	openedIndexes[repoId] = idx
+	return idx, nil
+}
+
+// DeleteIndex 删除仓库索引
+func DeleteIndex(repoId string) {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	if idx, b := openedIndexes[repoId]; b {
+		idx.Close()
+		delete(openedIndexes, repoId)
+	}
+	util.RemoveAll(filepath.Join(setting.CodeIndexDir(), repoId))
+}
+
+// TriggerIndex 异步更新仓库索引
+func TriggerIndex(repoId string) {
+	go indexRepo(repoId)
+}
+
+// indexRepo 同一仓库同时只有一个索引任务 索引期间有新的推送则完成后再索引一次
+func indexRepo(repoId string) {
+	dirty := new(atomic.Bool)
+	if v, loaded := indexingRepos.LoadOrStore(repoId, dirty); loaded {
+		v.(*atomic.Bool).Store(true)
+		return
+	}
+	defer indexingRepos.Delete(repoId)
+	indexSem <- struct{}{}
+	defer func() {
+		<-indexSem
+	}()
+	for {
+		dirty.Store(false)
+		if err := doIndexRepo(repoId); err != nil {
+			logger.Logger.Errorf("index repo: %s err: %v", repoId, err)
+		}
+		if !dirty.Load() {
+			return
+		}
+	}
+}
+
+func doIndexRepo(repoId string) error {
+	ctx, closer := mysqlstore.Context(context.Background())
+	defer closer.Close()
+	repo, b, err := repomd.GetByRepoId(ctx, repoId)
+	if err != nil || !b || repo.IsEmpty {
+		return err
+	}
+	branches, err := getIndexBranches(ctx, repo)
+	if err != nil {
+		return err
+	}
+	idx, err := getIndex(repoId)
+	if err != nil {
+		return err
+	}
+	state, err := codesearch.GetState(idx)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, indexTimeout)
+	defer cancel()
+	absPath := filepath.Join(setting.RepoDir(), repo.Path)
+	// 当前的分支提交 分支不存在则不索引
+	heads := make(map[string]string, len(branches))
+	for _, branch := range branches {
+		commitId, err := git.GetRefCommitId(ctx, absPath, git.BranchPrefix+branch)
+		if err == nil {
+			heads[branch] = commitId
+		}
+	}
+	for branch := range state.Branches {
+		if _, b := heads[branch]; b {
+			continue
+		}
+		if err = codesearch.DeleteBranch(idx, branch); err != nil {
+			return err
+		}
+		delete(state.Branches, branch)
+		if err = codesearch.SetState(idx, state); err != nil {
+			return err
+		}
+	}
+	for branch, commitId := range heads {
+		lastCommitId := state.Branches[branch]
+		if lastCommitId == commitId {
+			continue
+		}
+		if err = indexBranch(ctx, idx, repo.RepoId, absPath, branch, lastCommitId, commitId); err != nil {
+			return err
+		}
+		state.Branches[branch] = commitId
+		if err = codesearch.SetState(idx, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getIndexBranches 默认分支加上配置的分支
+func getIndexBranches(ctx context.Context, repo repomd.Repo) ([]string, error) {
+	ret := []string{repo.DefaultBranch}
+	cfg, b, err := codeindexmd.GetCodeIndex(ctx, repo.RepoId)
+	if err != nil || !b {
+		return ret, err
+	}
+	for _, branch := range cfg.Cfg.Branches {
+		if branch != repo.DefaultBranch {
+			ret = append(ret, branch)
+		}
+	}
+	return ret, nil
+}
+
+// indexBranch 上次索引的提交还存在则只索引变更的文件 否则全量索引
+func indexBranch(ctx context.Context, idx bleve.Index, repoId, absPath, branch, lastCommitId, commitId string) error {
+	batch := codesearch.NewBatch(idx)
+	changes := make([]git.TreeChange, 0)
+	if lastCommitId != "" && git.CheckExists(ctx, absPath, lastCommitId) {
+		diff, err := git.DiffTreeChanges(ctx, absPath, lastCommitId, commitId)
+		if err != nil {
+			return err
+		}
+		changes = diff
+	} else {
+		if err := codesearch.DeleteBranch(idx, branch); err != nil {
+			return err
+		}
+		blobs, err := git.ListTreeBlobs(ctx, absPath, commitId)
+		if err != nil {
+			return err
+		}
+		for _, blob := range blobs {
+			if blob.Size > maxIndexFileSize {
+				continue
+			}
+			changes = append(changes, git.TreeChange{
+				Path: blob.Path,
+				Mode: blob.Mode,
+				Blob: blob.Blob,
+			})
+		}
+	}
+	// 需要读取内容的文件
+	indexList := make([]git.TreeChange, 0, len(changes))
+	for _, change := range changes {
+		if change.IsDeleted || (change.Mode != git.RegularFileMode && change.Mode != git.ExecutableFileMode) {
+			if err := batch.Delete(branch, change.Path); err != nil {
+				return err
+			}
+			continue
+		}
+		indexList = append(indexList, change)
+	}
+	blobIdList := make([]string, 0, len(indexList))
+	for _, change := range indexList {
+		blobIdList = append(blobIdList, change.Blob)
+	}
+	i := 0
+	err := git.CatFileBatchBlobs(ctx, absPath, blobIdList, func(_ string, size int64, r io.Reader) error {
+		change := indexList[i]
+		i++
+		if size > maxIndexFileSize {
+			return batch.Delete(branch, change.Path)
+		}
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if git.IsBinaryContent(content) {
+			return batch.Delete(branch, change.Path)
+		}
+		return batch.Index(codesearch.Document{
+			RepoId:   repoId,
+			Branch:   branch,
+			Path:     change.Path,
+			Language: codesearch.DetectLanguage(change.Path),
+			Content:  string(content),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return batch.Flush()
+}
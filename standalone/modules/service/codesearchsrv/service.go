@@ -0,0 +1,244 @@
+package codesearchsrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"github.com/blevesearch/bleve"
+	"zgit/pkg/codesearch"
+	"zgit/pkg/perm"
+	"zgit/standalone/modules/model/codeindexmd"
+	"zgit/standalone/modules/model/projectmd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+// GetIndexCfg 获取索引分支配置和索引进度
+func GetIndexCfg(ctx context.Context, reqDTO GetIndexCfgReqDTO) (IndexCfgDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return IndexCfgDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return IndexCfgDTO{}, err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanAccess {
+		return IndexCfgDTO{}, util.UnauthorizedError()
+	}
+	cfg, _, err := codeindexmd.GetCodeIndex(ctx, repo.RepoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return IndexCfgDTO{}, util.InternalError()
+	}
+	ret := IndexCfgDTO{
+		DefaultBranch: repo.DefaultBranch,
+		Branches:      cfg.Cfg.Branches,
+	}
+	if ret.Branches == nil {
+		ret.Branches = []string{}
+	}
+	idx, err := getIndex(repo.RepoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return IndexCfgDTO{}, util.InternalError()
+	}
+	state, err := codesearch.GetState(idx)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return IndexCfgDTO{}, util.InternalError()
+	}
+	ret.IndexedCommits = state.Branches
+	return ret, nil
+}
+
+// UpdateIndexCfg 更新需要索引的分支
+func UpdateIndexCfg(ctx context.Context, reqDTO UpdateIndexCfgReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanHandleProtectedBranch {
+		return util.UnauthorizedError()
+	}
+	cfg := codeindexmd.CodeIndexCfg{
+		Branches: reqDTO.Branches,
+	}
+	_, b, err := codeindexmd.GetCodeIndex(ctx, repo.RepoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if b {
+		_, err = codeindexmd.UpdateCodeIndexCfg(ctx, codeindexmd.UpdateCodeIndexCfgReqDTO{
+			RepoId: repo.RepoId,
+			Cfg:    cfg,
+		})
+	} else {
+		err = codeindexmd.InsertCodeIndex(ctx, codeindexmd.InsertCodeIndexReqDTO{
+			RepoId: repo.RepoId,
+			Cfg:    cfg,
+		})
+	}
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	TriggerIndex(repo.RepoId)
+	return nil
+}
+
+// SearchRepo 搜索单个仓库 只能搜索默认分支或配置的分支
+func SearchRepo(ctx context.Context, reqDTO SearchRepoReqDTO) (SearchRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return SearchRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return SearchRespDTO{}, err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanAccess {
+		return SearchRespDTO{}, util.UnauthorizedError()
+	}
+	if repo.IsEmpty {
+		return SearchRespDTO{
+			Hits: []SearchHitDTO{},
+		}, nil
+	}
+	branch := reqDTO.Branch
+	if branch == "" {
+		branch = repo.DefaultBranch
+	}
+	branches, err := getIndexBranches(ctx, repo)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return SearchRespDTO{}, util.InternalError()
+	}
+	if contains, _ := listutil.Contains(branches, func(t string) (bool, error) {
+		return t == branch, nil
+	}); !contains {
+		return SearchRespDTO{}, util.InvalidArgsError()
+	}
+	return search(ctx, []repomd.Repo{repo}, map[string]string{repo.RepoId: branch}, reqDTO.SearchOpts)
+}
+
+// SearchProject 搜索项目下有权限访问的仓库的默认分支
+func SearchProject(ctx context.Context, reqDTO SearchProjectReqDTO) (SearchRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return SearchRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	p, b, err := projectmd.GetProjectUserPermDetail(ctx, reqDTO.ProjectId, reqDTO.Operator.Account)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return SearchRespDTO{}, util.InternalError()
+	}
+	if !b {
+		return SearchRespDTO{}, util.UnauthorizedError()
+	}
+	repoList, err := repomd.ListAllRepo(ctx, reqDTO.ProjectId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return SearchRespDTO{}, util.InternalError()
+	}
+	// 项目管理员可访问所有仓库
+	repoList, _ = listutil.Filter(repoList, func(t repomd.Repo) (bool, error) {
+		return !t.IsEmpty && (p.IsAdmin || p.PermDetail.GetRepoPerm(t.RepoId).CanAccess), nil
+	})
+	branches := make(map[string]string, len(repoList))
+	for _, repo := range repoList {
+		branches[repo.RepoId] = repo.DefaultBranch
+	}
+	return search(ctx, repoList, branches, reqDTO.SearchOpts)
+}
+
+func search(ctx context.Context, repoList []repomd.Repo, branches map[string]string, opts SearchOpts) (SearchRespDTO, error) {
+	indexes := make([]bleve.Index, 0, len(repoList))
+	targets := make([]codesearch.Target, 0, len(repoList))
+	repoNames := make(map[string]string, len(repoList))
+	for _, repo := range repoList {
+		idx, err := getIndex(repo.RepoId)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return SearchRespDTO{}, util.InternalError()
+		}
+		indexes = append(indexes, idx)
+		targets = append(targets, codesearch.Target{
+			RepoId: repo.RepoId,
+			Branch: branches[repo.RepoId],
+		})
+		repoNames[repo.RepoId] = repo.Name
+		// 还没建立索引的仓库 如启用搜索前已存在的仓库
+		state, err := codesearch.GetState(idx)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return SearchRespDTO{}, util.InternalError()
+		}
+		if state.Branches[branches[repo.RepoId]] == "" {
+			TriggerIndex(repo.RepoId)
+		}
+	}
+	res, err := codesearch.Search(indexes, codesearch.SearchOpts{
+		Keyword:  opts.Keyword,
+		PathGlob: opts.PathGlob,
+		Language: opts.Language,
+		Targets:  targets,
+		From:     opts.Offset,
+		Size:     opts.Limit,
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return SearchRespDTO{}, util.InternalError()
+	}
+	ret := SearchRespDTO{
+		Total: res.Total,
+	}
+	ret.Hits, _ = listutil.Map(res.Hits, func(t codesearch.Hit) (SearchHitDTO, error) {
+		hit := SearchHitDTO{
+			RepoId:   t.RepoId,
+			RepoName: repoNames[t.RepoId],
+			Branch:   t.Branch,
+			Path:     t.Path,
+			Language: t.Language,
+		}
+		hit.Snippets, _ = listutil.Map(t.Snippets, func(s codesearch.Snippet) (SnippetDTO, error) {
+			return SnippetDTO{
+				LineNo: s.LineNo,
+				Line:   s.Line,
+			}, nil
+		})
+		return hit, nil
+	})
+	return ret, nil
+}
+
+func getPerm(ctx context.Context, repoId string, operator usermd.UserInfo) (repomd.Repo, perm.Detail, error) {
+	repo, b, err := repomd.GetByRepoId(ctx, repoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return repomd.Repo{}, perm.Detail{}, util.InternalError()
+	}
+	if !b {
+		return repomd.Repo{}, perm.Detail{}, util.InvalidArgsError()
+	}
+	p, b, err := projectmd.GetProjectUserPermDetail(ctx, repo.ProjectId, operator.Account)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return repo, perm.Detail{}, util.InternalError()
+	}
+	if !b {
+		return repo, perm.Detail{}, util.UnauthorizedError()
+	}
+	return repo, p.PermDetail, nil
+}
@@ -0,0 +1,85 @@
+package commitstatussrv
+
+import (
+	"strings"
+	"time"
+	"zgit/standalone/modules/model/commitstatusmd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+type CreateCommitStatusReqDTO struct {
+	RepoId      string
+	CommitId    string
+	Context     string
+	State       commitstatusmd.State
+	TargetUrl   string
+	Description string
+	Operator    usermd.UserInfo
+}
+
+func (r *CreateCommitStatusReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateRef(r.CommitId) || strings.HasPrefix(r.CommitId, "-") {
+		return util.InvalidArgsError()
+	}
+	if len(r.Context) == 0 || len(r.Context) > 255 {
+		return util.InvalidArgsError()
+	}
+	if !r.State.IsValid() {
+		return util.InvalidArgsError()
+	}
+	if len(r.TargetUrl) > 1024 {
+		return util.InvalidArgsError()
+	}
+	if r.TargetUrl != "" && !strings.HasPrefix(r.TargetUrl, "http://") && !strings.HasPrefix(r.TargetUrl, "https://") {
+		return util.InvalidArgsError()
+	}
+	if len(r.Description) > 1024 {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type ListCommitStatusReqDTO struct {
+	RepoId   string
+	CommitId string
+	Operator usermd.UserInfo
+}
+
+func (r *ListCommitStatusReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateRef(r.CommitId) || strings.HasPrefix(r.CommitId, "-") {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type CommitStatusDTO struct {
+	CommitId    string
+	Context     string
+	State       commitstatusmd.State
+	TargetUrl   string
+	Description string
+	Creator     string
+	Created     time.Time
+	Updated     time.Time
+}
+
+type ListCommitStatusRespDTO struct {
+	CommitId string
+	// 合并后的状态
+	State      commitstatusmd.State
+	StatusList []CommitStatusDTO
+}
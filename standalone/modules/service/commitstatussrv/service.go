@@ -0,0 +1,143 @@
+package commitstatussrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"path/filepath"
+	"zgit/pkg/git"
+	"zgit/pkg/perm"
+	"zgit/setting"
+	"zgit/standalone/modules/model/commitstatusmd"
+	"zgit/standalone/modules/model/projectmd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+// CreateCommitStatus 上报提交状态 同一提交同一名称的状态会被覆盖
+func CreateCommitStatus(ctx context.Context, reqDTO CreateCommitStatusReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	// 需要推送权限
+	if !p.CanPush {
+		return util.UnauthorizedError()
+	}
+	// 附注标签需解析到其指向的提交
+	commitId, err := git.GetRefCommitId(ctx, filepath.Join(setting.RepoDir(), repo.Path), reqDTO.CommitId+"^{commit}")
+	if err != nil {
+		return util.InvalidArgsError()
+	}
+	b, err := updateCommitStatus(ctx, repo.RepoId, commitId, reqDTO)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if b {
+		return nil
+	}
+	_, err = commitstatusmd.InsertCommitStatus(ctx, commitstatusmd.InsertCommitStatusReqDTO{
+		RepoId:      repo.RepoId,
+		CommitId:    commitId,
+		Context:     reqDTO.Context,
+		State:       reqDTO.State,
+		TargetUrl:   reqDTO.TargetUrl,
+		Description: reqDTO.Description,
+		Creator:     reqDTO.Operator.Account,
+	})
+	if err != nil {
+		// 唯一索引冲突 并发上报时已被其他请求插入 改为更新
+		b, err2 := updateCommitStatus(ctx, repo.RepoId, commitId, reqDTO)
+		if err2 != nil || !b {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+	}
+	return nil
+}
+
+// updateCommitStatus 覆盖已存在的同名状态 不存在返回false
+func updateCommitStatus(ctx context.Context, repoId, commitId string, reqDTO CreateCommitStatusReqDTO) (bool, error) {
+	status, b, err := commitstatusmd.GetCommitStatus(ctx, repoId, commitId, reqDTO.Context)
+	if err != nil || !b {
+		return false, err
+	}
+	_, err = commitstatusmd.UpdateCommitStatus(ctx, commitstatusmd.UpdateCommitStatusReqDTO{
+		Sid:         status.Sid,
+		State:       reqDTO.State,
+		TargetUrl:   reqDTO.TargetUrl,
+		Description: reqDTO.Description,
+		Creator:     reqDTO.Operator.Account,
+	})
+	return err == nil, err
+}
+
+// ListCommitStatus 展示提交状态
+func ListCommitStatus(ctx context.Context, reqDTO ListCommitStatusReqDTO) (ListCommitStatusRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return ListCommitStatusRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return ListCommitStatusRespDTO{}, err
+	}
+	if !p.CanAccess {
+		return ListCommitStatusRespDTO{}, util.UnauthorizedError()
+	}
+	commitId, err := git.GetRefCommitId(ctx, filepath.Join(setting.RepoDir(), repo.Path), reqDTO.CommitId+"^{commit}")
+	if err != nil {
+		return ListCommitStatusRespDTO{}, util.InvalidArgsError()
+	}
+	statusList, err := commitstatusmd.ListCommitStatus(ctx, repo.RepoId, commitId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ListCommitStatusRespDTO{}, util.InternalError()
+	}
+	ret := ListCommitStatusRespDTO{
+		CommitId: commitId,
+		State:    commitstatusmd.CombineState(statusList),
+	}
+	ret.StatusList, _ = listutil.Map(statusList, func(t commitstatusmd.CommitStatus) (CommitStatusDTO, error) {
+		return CommitStatusDTO{
+			CommitId:    t.CommitId,
+			Context:     t.Context,
+			State:       t.State,
+			TargetUrl:   t.TargetUrl,
+			Description: t.Description,
+			Creator:     t.Creator,
+			Created:     t.Created,
+			Updated:     t.Updated,
+		}, nil
+	})
+	return ret, nil
+}
+
+func getPerm(ctx context.Context, repoId string, operator usermd.UserInfo) (repomd.Repo, perm.RepoPerm, error) {
+	repo, b, err := repomd.GetByRepoId(ctx, repoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return repomd.Repo{}, perm.RepoPerm{}, util.InternalError()
+	}
+	if !b {
+		return repomd.Repo{}, perm.RepoPerm{}, util.InvalidArgsError()
+	}
+	p, b, err := projectmd.GetProjectUserPermDetail(ctx, repo.ProjectId, operator.Account)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return repo, perm.RepoPerm{}, util.InternalError()
+	}
+	if !b {
+		return repo, perm.RepoPerm{}, util.UnauthorizedError()
+	}
+	return repo, p.PermDetail.GetRepoPerm(repoId), nil
+}
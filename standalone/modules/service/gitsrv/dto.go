@@ -1,11 +1,16 @@
 package gitsrv
 
 import (
+	"io"
 	"zgit/pkg/perm"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
 )
 
 const (
 	lfsAuthenticateVerb = "git-lfs-authenticate"
+	wikiSuffix          = ".wiki"
 )
 
 var (
@@ -18,3 +23,54 @@ var (
 		lfsAuthenticateVerb:  perm.AccessModeNone,
 	}
 )
+
+var (
+	allowedHttpServices = map[string]perm.AccessMode{
+		"git-upload-pack":  perm.AccessModeRead,
+		"git-receive-pack": perm.AccessModeWrite,
+	}
+)
+
+type CheckHttpAccessReqDTO struct {
+	RepoPath string
+	Service  string
+	Operator usermd.UserInfo
+}
+
+func (r *CheckHttpAccessReqDTO) IsValid() error {
+	if r.RepoPath == "" {
+		return util.InvalidArgsError()
+	}
+	if _, b := allowedHttpServices[r.Service]; !b {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type HttpServiceReqDTO struct {
+	Repo        repomd.Repo
+	Service     string
+	GitProtocol string
+	Stdin       io.Reader
+	Stdout      io.Writer
+	Operator    usermd.UserInfo
+}
+
+func (r *HttpServiceReqDTO) IsValid() error {
+	if r.Repo.Path == "" {
+		return util.InvalidArgsError()
+	}
+	if _, b := allowedHttpServices[r.Service]; !b {
+		return util.InvalidArgsError()
+	}
+	if r.Stdout == nil {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package gitsrv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/LeeZXin/zsf/logger"
+	"os"
+	"os/exec"
+	"strings"
+	"zgit/pkg/git"
+	"zgit/pkg/git/command"
+	"zgit/pkg/git/process"
+	"zgit/setting"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/util"
+)
+
+// CheckHttpAccess 校验http协议访问权限
+func CheckHttpAccess(ctx context.Context, reqDTO CheckHttpAccessReqDTO) (repomd.Repo, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return repomd.Repo{}, err
+	}
+	return checkAccessMode(ctx, reqDTO.Operator, reqDTO.RepoPath, allowedHttpServices[reqDTO.Service])
+}
+
+// HandleHttpInfoRefs 处理smart http info/refs请求
+func HandleHttpInfoRefs(ctx context.Context, reqDTO HttpServiceReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	// protocol v2不需要service头
+	if !strings.Contains(reqDTO.GitProtocol, "version=2") {
+		if _, err := reqDTO.Stdout.Write(packetWrite("# service=" + reqDTO.Service + "\n")); err != nil {
+			return err
+		}
+		if _, err := reqDTO.Stdout.Write([]byte("0000")); err != nil {
+			return err
+		}
+	}
+	return runHttpService(ctx, reqDTO, true)
+}
+
+// HandleHttpServiceRpc 处理smart http upload-pack/receive-pack请求
+func HandleHttpServiceRpc(ctx context.Context, reqDTO HttpServiceReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	return runHttpService(ctx, reqDTO, false)
+}
+
+func runHttpService(ctx context.Context, reqDTO HttpServiceReqDTO, advertiseRefs bool) error {
+	args := []string{strings.TrimPrefix(reqDTO.Service, "git-"), "--stateless-rpc"}
+	if advertiseRefs {
+		args = append(args, "--advertise-refs")
+	}
+	args = append(args, reqDTO.Repo.Path)
+	gitCmd := exec.CommandContext(ctx, setting.GitExecutablePath(), args...)
+	process.SetSysProcAttribute(gitCmd)
+	stderr := new(bytes.Buffer)
+	gitCmd.Dir = setting.RepoDir()
+	gitCmd.Stdin = reqDTO.Stdin
+	gitCmd.Stdout = reqDTO.Stdout
+	gitCmd.Stderr = stderr
+	gitCmd.Env = append(gitCmd.Env, os.Environ()...)
+	gitCmd.Env = append(gitCmd.Env,
+		util.JoinFields(
+			git.EnvRepoId, reqDTO.Repo.RepoId,
+			git.EnvPusherId, reqDTO.Operator.Account,
+			git.EnvAppUrl, setting.AppUrl(),
+			git.EnvHookToken, setting.HookToken(),
+		)...,
+	)
+	if reqDTO.GitProtocol != "" {
+		gitCmd.Env = append(gitCmd.Env, "GIT_PROTOCOL="+reqDTO.GitProtocol)
+	}
+	gitCmd.Env = append(gitCmd.Env, command.CommonEnvs()...)
+	if err := gitCmd.Run(); err != nil {
+		logger.Logger.WithContext(ctx).Errorf("%s repo: %s err: %v stderr: %s", reqDTO.Service, reqDTO.Repo.Path, err, stderr.String())
+		return err
+	}
+	return nil
+}
+
+func packetWrite(str string) []byte {
+	return []byte(fmt.Sprintf("%04x%s", len(str)+4, str))
+}
@@ -13,6 +13,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 	"zgit/pkg/git"
@@ -25,6 +26,7 @@ import (
 	"zgit/standalone/modules/model/projectmd"
 	"zgit/standalone/modules/model/repomd"
 	"zgit/standalone/modules/model/usermd"
+	"zgit/standalone/modules/service/wikisrv"
 	"zgit/util"
 )
 
@@ -53,9 +55,11 @@ func HandleSshCommand(ctx context.Context, cmd string, keyUser usermd.UserInfo,
 func HandleGitCommand(ctx context.Context, operator usermd.UserInfo, words []string, session ssh.Session) error {
 	verb := words[0]
 	repoPath := strings.TrimPrefix(words[1], "/")
+	// wiki仓库 如xxx.wiki 权限同xxx.git
+	isWiki := strings.HasSuffix(repoPath, wikiSuffix)
 	var lfsVerb string
 	if verb == lfsAuthenticateVerb {
-		if !setting.LfsEnabled() {
+		if !setting.LfsEnabled() || isWiki {
 			return errors.New(i18n.GetByKey(i18n.LfsNotSupported))
 		}
 		if len(words) > 2 {
@@ -77,10 +81,22 @@ func HandleGitCommand(ctx context.Context, operator usermd.UserInfo, words []str
 			return errors.New(i18n.GetByKey(i18n.SshCmdNotSupported))
 		}
 	}
-	repo, err := checkAccessMode(ctx, operator, repoPath, accessMode)
+	lookupPath := repoPath
+	if isWiki {
+		lookupPath = strings.TrimSuffix(repoPath, wikiSuffix) + ".git"
+	}
+	repo, err := checkAccessMode(ctx, operator, lookupPath, accessMode)
 	if err != nil {
 		return err
 	}
+	if isWiki {
+		// 首次访问时创建
+		if err = wikisrv.EnsureWiki(ctx, repo); err != nil {
+			logger.Logger.Error(err)
+			return util.InternalError()
+		}
+		repoPath = repo.GetWikiPath()
+	}
 	// LFS token authentication
 	if verb == lfsAuthenticateVerb {
 		url := fmt.Sprintf("%s/%s/info/lfs", setting.AppUrl(), repoPath)
@@ -136,6 +152,7 @@ func HandleGitCommand(ctx context.Context, operator usermd.UserInfo, words []str
 			git.EnvPusherId, operator.Account,
 			git.EnvAppUrl, setting.AppUrl(),
 			git.EnvHookToken, setting.HookToken(),
+			git.EnvIsWiki, strconv.FormatBool(isWiki),
 		)...,
 	)
 	gitCmd.Env = append(gitCmd.Env, command.CommonEnvs()...)
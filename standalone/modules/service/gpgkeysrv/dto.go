@@ -0,0 +1,76 @@
+package gpgkeysrv
+
+import (
+	"strings"
+	"time"
+	"zgit/standalone/modules/model/gpgkeymd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+type InsertGpgKeyReqDTO struct {
+	Name          string
+	PubKeyContent string
+	Operator      usermd.UserInfo
+}
+
+func (r *InsertGpgKeyReqDTO) IsValid() error {
+	if len(r.Name) == 0 || len(r.Name) > 128 {
+		return util.InvalidArgsError()
+	}
+	r.PubKeyContent = strings.TrimSpace(r.PubKeyContent)
+	if r.PubKeyContent == "" || len(r.PubKeyContent) > 65535 {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type DeleteGpgKeyReqDTO struct {
+	KeyId    string
+	Operator usermd.UserInfo
+}
+
+func (r *DeleteGpgKeyReqDTO) IsValid() error {
+	if !gpgkeymd.IsKeyIdValid(r.KeyId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type ListGpgKeyReqDTO struct {
+	Offset   int64
+	Limit    int
+	Operator usermd.UserInfo
+}
+
+func (r *ListGpgKeyReqDTO) IsValid() error {
+	if r.Offset < 0 {
+		return util.InvalidArgsError()
+	}
+	if r.Limit <= 0 || r.Limit > 1000 {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type ListGpgKeyRespDTO struct {
+	Cursor  int64
+	KeyList []GpgKeyDTO
+}
+
+type GpgKeyDTO struct {
+	KeyId        string
+	Name         string
+	PrimaryKeyId string
+	SubKeyIdList []string
+	Expiry       time.Time
+}
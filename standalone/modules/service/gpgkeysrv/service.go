@@ -0,0 +1,120 @@
+package gpgkeysrv
+
+import (
+	"context"
+	"fmt"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"zgit/pkg/apicode"
+	"zgit/pkg/git/signature"
+	"zgit/pkg/i18n"
+	"zgit/standalone/modules/model/gpgkeymd"
+	"zgit/util"
+)
+
+// FormatGpgKeyId 与签名中的issuer key id格式保持一致
+func FormatGpgKeyId(keyId uint64) string {
+	return fmt.Sprintf("%X", keyId)
+}
+
+func InsertGpgKey(ctx context.Context, reqDTO InsertGpgKeyReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	entityList, err := signature.ConvertArmoredGPGKeyString(reqDTO.PubKeyContent)
+	// 只支持单个公钥
+	if err != nil || len(entityList) != 1 {
+		return util.NewBizErr(apicode.InvalidArgsCode, i18n.GpgKeyFormatError)
+	}
+	entity := entityList[0]
+	primaryKeyId := FormatGpgKeyId(entity.PrimaryKey.KeyId)
+	subKeyIdList := make([]string, 0, len(entity.Subkeys))
+	for _, subKey := range entity.Subkeys {
+		if subKey.PublicKey == nil {
+			continue
+		}
+		subKeyIdList = append(subKeyIdList, FormatGpgKeyId(subKey.PublicKey.KeyId))
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	_, b, err := gpgkeymd.SearchByGpgKeyId(ctx, primaryKeyId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if b {
+		return util.NewBizErr(apicode.InvalidArgsCode, i18n.GpgKeyAlreadyExists)
+	}
+	_, err = gpgkeymd.InsertGpgKey(ctx, gpgkeymd.InsertGpgKeyReqDTO{
+		Account:      reqDTO.Operator.Account,
+		Name:         reqDTO.Name,
+		PrimaryKeyId: primaryKeyId,
+		SubKeyIdList: subKeyIdList,
+		Content:      reqDTO.PubKeyContent,
+		Expiry:       signature.GetGPGKeyExpiryTime(entity),
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
+func DeleteGpgKey(ctx context.Context, reqDTO DeleteGpgKeyReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	gpgKey, b, err := gpgkeymd.GetByKeyId(ctx, reqDTO.KeyId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if !b {
+		return util.InvalidArgsError()
+	}
+	// 只有拥有人才能删掉公钥
+	if gpgKey.Account != reqDTO.Operator.Account {
+		return util.InvalidArgsError()
+	}
+	_, err = gpgkeymd.DeleteGpgKey(ctx, gpgKey)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
+func ListGpgKey(ctx context.Context, reqDTO ListGpgKeyReqDTO) (ListGpgKeyRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return ListGpgKeyRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	// 展示登录人的gpg公钥列表
+	keyList, err := gpgkeymd.ListGpgKey(ctx, gpgkeymd.ListGpgKeyReqDTO{
+		Offset:  reqDTO.Offset,
+		Limit:   reqDTO.Limit,
+		Account: reqDTO.Operator.Account,
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ListGpgKeyRespDTO{}, util.InternalError()
+	}
+	ret := ListGpgKeyRespDTO{}
+	ret.KeyList, _ = listutil.Map(keyList, func(t gpgkeymd.GpgKey) (GpgKeyDTO, error) {
+		return GpgKeyDTO{
+			KeyId:        t.KeyId,
+			Name:         t.Name,
+			PrimaryKeyId: t.PrimaryKeyId,
+			SubKeyIdList: t.GetSubKeyIdList(),
+			Expiry:       t.Expiry,
+		}, nil
+	})
+	if len(keyList) > 0 {
+		ret.Cursor = keyList[len(keyList)-1].Id
+	}
+	return ret, nil
+}
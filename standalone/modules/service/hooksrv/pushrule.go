@@ -0,0 +1,147 @@
+package hooksrv
+
+import (
+	"context"
+	"github.com/IGLOU-EU/go-wildcard/v2"
+	"github.com/LeeZXin/zsf/logger"
+	"path"
+	"regexp"
+	"strings"
+	"zgit/pkg/apicode"
+	"zgit/pkg/git"
+	"zgit/pkg/hook"
+	"zgit/pkg/i18n"
+	"zgit/standalone/modules/model/pushrulemd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+// checkPushRule 检查仓库级别和项目级别的推送规则 两者同时生效
+func checkPushRule(ctx context.Context, repo repomd.Repo, repoPath string, opts hook.Opts) error {
+	// 来自合并请求的提交由服务端生成 源分支推送时已检查过
+	if opts.PrId != "" {
+		return nil
+	}
+	cfgList := make([]pushrulemd.PushRuleCfg, 0, 2)
+	for _, repoId := range []string{repo.RepoId, ""} {
+		rule, b, err := pushrulemd.GetPushRule(ctx, repo.ProjectId, repoId)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+		if b {
+			cfgList = append(cfgList, rule.Cfg)
+		}
+	}
+	if len(cfgList) == 0 {
+		return nil
+	}
+	var (
+		checkEmail, checkBlobSize, checkPath bool
+		msgPatterns                          []*regexp.Regexp
+	)
+	for _, cfg := range cfgList {
+		if cfg.CommitMessagePattern != "" {
+			pattern, err := regexp.Compile(cfg.CommitMessagePattern)
+			if err != nil {
+				logger.Logger.WithContext(ctx).Error(err)
+				continue
+			}
+			msgPatterns = append(msgPatterns, pattern)
+		}
+		checkEmail = checkEmail || cfg.CheckCommitterEmail
+		checkBlobSize = checkBlobSize || cfg.MaxBlobSize > 0
+		checkPath = checkPath || len(cfg.ForbiddenPathList) > 0
+	}
+	var pusherEmail string
+	if checkEmail {
+		pusher, b, err := usermd.GetByAccount(ctx, opts.PusherId)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+		if !b {
+			return util.InvalidArgsError()
+		}
+		pusherEmail = pusher.Email
+	}
+	env := git.QuarantineEnv{
+		ObjectDirectory:              opts.ObjectDirectory,
+		AlternativeObjectDirectories: opts.AlternativeObjectDirectories,
+		QuarantinePath:               opts.QuarantinePath,
+	}
+	for _, info := range opts.RevInfoList {
+		// 删除不做检查
+		if info.NewCommitId == git.ZeroCommitId {
+			continue
+		}
+		// 新建分支检查分支名称
+		if git.RefName(info.RefName).IsBranch() && info.OldCommitId == git.ZeroCommitId {
+			branch := strings.TrimPrefix(info.RefName, git.BranchPrefix)
+			for _, cfg := range cfgList {
+				if len(cfg.AllowedNewBranchList) > 0 && !matchAnyPattern(cfg.AllowedNewBranchList, branch) {
+					return util.NewBizErr(apicode.PushRuleRejectedCode, i18n.PushRuleBranchNameNotAllowedWarnFormat, branch)
+				}
+			}
+		}
+		if len(msgPatterns) == 0 && !checkEmail && !checkBlobSize && !checkPath {
+			continue
+		}
+		commitList, err := git.ListPushCommits(ctx, repoPath, info.OldCommitId, info.NewCommitId, env)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+		commitIdList := make([]string, 0, len(commitList))
+		for _, commit := range commitList {
+			for _, pattern := range msgPatterns {
+				if !pattern.MatchString(strings.TrimSpace(commit.CommitMsg)) {
+					return util.NewBizErr(apicode.PushRuleRejectedCode, i18n.PushRuleCommitMessageNotMatchWarnFormat, commit.Id)
+				}
+			}
+			if checkEmail && (!strings.EqualFold(commit.Author.Email, pusherEmail) || !strings.EqualFold(commit.Committer.Email, pusherEmail)) {
+				return util.NewBizErr(apicode.PushRuleRejectedCode, i18n.PushRuleCommitEmailNotMatchWarnFormat, commit.Id)
+			}
+			commitIdList = append(commitIdList, commit.Id)
+		}
+		if checkPath {
+			fileList, err := git.ListPushChangedFiles(ctx, repoPath, commitIdList, env)
+			if err != nil {
+				logger.Logger.WithContext(ctx).Error(err)
+				return util.InternalError()
+			}
+			for _, filePath := range fileList {
+				for _, cfg := range cfgList {
+					if matchAnyPattern(cfg.ForbiddenPathList, filePath) || matchAnyPattern(cfg.ForbiddenPathList, path.Base(filePath)) {
+						return util.NewBizErr(apicode.PushRuleRejectedCode, i18n.PushRuleForbiddenPathWarnFormat, filePath)
+					}
+				}
+			}
+		}
+		if checkBlobSize {
+			blobList, err := git.ListPushBlobs(ctx, repoPath, info.OldCommitId, info.NewCommitId, env)
+			if err != nil {
+				logger.Logger.WithContext(ctx).Error(err)
+				return util.InternalError()
+			}
+			for _, blob := range blobList {
+				for _, cfg := range cfgList {
+					if cfg.MaxBlobSize > 0 && blob.Size > cfg.MaxBlobSize {
+						return util.NewBizErr(apicode.PushRuleRejectedCode, i18n.PushRuleBlobSizeExceedWarnFormat, blob.Path)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func matchAnyPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if wildcard.Match(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package hooksrv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/LeeZXin/zsf/logger"
+	"zgit/pkg/apicode"
+	"zgit/pkg/git"
+	"zgit/pkg/hook"
+	"zgit/pkg/i18n"
+	"zgit/pkg/secretscan"
+	"zgit/setting"
+	"zgit/standalone/modules/model/projectmd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+var errSecretFound = errors.New("secret found")
+
+// checkSecret 扫描推送提交中新增的代码行 发现疑似密钥则拒绝推送
+func checkSecret(ctx context.Context, repo repomd.Repo, repoPath string, opts hook.Opts) error {
+	// 来自合并请求的提交在源分支推送时已扫描过
+	if !setting.SecretScanEnabled() || opts.PrId != "" {
+		return nil
+	}
+	for _, option := range opts.PushOptions {
+		if option != hook.PushOptionSkipSecretScan {
+			continue
+		}
+		isAdmin, err := isPusherAdmin(ctx, repo, opts.PusherId)
+		if err != nil {
+			return err
+		}
+		if !isAdmin {
+			return util.NewBizErr(apicode.SecretDetectedCode, i18n.SecretScanSkipNotAllowed)
+		}
+		logger.Logger.WithContext(ctx).Warnf("secret scan skipped by %s repoId: %s", opts.PusherId, repo.RepoId)
+		return nil
+	}
+	env := git.QuarantineEnv{
+		ObjectDirectory:              opts.ObjectDirectory,
+		AlternativeObjectDirectories: opts.AlternativeObjectDirectories,
+		QuarantinePath:               opts.QuarantinePath,
+	}
+	for _, info := range opts.RevInfoList {
+		if info.NewCommitId == git.ZeroCommitId {
+			continue
+		}
+		commitList, err := git.ListPushCommits(ctx, repoPath, info.OldCommitId, info.NewCommitId, env)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+		if len(commitList) == 0 {
+			continue
+		}
+		commitIdList := make([]string, 0, len(commitList))
+		for _, commit := range commitList {
+			commitIdList = append(commitIdList, commit.Id)
+		}
+		// 白名单以推送前的版本为准 防止推送者在同一次推送中修改白名单绕过扫描 新建分支时以默认分支为准
+		allowlistRev := info.OldCommitId
+		if allowlistRev == git.ZeroCommitId {
+			allowlistRev = git.BranchPrefix + repo.DefaultBranch
+		}
+		var allowlist *secretscan.Allowlist
+		content, b, err := git.GetFileContentWithEnv(ctx, repoPath, allowlistRev, secretscan.AllowlistFilePath, env)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+		if b {
+			allowlist = secretscan.ParseAllowlist(content)
+		}
+		scanner := secretscan.NewScanner(secretscan.DefaultRules, allowlist)
+		var finding secretscan.Finding
+		err = git.RangePushAddedLines(ctx, repoPath, commitIdList, env, func(filePath string, lineNo int, line string) error {
+			if findings := scanner.ScanLine(filePath, lineNo, line); len(findings) > 0 {
+				finding = findings[0]
+				return errSecretFound
+			}
+			return nil
+		})
+		if errors.Is(err, errSecretFound) {
+			return util.NewBizErr(apicode.SecretDetectedCode, i18n.SecretScanDetectedWarnFormat,
+				fmt.Sprintf("%s:%d %s %s", finding.FilePath, finding.LineNo, finding.RuleName, secretscan.MaskSecret(finding.Secret)))
+		}
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+	}
+	return nil
+}
+
+// isPusherAdmin 系统管理员或项目管理员
+func isPusherAdmin(ctx context.Context, repo repomd.Repo, account string) (bool, error) {
+	user, b, err := usermd.GetByAccount(ctx, account)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return false, util.InternalError()
+	}
+	if !b {
+		return false, nil
+	}
+	if user.IsAdmin {
+		return true, nil
+	}
+	detail, b, err := projectmd.GetProjectUserPermDetail(ctx, repo.ProjectId, account)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return false, util.InternalError()
+	}
+	return b && detail.IsAdmin, nil
+}
@@ -14,7 +14,15 @@ import (
 	"zgit/pkg/i18n"
 	"zgit/setting"
 	"zgit/standalone/modules/model/branchmd"
+	"zgit/standalone/modules/model/mirrormd"
 	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/tagmd"
+	"zgit/standalone/modules/service/codesearchsrv"
+	"zgit/standalone/modules/service/mirrorsrv"
+	"zgit/standalone/modules/service/pullrequestsrv"
+	"zgit/standalone/modules/service/quotasrv"
+	"zgit/standalone/modules/service/webhooksrv"
+	"zgit/standalone/modules/service/wikisrv"
 	"zgit/util"
 )
 
@@ -30,8 +38,35 @@ func PreReceive(ctx context.Context, opts hook.Opts) error {
 	if !b {
 		return util.InvalidArgsError()
 	}
+	// 检查仓库配额 隔离区大小即本次推送的数据大小 wiki大小同样计入仓库大小
+	if opts.QuarantinePath != "" {
+		incrSize, err := git.GetRepoSize(opts.QuarantinePath)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+		if err = quotasrv.CheckGitQuota(ctx, repo, incrSize); err != nil {
+			return err
+		}
+	}
+	// wiki不受分支保护和推送规则限制
+	if opts.IsWiki {
+		return nil
+	}
+	// 镜像仓库只能从远端同步
+	isMirror, err := mirrormd.ExistsPullMirror(ctx, repo.RepoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if isMirror {
+		return util.NewBizErr(apicode.MirrorRepoPushForbiddenCode, i18n.RepoMirrorNotAllowPush)
+	}
 	repoPath := filepath.Join(setting.RepoDir(), repo.Path)
-	var pbList []branchmd.ProtectedBranchDTO
+	var (
+		pbList []branchmd.ProtectedBranchDTO
+		ptList []tagmd.ProtectedTagDTO
+	)
 	for _, info := range opts.RevInfoList {
 		name := info.RefName
 		// 是分支
@@ -63,16 +98,13 @@ func PreReceive(ctx context.Context, opts hook.Opts) error {
 					if info.NewCommitId == git.ZeroCommitId {
 						return util.NewBizErr(apicode.ForcePushForbiddenCode, i18n.ProtectedBranchNotAllowDelete)
 					}
+					env := git.QuarantineEnv{
+						ObjectDirectory:              opts.ObjectDirectory,
+						AlternativeObjectDirectories: opts.AlternativeObjectDirectories,
+						QuarantinePath:               opts.QuarantinePath,
+					}
 					// 检查push -f
-					isForcePush, err := git.DetectForcePush(ctx,
-						repoPath,
-						info.OldCommitId,
-						info.NewCommitId,
-						git.DetectForcePushEnv{
-							ObjectDirectory:              opts.ObjectDirectory,
-							AlternativeObjectDirectories: opts.AlternativeObjectDirectories,
-							QuarantinePath:               opts.QuarantinePath,
-						})
+					isForcePush, err := git.DetectForcePush(ctx, repoPath, info.OldCommitId, info.NewCommitId, env)
 					if err != nil {
 						logger.Logger.WithContext(ctx).Error(err)
 						return util.InternalError()
@@ -81,14 +113,92 @@ func PreReceive(ctx context.Context, opts hook.Opts) error {
 						// 禁止push -f
 						return util.NewBizErr(apicode.ForcePushForbiddenCode, i18n.ProtectedBranchNotAllowForcePush)
 					}
+					// 检查提交签名
+					if pb.Cfg.RequireSignedCommits {
+						if err = checkCommitsSigned(ctx, repoPath, info, opts, env); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		} else if strings.HasPrefix(name, git.TagPrefix) {
+			// 是标签 检查是否是保护标签
+			if ptList == nil {
+				// 懒加载一下
+				ptList, err = tagmd.ListProtectedTag(ctx, opts.RepoId)
+				if err != nil {
+					logger.Logger.WithContext(ctx).Error(err)
+					return util.InternalError()
+				}
+			}
+			name = strings.TrimPrefix(name, git.TagPrefix)
+			for _, pt := range ptList {
+				if !wildcard.Match(pt.Tag, name) {
+					continue
+				}
+				// 不允许删除保护标签
+				if info.NewCommitId == git.ZeroCommitId {
+					return util.NewBizErr(apicode.ProtectedTagForbiddenCode, i18n.ProtectedTagNotAllowDelete)
+				}
+				// 不允许修改保护标签指向
+				if info.OldCommitId != git.ZeroCommitId {
+					return util.NewBizErr(apicode.ProtectedTagForbiddenCode, i18n.ProtectedTagNotAllowUpdate)
+				}
+				// 只有创建名单里面才能创建
+				if len(pt.Cfg.CreatorList) > 0 {
+					contains, _ := listutil.Contains(pt.Cfg.CreatorList, func(account string) (bool, error) {
+						return account == opts.PusherId, nil
+					})
+					if !contains {
+						return util.NewBizErr(apicode.ProtectedTagForbiddenCode, i18n.ProtectedTagNotAllowCreate)
+					}
 				}
 			}
 		}
 	}
-	return nil
+	// 检查推送规则
+	if err = checkPushRule(ctx, repo, repoPath, opts); err != nil {
+		return err
+	}
+	// 扫描密钥
+	return checkSecret(ctx, repo, repoPath, opts)
 }
 
 func PostReceive(ctx context.Context, opts hook.Opts) error {
 	logger.Logger.WithContext(ctx).Info("post-receive", opts)
+	if opts.IsWiki {
+		if err := wikisrv.UpdateWikiSize(ctx, opts.RepoId); err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+		}
+		return nil
+	}
+	// 更新仓库大小
+	if err := updateRepoGitSize(ctx, opts.RepoId); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+	}
+	// 同步合并请求 失败不影响推送结果
+	if err := pullrequestsrv.SyncPullRequestOnPush(ctx, opts); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+	}
+	// 触发webhook
+	webhooksrv.TriggerPushEvent(ctx, opts)
+	// 同步推送镜像
+	mirrorsrv.TriggerPushMirrors(ctx, opts.RepoId)
+	// 更新代码搜索索引
+	codesearchsrv.TriggerIndex(opts.RepoId)
 	return nil
 }
+
+func updateRepoGitSize(ctx context.Context, repoId string) error {
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, b, err := repomd.GetByRepoId(ctx, repoId)
+	if err != nil || !b {
+		return err
+	}
+	size, err := git.GetRepoSize(filepath.Join(setting.RepoDir(), repo.Path))
+	if err != nil {
+		return err
+	}
+	return repomd.UpdateTotalAndGitSize(ctx, repo.RepoId, repo.LfsSize+repo.WikiSize+size, size)
+}
@@ -0,0 +1,37 @@
+package hooksrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf/logger"
+	"zgit/pkg/apicode"
+	"zgit/pkg/git"
+	"zgit/pkg/hook"
+	"zgit/pkg/i18n"
+	"zgit/standalone/modules/service/signaturesrv"
+	"zgit/util"
+)
+
+// checkCommitsSigned 检查推送的提交是否都有已登记公钥的有效签名
+// 合并请求推送的提交包含合并进来的全部提交 服务端生成的合并或压缩提交需由服务端密钥签名
+func checkCommitsSigned(ctx context.Context, repoPath string, info hook.RevInfo, opts hook.Opts, env git.QuarantineEnv) error {
+	commitList, err := git.ListPushCommits(ctx, repoPath, info.OldCommitId, info.NewCommitId, env)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	for _, commit := range commitList {
+		sig, err := signaturesrv.VerifyCommitSignature(ctx, commit)
+		if err != nil {
+			return err
+		}
+		if sig.IsVerified() {
+			continue
+		}
+		if opts.PrId != "" && commit.Id == info.NewCommitId &&
+			git.VerifyCommitSignedBy(ctx, repoPath, commit, git.GetGpnKeyId(repoPath, git.MergeScene), env) {
+			continue
+		}
+		return util.NewBizErr(apicode.UnsignedCommitForbiddenCode, i18n.ProtectedBranchUnsignedCommitWarnFormat, commit.Id)
+	}
+	return nil
+}
@@ -15,6 +15,7 @@ import (
 	"zgit/standalone/modules/model/projectmd"
 	"zgit/standalone/modules/model/repomd"
 	"zgit/standalone/modules/model/usermd"
+	"zgit/standalone/modules/service/quotasrv"
 	"zgit/util"
 )
 
@@ -177,8 +178,52 @@ func Upload(ctx context.Context, reqDTO UploadReqDTO) error {
 	if !p.GetRepoPerm(reqDTO.Repo.RepoId).CanPush {
 		return util.UnauthorizedError()
 	}
-	_, err = lfs.StorageImpl.Save(ctx, convertPointerPath(reqDTO.Repo.Path, reqDTO.Oid), reqDTO.Body)
-	return err
+	pointerPath := convertPointerPath(reqDTO.Repo.Path, reqDTO.Oid)
+	// lfs对象按oid存储 已存在时无需重复写入
+	if exists, _ := lfs.StorageImpl.Exists(ctx, pointerPath); exists {
+		return nil
+	}
+	// 检查是否超过单个lfs文件配置大小
+	if reqDTO.Repo.Cfg.SingleLfsFileLimitSize > 0 && reqDTO.Size > reqDTO.Repo.Cfg.SingleLfsFileLimitSize {
+		return fmt.Errorf(i18n.GetByKey(i18n.LfsExceedSingleFileLimitSize),
+			reqDTO.Oid,
+			util.VolumeReadable(reqDTO.Size),
+			util.VolumeReadable(reqDTO.Repo.Cfg.SingleLfsFileLimitSize),
+		)
+	}
+	if err = checkLfsQuota(ctx, reqDTO.Repo.RepoId, reqDTO.Size); err != nil {
+		return err
+	}
+	// 大小限制基于客户端声明的大小 多读一个字节 判断实际大小是否与声明一致
+	size, err := lfs.StorageImpl.Save(ctx, pointerPath, io.LimitReader(reqDTO.Body, reqDTO.Size+1))
+	if err != nil {
+		return err
+	}
+	if size != reqDTO.Size {
+		lfs.StorageImpl.Delete(ctx, pointerPath)
+		return util.InvalidArgsError()
+	}
+	// 更新仓库lfs大小
+	if err = repomd.IncrLfsSize(ctx, reqDTO.Repo.RepoId, size); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+	}
+	return nil
+}
+
+// checkLfsQuota 仓库信息可能来自缓存 需从数据库获取最新的大小
+func checkLfsQuota(ctx context.Context, repoId string, incrSize int64) error {
+	if incrSize <= 0 {
+		return nil
+	}
+	repo, b, err := repomd.GetByRepoId(ctx, repoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if !b {
+		return util.InvalidArgsError()
+	}
+	return quotasrv.CheckLfsQuota(ctx, repo, incrSize)
 }
 
 func convertPointerPath(repoPath, oid string) string {
@@ -192,7 +237,11 @@ func Batch(ctx context.Context, reqDTO BatchReqDTO) (BatchRespDTO, error) {
 	if err := reqDTO.IsValid(); err != nil {
 		return BatchRespDTO{}, err
 	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
 	ret := make([]ObjectDTO, 0, len(reqDTO.Objects))
+	// 本次需要上传的lfs大小
+	var incrSize int64
 	for _, object := range reqDTO.Objects {
 		meta, b, err := lfsmd.GetMetaObjectByOid(ctx, object.Oid)
 		if err != nil {
@@ -216,6 +265,9 @@ func Batch(ctx context.Context, reqDTO BatchReqDTO) (BatchRespDTO, error) {
 						util.VolumeReadable(reqDTO.Repo.Cfg.SingleLfsFileLimitSize),
 					)
 			}
+			if !exists {
+				incrSize += object.Size
+			}
 			if exists && !b {
 				if err = lfsmd.InsertMetaObject(lfsmd.MetaObject{
 					RepoId: reqDTO.Repo.Path,
@@ -241,6 +293,10 @@ func Batch(ctx context.Context, reqDTO BatchReqDTO) (BatchRespDTO, error) {
 			}
 		}
 	}
+	// 检查仓库和企业lfs配额
+	if err := checkLfsQuota(ctx, reqDTO.Repo.RepoId, incrSize); err != nil {
+		return BatchRespDTO{}, err
+	}
 	return BatchRespDTO{
 		ObjectList: ret,
 	}, nil
@@ -0,0 +1,210 @@
+package mirrorsrv
+
+import (
+	"zgit/standalone/modules/model/mirrormd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+type GetPullMirrorReqDTO struct {
+	RepoId   string
+	Operator usermd.UserInfo
+}
+
+func (r *GetPullMirrorReqDTO) IsValid() error {
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type UpdatePullMirrorReqDTO struct {
+	RepoId    string
+	RemoteUrl string
+	Username  string
+	// 为空时保留原密码
+	Password     string
+	SyncInterval int
+	Operator     usermd.UserInfo
+}
+
+func (r *UpdatePullMirrorReqDTO) IsValid() error {
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !mirrormd.IsRemoteUrlValid(r.RemoteUrl) {
+		return util.InvalidArgsError()
+	}
+	if len(r.Username) > 255 || len(r.Password) > 1024 {
+		return util.InvalidArgsError()
+	}
+	if !mirrormd.IsSyncIntervalValid(r.SyncInterval) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type SyncPullMirrorReqDTO struct {
+	RepoId   string
+	Operator usermd.UserInfo
+}
+
+func (r *SyncPullMirrorReqDTO) IsValid() error {
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type DeletePullMirrorReqDTO struct {
+	RepoId   string
+	Operator usermd.UserInfo
+}
+
+func (r *DeletePullMirrorReqDTO) IsValid() error {
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type PullMirrorDTO struct {
+	RepoId       string
+	RemoteUrl    string
+	Username     string
+	HasPassword  bool
+	SyncInterval int
+	LastSyncTime int64
+	LastSyncErr  string
+	NextSyncTime int64
+	// 是否正在同步
+	IsSyncing bool
+}
+
+type InsertPushMirrorReqDTO struct {
+	RepoId       string
+	RemoteUrl    string
+	Username     string
+	Password     string
+	SyncInterval int
+	Operator     usermd.UserInfo
+}
+
+func (r *InsertPushMirrorReqDTO) IsValid() error {
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !mirrormd.IsRemoteUrlValid(r.RemoteUrl) {
+		return util.InvalidArgsError()
+	}
+	if len(r.Username) > 255 || len(r.Password) > 1024 {
+		return util.InvalidArgsError()
+	}
+	if !mirrormd.IsSyncIntervalValid(r.SyncInterval) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type UpdatePushMirrorReqDTO struct {
+	MirrorId  string
+	RemoteUrl string
+	Username  string
+	// 为空时保留原密码
+	Password     string
+	SyncInterval int
+	Operator     usermd.UserInfo
+}
+
+func (r *UpdatePushMirrorReqDTO) IsValid() error {
+	if !mirrormd.IsMirrorIdValid(r.MirrorId) {
+		return util.InvalidArgsError()
+	}
+	if !mirrormd.IsRemoteUrlValid(r.RemoteUrl) {
+		return util.InvalidArgsError()
+	}
+	if len(r.Username) > 255 || len(r.Password) > 1024 {
+		return util.InvalidArgsError()
+	}
+	if !mirrormd.IsSyncIntervalValid(r.SyncInterval) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type DeletePushMirrorReqDTO struct {
+	MirrorId string
+	Operator usermd.UserInfo
+}
+
+func (r *DeletePushMirrorReqDTO) IsValid() error {
+	if !mirrormd.IsMirrorIdValid(r.MirrorId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type SyncPushMirrorReqDTO struct {
+	MirrorId string
+	Operator usermd.UserInfo
+}
+
+func (r *SyncPushMirrorReqDTO) IsValid() error {
+	if !mirrormd.IsMirrorIdValid(r.MirrorId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type ListPushMirrorReqDTO struct {
+	RepoId   string
+	Operator usermd.UserInfo
+}
+
+func (r *ListPushMirrorReqDTO) IsValid() error {
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type PushMirrorDTO struct {
+	MirrorId     string
+	RepoId       string
+	RemoteUrl    string
+	Username     string
+	HasPassword  bool
+	SyncInterval int
+	LastSyncTime int64
+	LastSyncErr  string
+	NextSyncTime int64
+	IsSyncing    bool
+}
@@ -0,0 +1,198 @@
+package mirrorsrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"time"
+	"zgit/pkg/apicode"
+	"zgit/pkg/i18n"
+	"zgit/setting"
+	"zgit/standalone/modules/model/mirrormd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+const (
+	// 单个仓库推送镜像数量上限
+	maxPushMirrorCount = 10
+)
+
+// InsertPushMirror 新增推送镜像
+func InsertPushMirror(ctx context.Context, reqDTO InsertPushMirrorReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	if _, err := checkPerm(ctx, reqDTO.RepoId, reqDTO.Operator, true); err != nil {
+		return err
+	}
+	count, err := mirrormd.CountPushMirror(ctx, reqDTO.RepoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if count >= maxPushMirrorCount {
+		return util.NewBizErr(apicode.InvalidArgsCode, i18n.RepoPushMirrorCountOutOfLimit)
+	}
+	if err = util.CheckRemoteHost(ctx, reqDTO.RemoteUrl); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.NewBizErr(apicode.InvalidArgsCode, i18n.RepoRemoteUrlNotAllowed)
+	}
+	var password string
+	if reqDTO.Password != "" {
+		password, err = util.AesGcmEncrypt(setting.SecretKeyBytes(), reqDTO.Password)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+	}
+	err = mirrormd.InsertPushMirror(ctx, mirrormd.InsertPushMirrorReqDTO{
+		RepoId:       reqDTO.RepoId,
+		RemoteUrl:    reqDTO.RemoteUrl,
+		Username:     reqDTO.Username,
+		Password:     password,
+		SyncInterval: reqDTO.SyncInterval,
+		// 尽快进行首次同步
+		NextSyncTime: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
+// UpdatePushMirror 编辑推送镜像
+func UpdatePushMirror(ctx context.Context, reqDTO UpdatePushMirrorReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	mirror, err := checkPushMirrorPerm(ctx, reqDTO.MirrorId, reqDTO.Operator, true)
+	if err != nil {
+		return err
+	}
+	if err = util.CheckRemoteHost(ctx, reqDTO.RemoteUrl); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.NewBizErr(apicode.InvalidArgsCode, i18n.RepoRemoteUrlNotAllowed)
+	}
+	password := mirror.Password
+	if reqDTO.Password != "" {
+		password, err = util.AesGcmEncrypt(setting.SecretKeyBytes(), reqDTO.Password)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+	}
+	_, err = mirrormd.UpdatePushMirrorCfg(ctx, mirrormd.UpdatePushMirrorCfgReqDTO{
+		MirrorId:     mirror.MirrorId,
+		RemoteUrl:    reqDTO.RemoteUrl,
+		Username:     reqDTO.Username,
+		Password:     password,
+		SyncInterval: reqDTO.SyncInterval,
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
+// DeletePushMirror 删除推送镜像
+func DeletePushMirror(ctx context.Context, reqDTO DeletePushMirrorReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	mirror, err := checkPushMirrorPerm(ctx, reqDTO.MirrorId, reqDTO.Operator, true)
+	if err != nil {
+		return err
+	}
+	if _, err = mirrormd.DeletePushMirror(ctx, mirror.MirrorId); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
+// ListPushMirror 推送镜像列表及同步状态
+func ListPushMirror(ctx context.Context, reqDTO ListPushMirrorReqDTO) ([]PushMirrorDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return nil, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	if _, err := checkPerm(ctx, reqDTO.RepoId, reqDTO.Operator, false); err != nil {
+		return nil, err
+	}
+	mirrors, err := mirrormd.ListPushMirror(ctx, reqDTO.RepoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return nil, util.InternalError()
+	}
+	ret, _ := listutil.Map(mirrors, func(m mirrormd.PushMirror) (PushMirrorDTO, error) {
+		_, isSyncing := pushingMirrors.Load(m.MirrorId)
+		return PushMirrorDTO{
+			MirrorId:     m.MirrorId,
+			RepoId:       m.RepoId,
+			RemoteUrl:    m.RemoteUrl,
+			Username:     m.Username,
+			HasPassword:  m.Password != "",
+			SyncInterval: m.SyncInterval,
+			LastSyncTime: m.LastSyncTime,
+			LastSyncErr:  m.LastSyncErr,
+			NextSyncTime: m.NextSyncTime,
+			IsSyncing:    isSyncing,
+		}, nil
+	})
+	return ret, nil
+}
+
+// SyncPushMirror 立即推送 异步执行
+func SyncPushMirror(ctx context.Context, reqDTO SyncPushMirrorReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	mirror, err := checkPushMirrorPerm(ctx, reqDTO.MirrorId, reqDTO.Operator, true)
+	if err != nil {
+		return err
+	}
+	go syncPushMirror(mirror)
+	return nil
+}
+
+// TriggerPushMirrors 推送后同步到所有推送镜像
+func TriggerPushMirrors(ctx context.Context, repoId string) {
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	mirrors, err := mirrormd.ListPushMirror(ctx, repoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return
+	}
+	for _, mirror := range mirrors {
+		go syncPushMirror(mirror)
+	}
+}
+
+func checkPushMirrorPerm(ctx context.Context, mirrorId string, operator usermd.UserInfo, isManage bool) (mirrormd.PushMirror, error) {
+	mirror, b, err := mirrormd.GetPushMirrorByMirrorId(ctx, mirrorId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return mirrormd.PushMirror{}, util.InternalError()
+	}
+	if !b {
+		return mirrormd.PushMirror{}, util.InvalidArgsError()
+	}
+	if _, err = checkPerm(ctx, mirror.RepoId, operator, isManage); err != nil {
+		return mirrormd.PushMirror{}, err
+	}
+	return mirror, nil
+}
@@ -0,0 +1,189 @@
+package mirrorsrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"path/filepath"
+	"zgit/pkg/apicode"
+	"zgit/pkg/git"
+	"zgit/pkg/i18n"
+	"zgit/setting"
+	"zgit/standalone/modules/model/mirrormd"
+	"zgit/standalone/modules/model/projectmd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+// GetPullMirror 获取镜像配置及同步状态
+func GetPullMirror(ctx context.Context, reqDTO GetPullMirrorReqDTO) (PullMirrorDTO, bool, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return PullMirrorDTO{}, false, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	if _, err := checkPerm(ctx, reqDTO.RepoId, reqDTO.Operator, false); err != nil {
+		return PullMirrorDTO{}, false, err
+	}
+	mirror, b, err := mirrormd.GetPullMirrorByRepoId(ctx, reqDTO.RepoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return PullMirrorDTO{}, false, util.InternalError()
+	}
+	if !b {
+		return PullMirrorDTO{}, false, nil
+	}
+	_, isSyncing := syncingRepos.Load(mirror.RepoId)
+	return PullMirrorDTO{
+		RepoId:       mirror.RepoId,
+		RemoteUrl:    mirror.RemoteUrl,
+		Username:     mirror.Username,
+		HasPassword:  mirror.Password != "",
+		SyncInterval: mirror.SyncInterval,
+		LastSyncTime: mirror.LastSyncTime,
+		LastSyncErr:  mirror.LastSyncErr,
+		NextSyncTime: mirror.NextSyncTime,
+		IsSyncing:    isSyncing,
+	}, true, nil
+}
+
+// UpdatePullMirror 编辑镜像配置
+func UpdatePullMirror(ctx context.Context, reqDTO UpdatePullMirrorReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, err := checkPerm(ctx, reqDTO.RepoId, reqDTO.Operator, true)
+	if err != nil {
+		return err
+	}
+	mirror, err := mustGetPullMirror(ctx, reqDTO.RepoId)
+	if err != nil {
+		return err
+	}
+	password := mirror.Password
+	if reqDTO.Password != "" {
+		password, err = util.AesGcmEncrypt(setting.SecretKeyBytes(), reqDTO.Password)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+	}
+	// 远端地址变更 重新添加远端
+	if reqDTO.RemoteUrl != mirror.RemoteUrl {
+		if err = util.CheckRemoteHost(ctx, reqDTO.RemoteUrl); err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.NewBizErr(apicode.InvalidArgsCode, i18n.RepoRemoteUrlNotAllowed)
+		}
+		absPath := filepath.Join(setting.RepoDir(), repo.Path)
+		if err = git.RemoveRemote(ctx, absPath, git.DefaultRemote); err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+		}
+		if err = git.AddMirrorRemote(ctx, absPath, git.DefaultRemote, reqDTO.RemoteUrl); err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+	}
+	_, err = mirrormd.UpdatePullMirrorCfg(ctx, mirrormd.UpdatePullMirrorCfgReqDTO{
+		RepoId:       reqDTO.RepoId,
+		RemoteUrl:    reqDTO.RemoteUrl,
+		Username:     reqDTO.Username,
+		Password:     password,
+		SyncInterval: reqDTO.SyncInterval,
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
+// SyncPullMirror 立即同步 异步执行
+func SyncPullMirror(ctx context.Context, reqDTO SyncPullMirrorReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	if _, err := checkPerm(ctx, reqDTO.RepoId, reqDTO.Operator, true); err != nil {
+		return err
+	}
+	mirror, err := mustGetPullMirror(ctx, reqDTO.RepoId)
+	if err != nil {
+		return err
+	}
+	if _, isSyncing := syncingRepos.Load(mirror.RepoId); isSyncing {
+		return util.NewBizErr(apicode.MirrorSyncingCode, i18n.RepoMirrorIsSyncing)
+	}
+	go syncPullMirror(mirror)
+	return nil
+}
+
+// DeletePullMirror 取消镜像 转为普通仓库
+func DeletePullMirror(ctx context.Context, reqDTO DeletePullMirrorReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, err := checkPerm(ctx, reqDTO.RepoId, reqDTO.Operator, true)
+	if err != nil {
+		return err
+	}
+	if _, err = mustGetPullMirror(ctx, reqDTO.RepoId); err != nil {
+		return err
+	}
+	if _, isSyncing := syncingRepos.Load(repo.RepoId); isSyncing {
+		return util.NewBizErr(apicode.MirrorSyncingCode, i18n.RepoMirrorIsSyncing)
+	}
+	if _, err = mirrormd.DeletePullMirror(ctx, repo.RepoId); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if err = git.RemoveRemote(ctx, filepath.Join(setting.RepoDir(), repo.Path), git.DefaultRemote); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+	}
+	return nil
+}
+
+func mustGetPullMirror(ctx context.Context, repoId string) (mirrormd.PullMirror, error) {
+	mirror, b, err := mirrormd.GetPullMirrorByRepoId(ctx, repoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return mirrormd.PullMirror{}, util.InternalError()
+	}
+	if !b {
+		return mirrormd.PullMirror{}, util.InvalidArgsError()
+	}
+	return mirror, nil
+}
+
+// checkPerm 查看需有访问权限 管理需有管理保护分支权限
+func checkPerm(ctx context.Context, repoId string, operator usermd.UserInfo, isManage bool) (repomd.Repo, error) {
+	repo, b, err := repomd.GetByRepoId(ctx, repoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return repomd.Repo{}, util.InternalError()
+	}
+	if !b {
+		return repomd.Repo{}, util.InvalidArgsError()
+	}
+	detail, b, err := projectmd.GetProjectUserPermDetail(ctx, repo.ProjectId, operator.Account)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return repomd.Repo{}, util.InternalError()
+	}
+	if !b {
+		return repomd.Repo{}, util.UnauthorizedError()
+	}
+	p := detail.PermDetail.GetRepoPerm(repoId)
+	if isManage && !p.CanHandleProtectedBranch {
+		return repomd.Repo{}, util.UnauthorizedError()
+	}
+	if !isManage && !p.CanAccess {
+		return repomd.Repo{}, util.UnauthorizedError()
+	}
+	return repo, nil
+}
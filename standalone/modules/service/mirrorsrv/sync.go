@@ -0,0 +1,223 @@
+package mirrorsrv
+
+import (
+	"context"
+	"errors"
+	"github.com/LeeZXin/zsf-utils/taskutil"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+	"zgit/pkg/git"
+	"zgit/setting"
+	"zgit/standalone/modules/model/mirrormd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/util"
+)
+
+const (
+	syncTimeout = 30 * time.Minute
+	// 每轮最多调度的镜像数量
+	dueMirrorLimit = 50
+	// 最大并发同步数
+	maxConcurrentSyncs = 4
+	// 错误信息最大长度
+	maxSyncErrLength = 1024
+)
+
+var (
+	// 正在同步的仓库
+	syncingRepos sync.Map
+	// 正在推送的镜像 值为推送期间是否有新的推送
+	pushingMirrors sync.Map
+	syncSem        = make(chan struct{}, maxConcurrentSyncs)
+	syncTask       *taskutil.PeriodicalTask
+)
+
+// InitTask 启动镜像定时同步任务
+func InitTask() {
+	syncTask, _ = taskutil.NewPeriodicalTask(time.Minute, syncDueMirrors)
+	syncTask.Start()
+}
+
+func syncDueMirrors() {
+	ctx, closer := mysqlstore.Context(context.Background())
+	defer closer.Close()
+	now := time.Now().UnixMilli()
+	pullMirrors, err := mirrormd.ListDuePullMirror(ctx, now, dueMirrorLimit)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return
+	}
+	for _, mirror := range pullMirrors {
+		go syncPullMirror(mirror)
+	}
+	pushMirrors, err := mirrormd.ListDuePushMirror(ctx, now, dueMirrorLimit)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return
+	}
+	for _, mirror := range pushMirrors {
+		go syncPushMirror(mirror)
+	}
+}
+
+// syncPullMirror 同一仓库同时只有一个同步任务
+func syncPullMirror(mirror mirrormd.PullMirror) {
+	if _, loaded := syncingRepos.LoadOrStore(mirror.RepoId, struct{}{}); loaded {
+		return
+	}
+	defer syncingRepos.Delete(mirror.RepoId)
+	syncSem <- struct{}{}
+	defer func() {
+		<-syncSem
+	}()
+	ctx, closer := mysqlstore.Context(context.Background())
+	defer closer.Close()
+	var errMsg string
+	if err := doSyncPullMirror(ctx, mirror); err != nil {
+		logger.Logger.WithContext(ctx).Errorf("sync mirror: %s err: %v", mirror.RepoId, err)
+		errMsg = truncateSyncErr(err)
+	}
+	now := time.Now()
+	_, err := mirrormd.UpdateSyncResult(ctx, mirrormd.UpdateSyncResultReqDTO{
+		RepoId:       mirror.RepoId,
+		LastSyncTime: now.UnixMilli(),
+		LastSyncErr:  errMsg,
+		NextSyncTime: now.Add(time.Duration(mirror.SyncInterval) * time.Minute).UnixMilli(),
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+	}
+}
+
+func doSyncPullMirror(ctx context.Context, mirror mirrormd.PullMirror) error {
+	repo, b, err := repomd.GetByRepoId(ctx, mirror.RepoId)
+	if err != nil {
+		return err
+	}
+	if !b {
+		return errors.New("repo not found")
+	}
+	auth := git.RemoteAuth{
+		Username: mirror.Username,
+	}
+	if mirror.Password != "" {
+		auth.Password, err = util.AesGcmDecrypt(setting.SecretKeyBytes(), mirror.Password)
+		if err != nil {
+			return err
+		}
+	}
+	absPath := filepath.Join(setting.RepoDir(), repo.Path)
+	fetchCtx, cancel := context.WithTimeout(ctx, syncTimeout)
+	defer cancel()
+	// 域名解析结果可能变化 每次同步前都需要校验
+	if err = util.CheckRemoteHost(fetchCtx, mirror.RemoteUrl); err != nil {
+		return err
+	}
+	if err = git.FetchRemote(fetchCtx, absPath, git.DefaultRemote, auth, true); err != nil {
+		return err
+	}
+	// 空仓库首次同步到内容
+	if repo.IsEmpty {
+		branch, err := git.GetRemoteHeadBranch(fetchCtx, absPath, git.DefaultRemote, auth)
+		if err != nil {
+			return err
+		}
+		if branch != "" && branch == repo.DefaultBranch {
+			if err = repomd.UpdateIsEmpty(ctx, repo.RepoId, false); err != nil {
+				return err
+			}
+		}
+	}
+	size, err := git.GetRepoSize(absPath)
+	if err != nil {
+		return err
+	}
+	return repomd.UpdateTotalAndGitSize(ctx, repo.RepoId, repo.LfsSize+repo.WikiSize+size, size)
+}
+
+// syncPushMirror 同一镜像同时只有一个推送任务 推送期间有新的推送则完成后再推送一次
+func syncPushMirror(mirror mirrormd.PushMirror) {
+	dirty := new(atomic.Bool)
+	if v, loaded := pushingMirrors.LoadOrStore(mirror.MirrorId, dirty); loaded {
+		v.(*atomic.Bool).Store(true)
+		return
+	}
+	defer pushingMirrors.Delete(mirror.MirrorId)
+	syncSem <- struct{}{}
+	defer func() {
+		<-syncSem
+	}()
+	ctx, closer := mysqlstore.Context(context.Background())
+	defer closer.Close()
+	for {
+		dirty.Store(false)
+		var errMsg string
+		if err := doSyncPushMirror(ctx, mirror); err != nil {
+			logger.Logger.WithContext(ctx).Errorf("push mirror: %s err: %v", mirror.MirrorId, err)
+			errMsg = truncateSyncErr(err)
+		}
+		now := time.Now()
+		_, err := mirrormd.UpdatePushMirrorSyncResult(ctx, mirrormd.UpdatePushMirrorSyncResultReqDTO{
+			MirrorId:     mirror.MirrorId,
+			LastSyncTime: now.UnixMilli(),
+			LastSyncErr:  errMsg,
+			NextSyncTime: now.Add(time.Duration(mirror.SyncInterval) * time.Minute).UnixMilli(),
+		})
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+		}
+		if !dirty.Load() {
+			return
+		}
+	}
+}
+
+func doSyncPushMirror(ctx context.Context, mirror mirrormd.PushMirror) error {
+	// 重新获取 防止配置已被修改或删除
+	mirror, b, err := mirrormd.GetPushMirrorByMirrorId(ctx, mirror.MirrorId)
+	if err != nil {
+		return err
+	}
+	if !b {
+		return errors.New("push mirror not found")
+	}
+	repo, b, err := repomd.GetByRepoId(ctx, mirror.RepoId)
+	if err != nil {
+		return err
+	}
+	if !b {
+		return errors.New("repo not found")
+	}
+	// 空仓库无需推送
+	if repo.IsEmpty {
+		return nil
+	}
+	auth := git.RemoteAuth{
+		Username: mirror.Username,
+	}
+	if mirror.Password != "" {
+		auth.Password, err = util.AesGcmDecrypt(setting.SecretKeyBytes(), mirror.Password)
+		if err != nil {
+			return err
+		}
+	}
+	pushCtx, cancel := context.WithTimeout(ctx, syncTimeout)
+	defer cancel()
+	// 域名解析结果可能变化 每次推送前都需要校验
+	if err = util.CheckRemoteHost(pushCtx, mirror.RemoteUrl); err != nil {
+		return err
+	}
+	return git.PushMirror(pushCtx, filepath.Join(setting.RepoDir(), repo.Path), mirror.RemoteUrl, auth)
+}
+
+func truncateSyncErr(err error) string {
+	errMsg := err.Error()
+	if len(errMsg) > maxSyncErrLength {
+		errMsg = errMsg[:maxSyncErrLength]
+	}
+	return errMsg
+}
@@ -0,0 +1,108 @@
+package pullrequestsrv
+
+import (
+	"context"
+	"zgit/pkg/git"
+	"zgit/standalone/modules/model/projectmd"
+	"zgit/standalone/modules/model/pullrequestmd"
+	"zgit/standalone/modules/model/repomd"
+)
+
+// getCodeOwnerApprovals 根据变更文件匹配CODEOWNERS 获取需要审批的代码所有者及审批情况
+func getCodeOwnerApprovals(ctx context.Context, absPath string, repo repomd.Repo, pr pullrequestmd.PullRequest, info git.DiffCommitsInfo) ([]CodeOwnerApprovalDTO, error) {
+	// CODEOWNERS以合入分支为准
+	rules, b, err := git.GetCodeOwners(ctx, absPath, pr.Head)
+	if err != nil || !b {
+		return nil, err
+	}
+	required := make([]git.CodeOwnerRule, 0)
+	seen := make(map[string]bool)
+	for _, stat := range info.DiffNumsStats.Stats {
+		rule, b := git.FindCodeOwnerRule(rules, stat.Path)
+		if !b || len(rule.Owners) == 0 || seen[rule.Pattern] {
+			continue
+		}
+		seen[rule.Pattern] = true
+		required = append(required, rule)
+	}
+	if len(required) == 0 {
+		return nil, nil
+	}
+	reviews, err := pullrequestmd.ListReview(ctx, pr.PrId)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := projectmd.ListProjectUserGroup(ctx, repo.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	groupIdMap := make(map[string]string, len(groups))
+	for _, group := range groups {
+		groupIdMap[group.Name] = group.GroupId
+	}
+	// 同意合并的评审者及其用户组 合并请求创建者的审批不计入
+	approvedAccounts := make(map[string]bool)
+	approvedGroups := make(map[string]bool)
+	for _, review := range reviews {
+		if review.ReviewStatus != pullrequestmd.AgreeMergeStatus || review.IsDismissed || review.Reviewer == pr.CreateBy {
+			continue
+		}
+		approvedAccounts[review.Reviewer] = true
+		pu, b, err := projectmd.GetProjectUser(ctx, repo.ProjectId, review.Reviewer)
+		if err != nil {
+			return nil, err
+		}
+		if b && pu.GroupId != "" {
+			approvedGroups[pu.GroupId] = true
+		}
+	}
+	ret := make([]CodeOwnerApprovalDTO, 0, len(required))
+	for _, rule := range required {
+		item := CodeOwnerApprovalDTO{
+			Pattern: rule.Pattern,
+			Owners:  make([]string, 0, len(rule.Owners)),
+		}
+		for _, owner := range rule.Owners {
+			item.Owners = append(item.Owners, owner.String())
+			if owner.IsGroup {
+				groupId, b := groupIdMap[owner.Name]
+				if b && approvedGroups[groupId] {
+					item.Approved = true
+				}
+			} else if approvedAccounts[owner.Name] {
+				item.Approved = true
+			}
+		}
+		ret = append(ret, item)
+	}
+	return ret, nil
+}
+
+// isCodeOwner 是否是CODEOWNERS中的代码所有者
+func isCodeOwner(ctx context.Context, absPath string, repo repomd.Repo, pr pullrequestmd.PullRequest, groupId, account string) (bool, error) {
+	rules, b, err := git.GetCodeOwners(ctx, absPath, pr.Head)
+	if err != nil || !b {
+		return false, err
+	}
+	groupName := ""
+	if groupId != "" {
+		group, b, err := projectmd.GetByGroupId(ctx, groupId)
+		if err != nil {
+			return false, err
+		}
+		if b && group.ProjectId == repo.ProjectId {
+			groupName = group.Name
+		}
+	}
+	for _, rule := range rules {
+		for _, owner := range rule.Owners {
+			if owner.IsGroup && owner.Name == groupName && groupName != "" {
+				return true, nil
+			}
+			if !owner.IsGroup && owner.Name == account {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -1,6 +1,9 @@
 package pullrequestsrv
 
 import (
+	"time"
+	"zgit/pkg/git"
+	"zgit/standalone/modules/model/projectmd"
 	"zgit/standalone/modules/model/pullrequestmd"
 	"zgit/standalone/modules/model/repomd"
 	"zgit/standalone/modules/model/usermd"
@@ -8,7 +11,9 @@ import (
 )
 
 type SubmitPullRequestReqDTO struct {
-	RepoId   string
+	RepoId string
+	// 为空时默认为 Target -> Head
+	Title    string
 	Target   string
 	Head     string
 	Operator usermd.UserInfo
@@ -21,6 +26,9 @@ func (r *SubmitPullRequestReqDTO) IsValid() error {
 	if !repomd.IsRepoIdValid(r.RepoId) {
 		return util.InvalidArgsError()
 	}
+	if len(r.Title) > 255 {
+		return util.InvalidArgsError()
+	}
 	if !util.ValidateRef(r.Target) {
 		return util.InvalidArgsError()
 	}
@@ -30,6 +38,12 @@ func (r *SubmitPullRequestReqDTO) IsValid() error {
 	return nil
 }
 
+type SubmitPullRequestRespDTO struct {
+	PrId string
+	// 变更文件对应的代码所有者审批情况
+	CodeOwnerList []CodeOwnerApprovalDTO
+}
+
 type ClosePullRequestReqDTO struct {
 	PrId     string
 	Operator usermd.UserInfo
@@ -47,6 +61,7 @@ func (r *ClosePullRequestReqDTO) IsValid() error {
 
 type MergePullRequestReqDTO struct {
 	PrId     string
+	Strategy git.MergeStrategy
 	Operator usermd.UserInfo
 }
 
@@ -54,6 +69,13 @@ func (r *MergePullRequestReqDTO) IsValid() error {
 	if !util.ValidateOperator(r.Operator) {
 		return util.InvalidArgsError()
 	}
+	// 默认合并提交
+	if r.Strategy == "" {
+		r.Strategy = git.MergeCommitStrategy
+	}
+	if !r.Strategy.IsValid() {
+		return util.InvalidArgsError()
+	}
 	if !pullrequestmd.IsPrIdValid(r.PrId) {
 		return util.InvalidArgsError()
 	}
@@ -82,3 +104,231 @@ func (r *ReviewPullRequestReqDTO) IsValid() error {
 	}
 	return nil
 }
+
+type ListPullRequestReqDTO struct {
+	RepoId   string
+	PrStatus *pullrequestmd.PrStatus
+	CreateBy string
+	Reviewer string
+	Target   string
+	Head     string
+	Title    string
+	Offset   int64
+	Limit    int
+	Operator usermd.UserInfo
+}
+
+func (r *ListPullRequestReqDTO) IsValid() error {
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return validateListCond(r.PrStatus, r.CreateBy, r.Reviewer, r.Target, r.Head, r.Title, r.Offset, r.Limit)
+}
+
+type ListProjectPullRequestReqDTO struct {
+	ProjectId string
+	PrStatus  *pullrequestmd.PrStatus
+	CreateBy  string
+	Reviewer  string
+	Target    string
+	Head      string
+	Title     string
+	Offset    int64
+	Limit     int
+	Operator  usermd.UserInfo
+}
+
+func (r *ListProjectPullRequestReqDTO) IsValid() error {
+	if !projectmd.IsProjectIdValid(r.ProjectId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return validateListCond(r.PrStatus, r.CreateBy, r.Reviewer, r.Target, r.Head, r.Title, r.Offset, r.Limit)
+}
+
+func validateListCond(prStatus *pullrequestmd.PrStatus, createBy, reviewer, target, head, title string, offset int64, limit int) error {
+	if prStatus != nil && !prStatus.IsValid() {
+		return util.InvalidArgsError()
+	}
+	if createBy != "" && !usermd.IsUserAccountValid(createBy) {
+		return util.InvalidArgsError()
+	}
+	if reviewer != "" && !usermd.IsUserAccountValid(reviewer) {
+		return util.InvalidArgsError()
+	}
+	if target != "" && !util.ValidateRef(target) {
+		return util.InvalidArgsError()
+	}
+	if head != "" && !util.ValidateRef(head) {
+		return util.InvalidArgsError()
+	}
+	if len(title) > 255 {
+		return util.InvalidArgsError()
+	}
+	if offset < 0 {
+		return util.InvalidArgsError()
+	}
+	if limit <= 0 || limit > 1000 {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type PullRequestDTO struct {
+	PrId           string
+	RepoId         string
+	Title          string
+	Target         string
+	TargetCommitId string
+	Head           string
+	HeadCommitId   string
+	PrStatus       pullrequestmd.PrStatus
+	IsMergeAble    bool
+	HasConflict    bool
+	CreateBy       string
+	Created        time.Time
+	Updated        time.Time
+}
+
+type ListPullRequestRespDTO struct {
+	PrList []PullRequestDTO
+	Cursor int64
+}
+
+type GetPullRequestDetailReqDTO struct {
+	PrId     string
+	Operator usermd.UserInfo
+}
+
+func (r *GetPullRequestDetailReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !pullrequestmd.IsPrIdValid(r.PrId) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type ReviewDTO struct {
+	Rid          string
+	Reviewer     string
+	ReviewMsg    string
+	ReviewStatus pullrequestmd.ReviewStatus
+	IsDismissed  bool
+	Created      time.Time
+	Updated      time.Time
+}
+
+type GetPullRequestDetailRespDTO struct {
+	PullRequest PullRequestDTO
+	ReviewList  []ReviewDTO
+	// 分支已删除等情况无法计算差异
+	HasDiffInfo bool
+	DiffInfo    git.DiffCommitsInfo
+	CanMerge    bool
+	// 变更文件对应的代码所有者审批情况
+	CodeOwnerList []CodeOwnerApprovalDTO
+}
+
+type CodeOwnerApprovalDTO struct {
+	Pattern  string
+	Owners   []string
+	Approved bool
+}
+
+type AddCommentReqDTO struct {
+	PrId string
+	// 回复的评论cid 为空则新建评论串
+	ReplyTo  string
+	FilePath string
+	Side     pullrequestmd.CommentSide
+	LineNo   int
+	Content  string
+	Operator usermd.UserInfo
+}
+
+func (r *AddCommentReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !pullrequestmd.IsPrIdValid(r.PrId) {
+		return util.InvalidArgsError()
+	}
+	if len(r.Content) == 0 || len(r.Content) > 1024 {
+		return util.InvalidArgsError()
+	}
+	if r.ReplyTo != "" {
+		if !pullrequestmd.IsCidValid(r.ReplyTo) {
+			return util.InvalidArgsError()
+		}
+		return nil
+	}
+	if len(r.FilePath) == 0 || len(r.FilePath) > 255 {
+		return util.InvalidArgsError()
+	}
+	if !r.Side.IsValid() {
+		return util.InvalidArgsError()
+	}
+	if r.LineNo <= 0 {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type ListCommentReqDTO struct {
+	PrId string
+	// 为空展示所有文件
+	FilePath string
+	Operator usermd.UserInfo
+}
+
+func (r *ListCommentReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !pullrequestmd.IsPrIdValid(r.PrId) {
+		return util.InvalidArgsError()
+	}
+	if len(r.FilePath) > 255 {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type CommentDTO struct {
+	Cid        string
+	ThreadId   string
+	FilePath   string
+	Side       pullrequestmd.CommentSide
+	LineNo     int
+	LineText   string
+	CommitId   string
+	Commenter  string
+	Content    string
+	IsResolved bool
+	ResolvedBy string
+	IsOutdated bool
+	Created    time.Time
+}
+
+type ResolveCommentThreadReqDTO struct {
+	ThreadId   string
+	IsResolved bool
+	Operator   usermd.UserInfo
+}
+
+func (r *ResolveCommentThreadReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !pullrequestmd.IsCidValid(r.ThreadId) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
@@ -2,62 +2,87 @@ package pullrequestsrv
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/LeeZXin/zsf-utils/listutil"
 	"github.com/LeeZXin/zsf/logger"
 	"github.com/LeeZXin/zsf/xorm/mysqlstore"
 	"path/filepath"
+	"strings"
 	"zgit/pkg/apicode"
 	"zgit/pkg/git"
+	"zgit/pkg/hook"
 	"zgit/pkg/i18n"
+	"zgit/pkg/perm"
+	"zgit/pkg/webhook"
 	"zgit/setting"
 	"zgit/standalone/modules/model/branchmd"
+	"zgit/standalone/modules/model/commitstatusmd"
 	"zgit/standalone/modules/model/projectmd"
 	"zgit/standalone/modules/model/pullrequestmd"
 	"zgit/standalone/modules/model/repomd"
 	"zgit/standalone/modules/model/usermd"
+	"zgit/standalone/modules/service/webhooksrv"
 	"zgit/util"
 )
 
-func SubmitPullRequest(ctx context.Context, reqDTO SubmitPullRequestReqDTO) error {
+func SubmitPullRequest(ctx context.Context, reqDTO SubmitPullRequestReqDTO) (SubmitPullRequestRespDTO, error) {
 	if err := reqDTO.IsValid(); err != nil {
-		return err
+		return SubmitPullRequestRespDTO{}, err
 	}
 	ctx, closer := mysqlstore.Context(ctx)
 	defer closer.Close()
 	// 校验权限
 	repo, err := checkPermByRepoId(ctx, reqDTO.RepoId, reqDTO.Operator)
 	if err != nil {
-		return err
+		return SubmitPullRequestRespDTO{}, err
 	}
 	absPath := filepath.Join(setting.RepoDir(), repo.Path)
 	if !git.CheckRefIsBranch(ctx, absPath, reqDTO.Head) {
-		return util.InvalidArgsError()
+		return SubmitPullRequestRespDTO{}, util.InvalidArgsError()
 	}
 	if !git.CheckExists(ctx, absPath, reqDTO.Target) {
-		return util.InvalidArgsError()
+		return SubmitPullRequestRespDTO{}, util.InvalidArgsError()
 	}
 	info, err := git.GetDiffCommitsInfo(ctx, absPath, reqDTO.Target, reqDTO.Head)
 	if err != nil {
 		logger.Logger.WithContext(ctx).Error(err)
-		return util.InternalError()
+		return SubmitPullRequestRespDTO{}, util.InternalError()
 	}
 	// 不可合并
 	if !info.IsMergeAble() {
-		return util.NewBizErr(apicode.PullRequestCannotMergeCode, i18n.PullRequestCannotMerge)
-	}
-	_, err = pullrequestmd.InsertPullRequest(ctx, pullrequestmd.InsertPullRequestReqDTO{
-		RepoId:   reqDTO.RepoId,
-		Target:   reqDTO.Target,
-		Head:     reqDTO.Head,
-		CreateBy: reqDTO.Operator.Account,
-		PrStatus: pullrequestmd.PrOpenStatus,
+		return SubmitPullRequestRespDTO{}, util.NewBizErr(apicode.PullRequestCannotMergeCode, i18n.PullRequestCannotMerge)
+	}
+	title := reqDTO.Title
+	if title == "" {
+		title = reqDTO.Target + " -> " + reqDTO.Head
+	}
+	pr, err := pullrequestmd.InsertPullRequest(ctx, pullrequestmd.InsertPullRequestReqDTO{
+		RepoId:         reqDTO.RepoId,
+		Title:          title,
+		Target:         reqDTO.Target,
+		TargetCommitId: info.TargetCommit.Id,
+		Head:           reqDTO.Head,
+		HeadCommitId:   info.HeadCommit.Id,
+		IsMergeAble:    true,
+		CreateBy:       reqDTO.Operator.Account,
+		PrStatus:       pullrequestmd.PrOpenStatus,
 	})
 	if err != nil {
 		logger.Logger.WithContext(ctx).Error(err)
-		return util.InternalError()
+		return SubmitPullRequestRespDTO{}, util.InternalError()
 	}
-	return nil
+	webhooksrv.TriggerPullRequestEvent(ctx, webhook.PrOpenedEvent, pr, reqDTO.Operator.Account)
+	ret := SubmitPullRequestRespDTO{
+		PrId: pr.PrId,
+	}
+	// 提交时即返回需要审批的代码所有者
+	ret.CodeOwnerList, err = getCodeOwnerApprovals(ctx, absPath, repo, pr, info)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return SubmitPullRequestRespDTO{}, util.InternalError()
+	}
+	return ret, nil
 }
 
 func ClosePullRequest(ctx context.Context, reqDTO ClosePullRequestReqDTO) error {
@@ -75,11 +100,14 @@ func ClosePullRequest(ctx context.Context, reqDTO ClosePullRequestReqDTO) error
 	if pr.PrStatus != pullrequestmd.PrOpenStatus {
 		return util.InvalidArgsError()
 	}
-	_, err = pullrequestmd.UpdatePrStatus(ctx, reqDTO.PrId, pullrequestmd.PrOpenStatus, pullrequestmd.PrClosedStatus)
+	b, err := pullrequestmd.UpdatePrStatus(ctx, reqDTO.PrId, pullrequestmd.PrOpenStatus, pullrequestmd.PrClosedStatus)
 	if err != nil {
 		logger.Logger.WithContext(ctx).Error(err)
 		return util.InternalError()
 	}
+	if b {
+		webhooksrv.TriggerPullRequestEvent(ctx, webhook.PrClosedEvent, pr, reqDTO.Operator.Account)
+	}
 	return nil
 }
 
@@ -105,6 +133,10 @@ func MergePullRequest(ctx context.Context, reqDTO MergePullRequestReqDTO) error
 		return util.InternalError()
 	}
 	if isProtectedBranch {
+		// 检查合并方式
+		if !cfg.IsMergeStrategyAllowed(reqDTO.Strategy) {
+			return util.NewBizErr(apicode.MergeStrategyNotAllowedCode, i18n.ProtectedBranchNotAllowMergeStrategy)
+		}
 		// 检查评审配置 评审者数量大于0
 		if cfg.ReviewCountWhenCreatePr > 0 {
 			reviewCount, err := pullrequestmd.CountReview(ctx, reqDTO.PrId, pullrequestmd.AgreeMergeStatus)
@@ -128,7 +160,45 @@ func MergePullRequest(ctx context.Context, reqDTO MergePullRequestReqDTO) error
 	if !info.IsMergeAble() {
 		return util.NewBizErr(apicode.PullRequestCannotMergeCode, i18n.PullRequestCannotMerge)
 	}
-	return mysqlstore.WithTx(ctx, func(ctx context.Context) error {
+	// 要求签名时 变基会丢失原有签名 合并和压缩产生的提交使用服务端密钥签名
+	var signKeyId string
+	if isProtectedBranch && cfg.RequireSignedCommits {
+		switch reqDTO.Strategy {
+		case git.RebaseStrategy:
+			return util.NewBizErr(apicode.MergeStrategyNotAllowedCode, i18n.ProtectedBranchSignedCommitsNotAllowRebase)
+		case git.MergeCommitStrategy, git.SquashStrategy:
+			signKeyId = git.GetGpnKeyId(absPath, git.MergeScene)
+			if signKeyId == "" {
+				return util.NewBizErr(apicode.MergeStrategyNotAllowedCode, i18n.RepoSignKeyNotConfigured)
+			}
+		}
+	}
+	// 检查必需的提交状态
+	if isProtectedBranch && len(cfg.RequiredStatusContexts) > 0 {
+		passed, err := checkRequiredStatus(ctx, repo.RepoId, info.TargetCommit.Id, cfg.RequiredStatusContexts)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+		if !passed {
+			return util.NewBizErr(apicode.RequiredStatusCheckFailedCode, i18n.PullRequestRequiredStatusCheckFailed)
+		}
+	}
+	// 检查代码所有者审批
+	if isProtectedBranch && cfg.RequireCodeOwnerReview {
+		approvals, err := getCodeOwnerApprovals(ctx, absPath, repo, pr, info)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+		for _, approval := range approvals {
+			if !approval.Approved {
+				return util.NewBizErr(apicode.CodeOwnerNotApprovedCode, i18n.PullRequestCodeOwnerNotApproved)
+			}
+		}
+	}
+	merged := false
+	err = mysqlstore.WithTx(ctx, func(ctx context.Context) error {
 		b, err := pullrequestmd.UpdatePrStatusAndCommitId(
 			ctx,
 			pr.PrId,
@@ -143,17 +213,34 @@ func MergePullRequest(ctx context.Context, reqDTO MergePullRequestReqDTO) error
 		}
 		if b {
 			err = git.Merge(ctx, absPath, pr.Target, pr.Head, info, git.MergeRepoOpts{
-				PrId:     pr.PrId,
-				PusherId: reqDTO.Operator.Account,
-				Message:  fmt.Sprintf(i18n.GetByKey(i18n.PullRequestMergeMessage), pr.PrId, pr.CreateBy, reqDTO.Operator.Account),
+				PrId:      pr.PrId,
+				PusherId:  reqDTO.Operator.Account,
+				Message:   fmt.Sprintf(i18n.GetByKey(i18n.PullRequestMergeMessage), pr.PrId, pr.CreateBy, reqDTO.Operator.Account),
+				Strategy:  reqDTO.Strategy,
+				SignKeyId: signKeyId,
 			})
 			if err != nil {
 				logger.Logger.WithContext(ctx).Error(err)
+				var (
+					ffErr       *git.ErrMergeNotFastForward
+					conflictErr *git.ErrMergeConflict
+				)
+				if errors.As(err, &ffErr) {
+					return util.NewBizErr(apicode.PullRequestCannotMergeCode, i18n.PullRequestCannotFastForward)
+				}
+				if errors.As(err, &conflictErr) {
+					return util.NewBizErr(apicode.PullRequestCannotMergeCode, i18n.PullRequestCannotMerge)
+				}
 				return util.InternalError()
 			}
+			merged = true
 		}
 		return nil
 	})
+	if err == nil && merged {
+		webhooksrv.TriggerPullRequestEvent(ctx, webhook.PrMergedEvent, pr, reqDTO.Operator.Account)
+	}
+	return err
 }
 
 func ReviewPullRequest(ctx context.Context, reqDTO ReviewPullRequestReqDTO) error {
@@ -209,6 +296,14 @@ func ReviewPullRequest(ctx context.Context, reqDTO ReviewPullRequestReqDTO) erro
 			contains, _ := listutil.Contains(cfg.ReviewerList, func(account string) (bool, error) {
 				return account == reqDTO.Operator.Account, nil
 			})
+			// 开启代码所有者审批时 代码所有者也可评审
+			if !contains && cfg.RequireCodeOwnerReview {
+				contains, err = isCodeOwner(ctx, filepath.Join(setting.RepoDir(), repo.Path), repo, pr, p.GroupId, reqDTO.Operator.Account)
+				if err != nil {
+					logger.Logger.WithContext(ctx).Error(err)
+					return util.InternalError()
+				}
+			}
 			if !contains {
 				return util.UnauthorizedError()
 			}
@@ -224,9 +319,398 @@ func ReviewPullRequest(ctx context.Context, reqDTO ReviewPullRequestReqDTO) erro
 		logger.Logger.WithContext(ctx).Error(err)
 		return util.InternalError()
 	}
+	webhooksrv.TriggerReviewEvent(ctx, pr, reqDTO.Operator.Account, reqDTO.ReviewMsg, reqDTO.Status)
 	return nil
 }
 
+// ListPullRequest 展示仓库合并请求列表
+func ListPullRequest(ctx context.Context, reqDTO ListPullRequestReqDTO) (ListPullRequestRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return ListPullRequestRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	// 校验权限
+	if _, err := checkAccessPermByRepoId(ctx, reqDTO.RepoId, reqDTO.Operator); err != nil {
+		return ListPullRequestRespDTO{}, err
+	}
+	return listPullRequest(ctx, pullrequestmd.ListPullRequestReqDTO{
+		RepoIdList: []string{reqDTO.RepoId},
+		PrStatus:   reqDTO.PrStatus,
+		CreateBy:   reqDTO.CreateBy,
+		Reviewer:   reqDTO.Reviewer,
+		Target:     reqDTO.Target,
+		Head:       reqDTO.Head,
+		Title:      reqDTO.Title,
+		Offset:     reqDTO.Offset,
+		Limit:      reqDTO.Limit,
+	})
+}
+
+// ListProjectPullRequest 展示项目下所有可访问仓库的合并请求列表
+func ListProjectPullRequest(ctx context.Context, reqDTO ListProjectPullRequestReqDTO) (ListPullRequestRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return ListPullRequestRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repoIdList, err := listAccessibleRepoId(ctx, reqDTO.ProjectId, reqDTO.Operator)
+	if err != nil {
+		return ListPullRequestRespDTO{}, err
+	}
+	if len(repoIdList) == 0 {
+		return ListPullRequestRespDTO{}, nil
+	}
+	return listPullRequest(ctx, pullrequestmd.ListPullRequestReqDTO{
+		RepoIdList: repoIdList,
+		PrStatus:   reqDTO.PrStatus,
+		CreateBy:   reqDTO.CreateBy,
+		Reviewer:   reqDTO.Reviewer,
+		Target:     reqDTO.Target,
+		Head:       reqDTO.Head,
+		Title:      reqDTO.Title,
+		Offset:     reqDTO.Offset,
+		Limit:      reqDTO.Limit,
+	})
+}
+
+func listPullRequest(ctx context.Context, reqDTO pullrequestmd.ListPullRequestReqDTO) (ListPullRequestRespDTO, error) {
+	prList, err := pullrequestmd.ListPullRequest(ctx, reqDTO)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ListPullRequestRespDTO{}, util.InternalError()
+	}
+	ret := ListPullRequestRespDTO{}
+	ret.PrList, _ = listutil.Map(prList, func(t pullrequestmd.PullRequest) (PullRequestDTO, error) {
+		return pr2Dto(t), nil
+	})
+	if len(prList) > 0 {
+		ret.Cursor = prList[len(prList)-1].Id
+	}
+	return ret, nil
+}
+
+// GetPullRequestDetail 合并请求详情
+func GetPullRequestDetail(ctx context.Context, reqDTO GetPullRequestDetailReqDTO) (GetPullRequestDetailRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return GetPullRequestDetailRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	// 校验权限
+	pr, repo, err := checkAccessPerm(ctx, reqDTO.PrId, reqDTO.Operator)
+	if err != nil {
+		return GetPullRequestDetailRespDTO{}, err
+	}
+	reviewList, err := pullrequestmd.ListReview(ctx, pr.PrId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return GetPullRequestDetailRespDTO{}, util.InternalError()
+	}
+	ret := GetPullRequestDetailRespDTO{
+		PullRequest: pr2Dto(pr),
+	}
+	ret.ReviewList, _ = listutil.Map(reviewList, func(t pullrequestmd.Review) (ReviewDTO, error) {
+		return ReviewDTO{
+			Rid:          t.Rid,
+			Reviewer:     t.Reviewer,
+			ReviewMsg:    t.ReviewMsg,
+			ReviewStatus: t.ReviewStatus,
+			IsDismissed:  t.IsDismissed,
+			Created:      t.Created,
+			Updated:      t.Updated,
+		}, nil
+	})
+	absPath := filepath.Join(setting.RepoDir(), repo.Path)
+	ret.DiffInfo, ret.HasDiffInfo = getDiffCommitsInfo(ctx, absPath, pr)
+	ret.CanMerge = pr.PrStatus == pullrequestmd.PrOpenStatus && ret.HasDiffInfo && ret.DiffInfo.IsMergeAble()
+	if pr.PrStatus == pullrequestmd.PrOpenStatus && ret.HasDiffInfo {
+		ret.CodeOwnerList, err = getCodeOwnerApprovals(ctx, absPath, repo, pr, ret.DiffInfo)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return GetPullRequestDetailRespDTO{}, util.InternalError()
+		}
+	}
+	return ret, nil
+}
+
+// AddComment 添加代码行评论或回复评论
+func AddComment(ctx context.Context, reqDTO AddCommentReqDTO) (CommentDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return CommentDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	pr, repo, err := checkAccessPerm(ctx, reqDTO.PrId, reqDTO.Operator)
+	if err != nil {
+		return CommentDTO{}, err
+	}
+	insertReq := pullrequestmd.InsertCommentReqDTO{
+		PrId:      pr.PrId,
+		Commenter: reqDTO.Operator.Account,
+		Content:   reqDTO.Content,
+	}
+	if reqDTO.ReplyTo != "" {
+		// 回复评论 沿用评论串的定位信息
+		parent, b, err := pullrequestmd.GetByCid(ctx, reqDTO.ReplyTo)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return CommentDTO{}, util.InternalError()
+		}
+		if !b || parent.PrId != pr.PrId {
+			return CommentDTO{}, util.InvalidArgsError()
+		}
+		insertReq.ThreadId = parent.ThreadId
+		insertReq.FilePath = parent.FilePath
+		insertReq.Side = parent.Side
+		insertReq.LineNo = parent.LineNo
+		insertReq.LineText = parent.LineText
+		insertReq.CommitId = parent.CommitId
+	} else {
+		// 只有打开的合并请求才能新建评论
+		if pr.PrStatus != pullrequestmd.PrOpenStatus {
+			return CommentDTO{}, util.InvalidArgsError()
+		}
+		absPath := filepath.Join(setting.RepoDir(), repo.Path)
+		commitId, err := git.GetRefCommitId(ctx, absPath, pr.Target)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return CommentDTO{}, util.InvalidArgsError()
+		}
+		detail, err := git.GetDiffFileDetail(ctx, absPath, pr.Target, pr.Head, reqDTO.FilePath)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return CommentDTO{}, util.InternalError()
+		}
+		// 评论的行必须在差异中
+		line, b := findDiffLine(detail.Lines, reqDTO.Side, reqDTO.LineNo)
+		if !b {
+			return CommentDTO{}, util.InvalidArgsError()
+		}
+		insertReq.FilePath = reqDTO.FilePath
+		insertReq.Side = reqDTO.Side
+		insertReq.LineNo = reqDTO.LineNo
+		insertReq.LineText = line.Text
+		insertReq.CommitId = commitId
+	}
+	comment, err := pullrequestmd.InsertComment(ctx, insertReq)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return CommentDTO{}, util.InternalError()
+	}
+	return comment2Dto(comment), nil
+}
+
+// ListComment 展示代码行评论
+func ListComment(ctx context.Context, reqDTO ListCommentReqDTO) ([]CommentDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return nil, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	pr, repo, err := checkAccessPerm(ctx, reqDTO.PrId, reqDTO.Operator)
+	if err != nil {
+		return nil, err
+	}
+	if pr.PrStatus == pullrequestmd.PrOpenStatus {
+		// 检查评论是否过时 失败不影响展示
+		if err = markOutdatedComments(ctx, filepath.Join(setting.RepoDir(), repo.Path), pr); err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+		}
+	}
+	comments, err := pullrequestmd.ListComment(ctx, pr.PrId, reqDTO.FilePath)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return nil, util.InternalError()
+	}
+	ret, _ := listutil.Map(comments, func(t pullrequestmd.Comment) (CommentDTO, error) {
+		return comment2Dto(t), nil
+	})
+	return ret, nil
+}
+
+// ResolveCommentThread 解决或重新打开评论串
+func ResolveCommentThread(ctx context.Context, reqDTO ResolveCommentThreadReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	comment, b, err := pullrequestmd.GetByCid(ctx, reqDTO.ThreadId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	// 必须是评论串首条评论
+	if !b || comment.ThreadId != comment.Cid {
+		return util.InvalidArgsError()
+	}
+	if _, _, err = checkAccessPerm(ctx, comment.PrId, reqDTO.Operator); err != nil {
+		return err
+	}
+	resolvedBy := ""
+	if reqDTO.IsResolved {
+		resolvedBy = reqDTO.Operator.Account
+	}
+	if err = pullrequestmd.UpdateThreadResolved(ctx, comment.ThreadId, reqDTO.IsResolved, resolvedBy); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
+// SyncPullRequestOnPush 分支推送后同步相关的打开状态合并请求
+func SyncPullRequestOnPush(ctx context.Context, opts hook.Opts) error {
+	pushed := make(map[string]hook.RevInfo)
+	for _, info := range opts.RevInfoList {
+		if strings.HasPrefix(info.RefName, git.BranchPrefix) {
+			pushed[strings.TrimPrefix(info.RefName, git.BranchPrefix)] = info
+		}
+	}
+	if len(pushed) == 0 {
+		return nil
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, b, err := repomd.GetByRepoId(ctx, opts.RepoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if !b {
+		return util.InvalidArgsError()
+	}
+	branchList := make([]string, 0, len(pushed))
+	for branch := range pushed {
+		branchList = append(branchList, branch)
+	}
+	prList, err := pullrequestmd.ListOpenPullRequestByBranch(ctx, repo.RepoId, branchList)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	absPath := filepath.Join(setting.RepoDir(), repo.Path)
+	for _, pr := range prList {
+		// 合并请求自身合并产生的推送由MergePullRequest处理
+		if pr.PrId == opts.PrId {
+			continue
+		}
+		if err = syncPullRequest(ctx, absPath, pr, pushed, opts.PusherId); err != nil {
+			logger.Logger.WithContext(ctx).Errorf("sync pr: %s err: %v", pr.PrId, err)
+		}
+	}
+	return nil
+}
+
+func syncPullRequest(ctx context.Context, absPath string, pr pullrequestmd.PullRequest, pushed map[string]hook.RevInfo, pusher string) error {
+	targetInfo, targetPushed := pushed[pr.Target]
+	headInfo, headPushed := pushed[pr.Head]
+	// 分支被删除 自动关闭合并请求
+	if (targetPushed && targetInfo.NewCommitId == git.ZeroCommitId) ||
+		(headPushed && headInfo.NewCommitId == git.ZeroCommitId) {
+		b, err := pullrequestmd.UpdatePrStatus(ctx, pr.PrId, pullrequestmd.PrOpenStatus, pullrequestmd.PrClosedStatus)
+		if err == nil && b {
+			webhooksrv.TriggerPullRequestEvent(ctx, webhook.PrClosedEvent, pr, pusher)
+		}
+		return err
+	}
+	info, err := git.GetDiffCommitsInfo(ctx, absPath, pr.Target, pr.Head)
+	if err != nil {
+		return err
+	}
+	_, err = pullrequestmd.UpdatePrSyncInfo(ctx, pullrequestmd.UpdatePrSyncInfoReqDTO{
+		PrId:           pr.PrId,
+		TargetCommitId: info.TargetCommit.Id,
+		HeadCommitId:   info.HeadCommit.Id,
+		IsMergeAble:    info.IsMergeAble(),
+		HasConflict:    len(info.ConflictFiles) > 0,
+	})
+	if err != nil {
+		return err
+	}
+	// 源分支没有新提交
+	if !targetPushed {
+		return nil
+	}
+	cfg, isProtectedBranch, err := branchmd.IsProtectedBranch(ctx, pr.RepoId, pr.Head)
+	if err != nil {
+		return err
+	}
+	// 推送前的同意评审失效 评审记录保留
+	if isProtectedBranch && cfg.DismissStaleReviews {
+		if err = pullrequestmd.DismissReviewByStatus(ctx, pr.PrId, pullrequestmd.AgreeMergeStatus); err != nil {
+			return err
+		}
+	}
+	return markOutdatedComments(ctx, absPath, pr)
+}
+
+// markOutdatedComments 评论所在行在后续提交中发生变化则标记为过时
+func markOutdatedComments(ctx context.Context, absPath string, pr pullrequestmd.PullRequest) error {
+	roots, err := pullrequestmd.ListNotOutdatedThreadRootComment(ctx, pr.PrId)
+	if err != nil || len(roots) == 0 {
+		return err
+	}
+	commitId, err := git.GetRefCommitId(ctx, absPath, pr.Target)
+	if err != nil {
+		return err
+	}
+	fileComments := make(map[string][]pullrequestmd.Comment)
+	for _, root := range roots {
+		// 基于最新提交的评论不会过时
+		if root.CommitId != commitId {
+			fileComments[root.FilePath] = append(fileComments[root.FilePath], root)
+		}
+	}
+	outdated := make([]string, 0)
+	for filePath, comments := range fileComments {
+		detail, err := git.GetDiffFileDetail(ctx, absPath, pr.Target, pr.Head, filePath)
+		if err != nil {
+			return err
+		}
+		for _, comment := range comments {
+			line, b := findDiffLine(detail.Lines, comment.Side, comment.LineNo)
+			if !b || line.Text != comment.LineText {
+				outdated = append(outdated, comment.ThreadId)
+			}
+		}
+	}
+	if len(outdated) == 0 {
+		return nil
+	}
+	return pullrequestmd.UpdateThreadOutdated(ctx, outdated)
+}
+
+func findDiffLine(lines []git.DiffLine, side pullrequestmd.CommentSide, lineNo int) (git.DiffLine, bool) {
+	for _, line := range lines {
+		if side == pullrequestmd.LeftSide && line.LeftNo == lineNo {
+			return line, true
+		}
+		if side == pullrequestmd.RightSide && line.RightNo == lineNo {
+			return line, true
+		}
+	}
+	return git.DiffLine{}, false
+}
+
+func comment2Dto(comment pullrequestmd.Comment) CommentDTO {
+	return CommentDTO{
+		Cid:        comment.Cid,
+		ThreadId:   comment.ThreadId,
+		FilePath:   comment.FilePath,
+		Side:       comment.Side,
+		LineNo:     comment.LineNo,
+		LineText:   comment.LineText,
+		CommitId:   comment.CommitId,
+		Commenter:  comment.Commenter,
+		Content:    comment.Content,
+		IsResolved: comment.IsResolved,
+		ResolvedBy: comment.ResolvedBy,
+		IsOutdated: comment.IsOutdated,
+		Created:    comment.Created,
+	}
+}
+
 // checkPerm 校验权限
 func checkPerm(ctx context.Context, prId string, operator usermd.UserInfo) (pullrequestmd.PullRequest, repomd.Repo, error) {
 	pr, b, err := pullrequestmd.GetByPrId(ctx, prId)
@@ -241,26 +725,148 @@ func checkPerm(ctx context.Context, prId string, operator usermd.UserInfo) (pull
 	return pr, repo, err
 }
 
+// checkRequiredStatus 检查提交的必需状态是否都成功
+func checkRequiredStatus(ctx context.Context, repoId, commitId string, contexts []string) (bool, error) {
+	statusList, err := commitstatusmd.ListCommitStatus(ctx, repoId, commitId)
+	if err != nil {
+		return false, err
+	}
+	stateMap := make(map[string]commitstatusmd.State, len(statusList))
+	for _, status := range statusList {
+		stateMap[status.Context] = status.State
+	}
+	for _, statusContext := range contexts {
+		state, b := stateMap[statusContext]
+		if !b || state != commitstatusmd.SuccessState {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// checkAccessPerm 校验访问权限
+func checkAccessPerm(ctx context.Context, prId string, operator usermd.UserInfo) (pullrequestmd.PullRequest, repomd.Repo, error) {
+	pr, b, err := pullrequestmd.GetByPrId(ctx, prId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return pullrequestmd.PullRequest{}, repomd.Repo{}, util.InternalError()
+	}
+	if !b {
+		return pullrequestmd.PullRequest{}, repomd.Repo{}, util.InvalidArgsError()
+	}
+	repo, err := checkAccessPermByRepoId(ctx, pr.RepoId, operator)
+	return pr, repo, err
+}
+
 // checkPermByRepoId 校验权限
 func checkPermByRepoId(ctx context.Context, repoId string, operator usermd.UserInfo) (repomd.Repo, error) {
+	repo, p, err := getRepoPerm(ctx, repoId, operator)
+	if err != nil {
+		return repo, err
+	}
+	if !p.CanHandlePullRequest {
+		return repo, util.UnauthorizedError()
+	}
+	return repo, nil
+}
+
+// checkAccessPermByRepoId 校验仓库访问权限
+func checkAccessPermByRepoId(ctx context.Context, repoId string, operator usermd.UserInfo) (repomd.Repo, error) {
+	repo, p, err := getRepoPerm(ctx, repoId, operator)
+	if err != nil {
+		return repo, err
+	}
+	if !p.CanAccess {
+		return repo, util.UnauthorizedError()
+	}
+	return repo, nil
+}
+
+func getRepoPerm(ctx context.Context, repoId string, operator usermd.UserInfo) (repomd.Repo, perm.RepoPerm, error) {
 	repo, b, err := repomd.GetByRepoId(ctx, repoId)
 	if err != nil {
 		logger.Logger.WithContext(ctx).Error(err)
-		return repomd.Repo{}, util.InternalError()
+		return repomd.Repo{}, perm.RepoPerm{}, util.InternalError()
 	}
 	if !b {
-		return repomd.Repo{}, util.InvalidArgsError()
+		return repomd.Repo{}, perm.RepoPerm{}, util.InvalidArgsError()
 	}
 	p, b, err := projectmd.GetProjectUserPermDetail(ctx, repo.ProjectId, operator.Account)
 	if err != nil {
 		logger.Logger.WithContext(ctx).Error(err)
-		return repo, util.InternalError()
+		return repo, perm.RepoPerm{}, util.InternalError()
 	}
 	if !b {
-		return repo, util.InvalidArgsError()
+		return repo, perm.RepoPerm{}, util.InvalidArgsError()
 	}
-	if !p.PermDetail.GetRepoPerm(repoId).CanHandlePullRequest {
-		return repo, util.UnauthorizedError()
+	return repo, p.PermDetail.GetRepoPerm(repoId), nil
+}
+
+// listAccessibleRepoId 获取项目下可访问的仓库id
+func listAccessibleRepoId(ctx context.Context, projectId string, operator usermd.UserInfo) ([]string, error) {
+	p, b, err := projectmd.GetProjectUserPermDetail(ctx, projectId, operator.Account)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return nil, util.InternalError()
+	}
+	if !b {
+		return nil, util.UnauthorizedError()
+	}
+	// 项目管理员可看到所有仓库或者应用所有仓库权限配置
+	if p.IsAdmin || (p.PermDetail.ApplyDefaultRepoPerm && p.PermDetail.DefaultRepoPerm.CanAccess) {
+		repoList, err := repomd.ListAllRepo(ctx, projectId)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return nil, util.InternalError()
+		}
+		ret, _ := listutil.Map(repoList, func(t repomd.Repo) (string, error) {
+			return t.RepoId, nil
+		})
+		return ret, nil
+	}
+	if p.PermDetail.ApplyDefaultRepoPerm {
+		return []string{}, nil
+	}
+	permList, _ := listutil.Filter(p.PermDetail.RepoPermList, func(t perm.RepoPermWithId) (bool, error) {
+		return t.CanAccess, nil
+	})
+	ret, _ := listutil.Map(permList, func(t perm.RepoPermWithId) (string, error) {
+		return t.RepoId, nil
+	})
+	return ret, nil
+}
+
+// getDiffCommitsInfo 获取合并请求差异 未关闭的实时计算 已合并的使用合并时的提交
+func getDiffCommitsInfo(ctx context.Context, absPath string, pr pullrequestmd.PullRequest) (git.DiffCommitsInfo, bool) {
+	target, head := pr.Target, pr.Head
+	if pr.PrStatus != pullrequestmd.PrOpenStatus && pr.TargetCommitId != "" && pr.HeadCommitId != "" {
+		target, head = pr.TargetCommitId, pr.HeadCommitId
+	}
+	if !git.CheckExists(ctx, absPath, target) || !git.CheckExists(ctx, absPath, head) {
+		return git.DiffCommitsInfo{}, false
+	}
+	info, err := git.GetDiffCommitsInfo(ctx, absPath, target, head)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return git.DiffCommitsInfo{}, false
+	}
+	return info, true
+}
+
+func pr2Dto(pr pullrequestmd.PullRequest) PullRequestDTO {
+	return PullRequestDTO{
+		PrId:           pr.PrId,
+		RepoId:         pr.RepoId,
+		Title:          pr.Title,
+		Target:         pr.Target,
+		TargetCommitId: pr.TargetCommitId,
+		Head:           pr.Head,
+		HeadCommitId:   pr.HeadCommitId,
+		PrStatus:       pr.PrStatus,
+		IsMergeAble:    pr.IsMergeAble,
+		HasConflict:    pr.HasConflict,
+		CreateBy:       pr.CreateBy,
+		Created:        pr.Created,
+		Updated:        pr.Updated,
 	}
-	return repo, nil
 }
@@ -0,0 +1,98 @@
+package pushrulesrv
+
+import (
+	"regexp"
+	"zgit/pkg/apicode"
+	"zgit/pkg/i18n"
+	"zgit/standalone/modules/model/projectmd"
+	"zgit/standalone/modules/model/pushrulemd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+type SavePushRuleReqDTO struct {
+	ProjectId string
+	// 为空表示项目级别规则
+	RepoId   string
+	Cfg      pushrulemd.PushRuleCfg
+	Operator usermd.UserInfo
+}
+
+func (r *SavePushRuleReqDTO) IsValid() error {
+	if !projectmd.IsProjectIdValid(r.ProjectId) {
+		return util.InvalidArgsError()
+	}
+	if r.RepoId != "" && !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if len(r.Cfg.CommitMessagePattern) > 1024 {
+		return util.InvalidArgsError()
+	}
+	if r.Cfg.CommitMessagePattern != "" {
+		if _, err := regexp.Compile(r.Cfg.CommitMessagePattern); err != nil {
+			return util.NewBizErr(apicode.InvalidArgsCode, i18n.PushRuleInvalidCommitMessagePattern)
+		}
+	}
+	if r.Cfg.MaxBlobSize < 0 {
+		return util.InvalidArgsError()
+	}
+	if len(r.Cfg.ForbiddenPathList) > 50 || len(r.Cfg.AllowedNewBranchList) > 50 {
+		return util.InvalidArgsError()
+	}
+	for _, pattern := range r.Cfg.ForbiddenPathList {
+		if len(pattern) == 0 || len(pattern) > 255 {
+			return util.InvalidArgsError()
+		}
+	}
+	for _, pattern := range r.Cfg.AllowedNewBranchList {
+		if len(pattern) == 0 || len(pattern) > 255 {
+			return util.InvalidArgsError()
+		}
+	}
+	return nil
+}
+
+type GetPushRuleReqDTO struct {
+	ProjectId string
+	RepoId    string
+	Operator  usermd.UserInfo
+}
+
+func (r *GetPushRuleReqDTO) IsValid() error {
+	if !projectmd.IsProjectIdValid(r.ProjectId) {
+		return util.InvalidArgsError()
+	}
+	if r.RepoId != "" && !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type DeletePushRuleReqDTO struct {
+	RuleId   string
+	Operator usermd.UserInfo
+}
+
+func (r *DeletePushRuleReqDTO) IsValid() error {
+	if !pushrulemd.IsRuleIdValid(r.RuleId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type PushRuleDTO struct {
+	RuleId    string
+	ProjectId string
+	RepoId    string
+	Cfg       pushrulemd.PushRuleCfg
+}
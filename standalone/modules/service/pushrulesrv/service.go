@@ -0,0 +1,150 @@
+package pushrulesrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"zgit/standalone/modules/model/projectmd"
+	"zgit/standalone/modules/model/pushrulemd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+// SavePushRule 新增或更新推送规则
+func SavePushRule(ctx context.Context, reqDTO SavePushRuleReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	if err := checkManagePerm(ctx, reqDTO.ProjectId, reqDTO.RepoId, reqDTO.Operator); err != nil {
+		return err
+	}
+	rule, b, err := pushrulemd.GetPushRule(ctx, reqDTO.ProjectId, reqDTO.RepoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if b {
+		_, err = pushrulemd.UpdatePushRuleCfg(ctx, rule.RuleId, reqDTO.Cfg)
+	} else {
+		err = pushrulemd.InsertPushRule(ctx, pushrulemd.InsertPushRuleReqDTO{
+			ProjectId: reqDTO.ProjectId,
+			RepoId:    reqDTO.RepoId,
+			Cfg:       reqDTO.Cfg,
+		})
+	}
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
+// GetPushRule 获取推送规则
+func GetPushRule(ctx context.Context, reqDTO GetPushRuleReqDTO) (PushRuleDTO, bool, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return PushRuleDTO{}, false, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	if err := checkAccessPerm(ctx, reqDTO.ProjectId, reqDTO.RepoId, reqDTO.Operator); err != nil {
+		return PushRuleDTO{}, false, err
+	}
+	rule, b, err := pushrulemd.GetPushRule(ctx, reqDTO.ProjectId, reqDTO.RepoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return PushRuleDTO{}, false, util.InternalError()
+	}
+	return PushRuleDTO{
+		RuleId:    rule.RuleId,
+		ProjectId: rule.ProjectId,
+		RepoId:    rule.RepoId,
+		Cfg:       rule.Cfg,
+	}, b, nil
+}
+
+// DeletePushRule 删除推送规则
+func DeletePushRule(ctx context.Context, reqDTO DeletePushRuleReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	rule, b, err := pushrulemd.GetByRuleId(ctx, reqDTO.RuleId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if !b {
+		return util.InvalidArgsError()
+	}
+	if err = checkManagePerm(ctx, rule.ProjectId, rule.RepoId, reqDTO.Operator); err != nil {
+		return err
+	}
+	if _, err = pushrulemd.DeletePushRule(ctx, rule.RuleId); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
+// checkManagePerm 仓库级别规则需有管理保护分支权限 项目级别规则需是项目管理员
+func checkManagePerm(ctx context.Context, projectId, repoId string, operator usermd.UserInfo) error {
+	if err := checkRepoBelongToProject(ctx, projectId, repoId); err != nil {
+		return err
+	}
+	detail, b, err := projectmd.GetProjectUserPermDetail(ctx, projectId, operator.Account)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if !b {
+		return util.UnauthorizedError()
+	}
+	if repoId == "" {
+		if !detail.IsAdmin {
+			return util.UnauthorizedError()
+		}
+		return nil
+	}
+	if !detail.PermDetail.GetRepoPerm(repoId).CanHandleProtectedBranch {
+		return util.UnauthorizedError()
+	}
+	return nil
+}
+
+// checkAccessPerm 项目成员均可查看
+func checkAccessPerm(ctx context.Context, projectId, repoId string, operator usermd.UserInfo) error {
+	if err := checkRepoBelongToProject(ctx, projectId, repoId); err != nil {
+		return err
+	}
+	detail, b, err := projectmd.GetProjectUserPermDetail(ctx, projectId, operator.Account)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if !b {
+		return util.UnauthorizedError()
+	}
+	if repoId != "" && !detail.PermDetail.GetRepoPerm(repoId).CanAccess {
+		return util.UnauthorizedError()
+	}
+	return nil
+}
+
+func checkRepoBelongToProject(ctx context.Context, projectId, repoId string) error {
+	if repoId == "" {
+		return nil
+	}
+	repo, b, err := repomd.GetByRepoId(ctx, repoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if !b || repo.ProjectId != projectId {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package quotasrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf/logger"
+	"strconv"
+	"zgit/metadata/modules/model/corpmd"
+	"zgit/pkg/apicode"
+	"zgit/pkg/i18n"
+	"zgit/setting"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/util"
+)
+
+// CheckGitQuota 检查新增git数据后是否超过仓库和企业配额
+func CheckGitQuota(ctx context.Context, repo repomd.Repo, incrSize int64) error {
+	if incrSize <= 0 {
+		return nil
+	}
+	cfg := repo.GetCfg()
+	// wiki大小同样计入git配额
+	if cfg.MaxGitLimitSize > 0 && repo.GitSize+repo.WikiSize+incrSize > cfg.MaxGitLimitSize {
+		return util.NewBizErr(apicode.QuotaExceededCode, i18n.QuotaRepoGitSizeExceedWarnFormat, util.VolumeReadable(cfg.MaxGitLimitSize))
+	}
+	corp, b, err := getCorp(ctx)
+	if err != nil || !b || corp.MaxGitSize <= 0 {
+		return err
+	}
+	gitSize, _, err := repomd.SumGitAndLfsSize(ctx)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if gitSize+incrSize > int64(corp.MaxGitSize) {
+		return util.NewBizErr(apicode.QuotaExceededCode, i18n.QuotaCorpGitSizeExceedWarnFormat, util.VolumeReadable(int64(corp.MaxGitSize)))
+	}
+	return nil
+}
+
+// CheckLfsQuota 检查新增lfs文件后是否超过仓库和企业配额
+func CheckLfsQuota(ctx context.Context, repo repomd.Repo, incrSize int64) error {
+	if incrSize <= 0 {
+		return nil
+	}
+	cfg := repo.GetCfg()
+	if cfg.MaxLfsLimitSize > 0 && repo.LfsSize+incrSize > cfg.MaxLfsLimitSize {
+		return util.NewBizErr(apicode.QuotaExceededCode, i18n.QuotaRepoLfsSizeExceedWarnFormat, util.VolumeReadable(cfg.MaxLfsLimitSize))
+	}
+	corp, b, err := getCorp(ctx)
+	if err != nil || !b || corp.MaxLfsSize <= 0 {
+		return err
+	}
+	_, lfsSize, err := repomd.SumGitAndLfsSize(ctx)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if lfsSize+incrSize > int64(corp.MaxLfsSize) {
+		return util.NewBizErr(apicode.QuotaExceededCode, i18n.QuotaCorpLfsSizeExceedWarnFormat, util.VolumeReadable(int64(corp.MaxLfsSize)))
+	}
+	return nil
+}
+
+// ReserveLfsQuota 先原子增加lfs大小占用配额 再以增加后的大小检查配额 超出时回滚
+// 并发上传时后写入者能看到先写入者的占用 不会共同超出配额
+func ReserveLfsQuota(ctx context.Context, repoId string, incrSize int64) error {
+	if incrSize <= 0 {
+		return nil
+	}
+	if err := repomd.IncrLfsSize(ctx, repoId, incrSize); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	err := checkReservedLfsQuota(ctx, repoId)
+	if err != nil {
+		ReleaseLfsQuota(ctx, repoId, incrSize)
+	}
+	return err
+}
+
+// ReleaseLfsQuota 释放已占用的lfs配额
+func ReleaseLfsQuota(ctx context.Context, repoId string, incrSize int64) {
+	if err := repomd.IncrLfsSize(ctx, repoId, -incrSize); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+	}
+}
+
+func checkReservedLfsQuota(ctx context.Context, repoId string) error {
+	repo, b, err := repomd.GetByRepoId(ctx, repoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if !b {
+		return util.InvalidArgsError()
+	}
+	cfg := repo.GetCfg()
+	if cfg.MaxLfsLimitSize > 0 && repo.LfsSize > cfg.MaxLfsLimitSize {
+		return util.NewBizErr(apicode.QuotaExceededCode, i18n.QuotaRepoLfsSizeExceedWarnFormat, util.VolumeReadable(cfg.MaxLfsLimitSize))
+	}
+	corp, b, err := getCorp(ctx)
+	if err != nil || !b || corp.MaxLfsSize <= 0 {
+		return err
+	}
+	_, lfsSize, err := repomd.SumGitAndLfsSize(ctx)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if lfsSize > int64(corp.MaxLfsSize) {
+		return util.NewBizErr(apicode.QuotaExceededCode, i18n.QuotaCorpLfsSizeExceedWarnFormat, util.VolumeReadable(int64(corp.MaxLfsSize)))
+	}
+	return nil
+}
+
+// CheckRepoCountQuota 检查仓库数量是否已达企业上限
+func CheckRepoCountQuota(ctx context.Context) error {
+	corp, b, err := getCorp(ctx)
+	if err != nil || !b || corp.RepoLimit <= 0 {
+		return err
+	}
+	count, err := repomd.CountRepo(ctx)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if count >= int64(corp.RepoLimit) {
+		return util.NewBizErr(apicode.QuotaExceededCode, i18n.QuotaRepoCountExceedWarnFormat, strconv.Itoa(corp.RepoLimit))
+	}
+	return nil
+}
+
+// getCorp 单机模式下企业为配置的corpId 未配置企业信息时不限制
+func getCorp(ctx context.Context) (corpmd.Corp, bool, error) {
+	corp, b, err := corpmd.GetByCorpId(ctx, setting.StandaloneCorpId())
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return corpmd.Corp{}, false, util.InternalError()
+	}
+	return corp, b, nil
+}
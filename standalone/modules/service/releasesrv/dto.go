@@ -0,0 +1,269 @@
+package releasesrv
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"zgit/standalone/modules/model/releasemd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+const (
+	// 单个附件最大大小 仓库配置了lfs单文件限制时取较小值
+	maxAssetSize = 2 * 1024 * 1024 * 1024
+	// 单个版本最多附件数
+	maxAssetCount = 50
+	// 变更记录最多展示的提交数
+	changelogMaxCommits = 500
+)
+
+var (
+	validTagNamePattern = regexp.MustCompile("^\\w[\\w\\-.]{0,63}(/[\\w\\-.]{1,64}){0,3}$")
+)
+
+type CreateReleaseReqDTO struct {
+	RepoId  string
+	TagName string
+	// 标签不存在时基于该分支或提交创建
+	Target       string
+	Title        string
+	Note         string
+	IsDraft      bool
+	IsPrerelease bool
+	// 在说明后追加自上一个标签以来的提交记录
+	GenerateChangelog bool
+	Operator          usermd.UserInfo
+}
+
+func (r *CreateReleaseReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !validateTagName(r.TagName) {
+		return util.InvalidArgsError()
+	}
+	if r.Target != "" && (!util.ValidateRef(r.Target) || strings.HasPrefix(r.Target, "-")) {
+		return util.InvalidArgsError()
+	}
+	if len(r.Title) == 0 || len(r.Title) > 255 {
+		return util.InvalidArgsError()
+	}
+	if len(r.Note) > 64*1024 {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type UpdateReleaseReqDTO struct {
+	ReleaseId    string
+	Title        string
+	Note         string
+	IsDraft      bool
+	IsPrerelease bool
+	Operator     usermd.UserInfo
+}
+
+func (r *UpdateReleaseReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !releasemd.IsReleaseIdValid(r.ReleaseId) {
+		return util.InvalidArgsError()
+	}
+	if len(r.Title) == 0 || len(r.Title) > 255 {
+		return util.InvalidArgsError()
+	}
+	if len(r.Note) > 64*1024 {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type DeleteReleaseReqDTO struct {
+	ReleaseId string
+	Operator  usermd.UserInfo
+}
+
+func (r *DeleteReleaseReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !releasemd.IsReleaseIdValid(r.ReleaseId) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type GetReleaseReqDTO struct {
+	ReleaseId string
+	Operator  usermd.UserInfo
+}
+
+func (r *GetReleaseReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !releasemd.IsReleaseIdValid(r.ReleaseId) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type ListReleaseReqDTO struct {
+	RepoId   string
+	Cursor   int64
+	Limit    int
+	Operator usermd.UserInfo
+}
+
+func (r *ListReleaseReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if r.Cursor < 0 || r.Limit <= 0 || r.Limit > 100 {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type ListReleaseRespDTO struct {
+	Releases []ReleaseDTO
+	Cursor   int64
+}
+
+type GenerateChangelogReqDTO struct {
+	RepoId string
+	// 标签存在时以标签为准 否则使用Target
+	TagName  string
+	Target   string
+	Operator usermd.UserInfo
+}
+
+func (r *GenerateChangelogReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !validateTagName(r.TagName) {
+		return util.InvalidArgsError()
+	}
+	if r.Target != "" && (!util.ValidateRef(r.Target) || strings.HasPrefix(r.Target, "-")) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type UploadAssetReqDTO struct {
+	ReleaseId string
+	Name      string
+	Size      int64
+	Body      io.Reader
+	Operator  usermd.UserInfo
+}
+
+func (r *UploadAssetReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !releasemd.IsReleaseIdValid(r.ReleaseId) {
+		return util.InvalidArgsError()
+	}
+	if !validateAssetName(r.Name) {
+		return util.InvalidArgsError()
+	}
+	if r.Size <= 0 || r.Body == nil {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type DeleteAssetReqDTO struct {
+	AssetId  string
+	Operator usermd.UserInfo
+}
+
+func (r *DeleteAssetReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !releasemd.IsAssetIdValid(r.AssetId) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type DownloadAssetReqDTO struct {
+	AssetId  string
+	Operator usermd.UserInfo
+}
+
+func (r *DownloadAssetReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !releasemd.IsAssetIdValid(r.AssetId) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type DownloadAssetRespDTO struct {
+	io.ReadCloser
+	Name string
+	Size int64
+}
+
+type ReleaseDTO struct {
+	ReleaseId    string
+	RepoId       string
+	TagName      string
+	Target       string
+	Title        string
+	Note         string
+	NoteHtml     string
+	IsDraft      bool
+	IsPrerelease bool
+	// 草稿未创建标签时为空
+	CommitId string
+	ShortId  string
+	Assets   []AssetDTO
+	CreateBy string
+	Created  time.Time
+}
+
+type AssetDTO struct {
+	AssetId  string
+	Name     string
+	Size     int64
+	CreateBy string
+	Created  time.Time
+}
+
+func validateTagName(name string) bool {
+	return validTagNamePattern.MatchString(name) &&
+		!strings.Contains(name, "..") &&
+		!strings.HasSuffix(name, ".") &&
+		!strings.HasSuffix(name, ".lock")
+}
+
+// validateAssetName 附件名作为下载文件名 不允许路径和控制字符
+func validateAssetName(name string) bool {
+	if len(name) == 0 || len(name) > 255 || name == "." || name == ".." {
+		return false
+	}
+	return strings.IndexFunc(name, func(r rune) bool {
+		return r == '/' || r == '\\' || r == '"' || unicode.IsControl(r)
+	}) < 0
+}
@@ -0,0 +1,574 @@
+package releasesrv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"io"
+	"path/filepath"
+	"strings"
+	"zgit/pkg/apicode"
+	"zgit/pkg/git"
+	"zgit/pkg/git/lfs"
+	"zgit/pkg/i18n"
+	"zgit/pkg/markdown"
+	"zgit/pkg/perm"
+	"zgit/setting"
+	"zgit/standalone/modules/model/projectmd"
+	"zgit/standalone/modules/model/releasemd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/standalone/modules/service/quotasrv"
+	"zgit/util"
+)
+
+const (
+	// 附件在lfs存储中仓库目录下的子目录 lfs对象按oid前两位分目录 不会冲突
+	assetDirName = "releases"
+)
+
+// CreateRelease 创建发布版本 标签不存在时基于target创建 正式发布时才创建标签
+func CreateRelease(ctx context.Context, reqDTO CreateReleaseReqDTO) (ReleaseDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return ReleaseDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return ReleaseDTO{}, err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanPush {
+		return ReleaseDTO{}, util.UnauthorizedError()
+	}
+	if repo.IsEmpty {
+		return ReleaseDTO{}, util.InvalidArgsError()
+	}
+	_, b, err := releasemd.GetByTagName(ctx, repo.RepoId, reqDTO.TagName)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ReleaseDTO{}, util.InternalError()
+	}
+	if b {
+		return ReleaseDTO{}, util.NewBizErr(apicode.ReleaseAlreadyExistsCode, i18n.ReleaseAlreadyExists)
+	}
+	absPath := filepath.Join(setting.RepoDir(), repo.Path)
+	tagExists := git.CheckRefIsTag(ctx, absPath, reqDTO.TagName)
+	if tagExists {
+		// 以已有标签为准
+		reqDTO.Target = ""
+	} else if reqDTO.Target == "" || !git.CheckExists(ctx, absPath, reqDTO.Target+"^{commit}") {
+		return ReleaseDTO{}, util.InvalidArgsError()
+	}
+	if reqDTO.GenerateChangelog {
+		head := reqDTO.Target
+		if tagExists {
+			head = git.TagPrefix + reqDTO.TagName
+		}
+		changelog, err := generateChangelog(ctx, absPath, head)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return ReleaseDTO{}, util.InternalError()
+		}
+		if reqDTO.Note != "" {
+			reqDTO.Note += "\n\n"
+		}
+		reqDTO.Note += changelog
+	}
+	if !tagExists && !reqDTO.IsDraft {
+		if err = createTag(ctx, repo, reqDTO.TagName, reqDTO.Target, reqDTO.Operator); err != nil {
+			return ReleaseDTO{}, err
+		}
+	}
+	release, err := releasemd.InsertRelease(ctx, releasemd.InsertReleaseReqDTO{
+		RepoId:       repo.RepoId,
+		TagName:      reqDTO.TagName,
+		Target:       reqDTO.Target,
+		Title:        reqDTO.Title,
+		Note:         reqDTO.Note,
+		IsDraft:      reqDTO.IsDraft,
+		IsPrerelease: reqDTO.IsPrerelease,
+		CreateBy:     reqDTO.Operator.Account,
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ReleaseDTO{}, util.InternalError()
+	}
+	return release2Dto(ctx, absPath, release, nil), nil
+}
+
+// UpdateRelease 编辑发布版本 草稿转为正式发布时创建标签
+func UpdateRelease(ctx context.Context, reqDTO UpdateReleaseReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	release, repo, err := checkReleasePushPerm(ctx, reqDTO.ReleaseId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	if release.IsDraft && !reqDTO.IsDraft {
+		absPath := filepath.Join(setting.RepoDir(), repo.Path)
+		if !git.CheckRefIsTag(ctx, absPath, release.TagName) {
+			if release.Target == "" || !git.CheckExists(ctx, absPath, release.Target+"^{commit}") {
+				return util.InvalidArgsError()
+			}
+			if err = createTag(ctx, repo, release.TagName, release.Target, reqDTO.Operator); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = releasemd.UpdateRelease(ctx, releasemd.UpdateReleaseReqDTO{
+		ReleaseId:    release.ReleaseId,
+		Title:        reqDTO.Title,
+		Note:         reqDTO.Note,
+		IsDraft:      reqDTO.IsDraft,
+		IsPrerelease: reqDTO.IsPrerelease,
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
+// DeleteRelease 删除发布版本和附件 标签保留
+func DeleteRelease(ctx context.Context, reqDTO DeleteReleaseReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	release, repo, err := checkReleasePushPerm(ctx, reqDTO.ReleaseId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	assets, err := releasemd.ListAssetByReleaseIdList(ctx, []string{release.ReleaseId})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if err = mysqlstore.WithTx(ctx, func(ctx context.Context) error {
+		_, err := releasemd.DeleteRelease(ctx, release.ReleaseId)
+		if err != nil {
+			return err
+		}
+		return releasemd.DeleteAssetByReleaseId(ctx, release.ReleaseId)
+	}); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	var totalSize int64
+	for _, asset := range assets {
+		if err = lfs.StorageImpl.Delete(ctx, getAssetPath(repo, asset.AssetId)); err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+		}
+		totalSize += asset.Size
+	}
+	if totalSize > 0 {
+		if err = repomd.IncrLfsSize(ctx, repo.RepoId, -totalSize); err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+		}
+	}
+	return nil
+}
+
+// GetRelease 发布版本详情 草稿需要推送权限
+func GetRelease(ctx context.Context, reqDTO GetReleaseReqDTO) (ReleaseDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return ReleaseDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	release, b, err := releasemd.GetByReleaseId(ctx, reqDTO.ReleaseId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ReleaseDTO{}, util.InternalError()
+	}
+	if !b {
+		return ReleaseDTO{}, util.InvalidArgsError()
+	}
+	repo, p, err := getPerm(ctx, release.RepoId, reqDTO.Operator)
+	if err != nil {
+		return ReleaseDTO{}, err
+	}
+	repoPerm := p.GetRepoPerm(repo.RepoId)
+	if !repoPerm.CanAccess || (release.IsDraft && !repoPerm.CanPush) {
+		return ReleaseDTO{}, util.UnauthorizedError()
+	}
+	assets, err := releasemd.ListAssetByReleaseIdList(ctx, []string{release.ReleaseId})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ReleaseDTO{}, util.InternalError()
+	}
+	return release2Dto(ctx, filepath.Join(setting.RepoDir(), repo.Path), release, assets), nil
+}
+
+// ListRelease 发布版本列表 有推送权限才展示草稿
+func ListRelease(ctx context.Context, reqDTO ListReleaseReqDTO) (ListReleaseRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return ListReleaseRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return ListReleaseRespDTO{}, err
+	}
+	repoPerm := p.GetRepoPerm(repo.RepoId)
+	if !repoPerm.CanAccess {
+		return ListReleaseRespDTO{}, util.UnauthorizedError()
+	}
+	releases, err := releasemd.ListRelease(ctx, releasemd.ListReleaseReqDTO{
+		RepoId:    repo.RepoId,
+		WithDraft: repoPerm.CanPush,
+		Offset:    reqDTO.Cursor,
+		Limit:     reqDTO.Limit,
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ListReleaseRespDTO{}, util.InternalError()
+	}
+	releaseIdList, _ := listutil.Map(releases, func(t releasemd.Release) (string, error) {
+		return t.ReleaseId, nil
+	})
+	assets, err := releasemd.ListAssetByReleaseIdList(ctx, releaseIdList)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ListReleaseRespDTO{}, util.InternalError()
+	}
+	assetMap := make(map[string][]releasemd.Asset, len(releases))
+	for _, asset := range assets {
+		assetMap[asset.ReleaseId] = append(assetMap[asset.ReleaseId], asset)
+	}
+	absPath := filepath.Join(setting.RepoDir(), repo.Path)
+	ret := ListReleaseRespDTO{}
+	ret.Releases, _ = listutil.Map(releases, func(t releasemd.Release) (ReleaseDTO, error) {
+		return release2Dto(ctx, absPath, t, assetMap[t.ReleaseId]), nil
+	})
+	if len(releases) > 0 {
+		ret.Cursor = releases[len(releases)-1].Id
+	}
+	return ret, nil
+}
+
+// GenerateChangelog 预览自上一个标签以来的提交记录
+func GenerateChangelog(ctx context.Context, reqDTO GenerateChangelogReqDTO) (string, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return "", err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return "", err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanAccess {
+		return "", util.UnauthorizedError()
+	}
+	absPath := filepath.Join(setting.RepoDir(), repo.Path)
+	head := reqDTO.Target
+	if git.CheckRefIsTag(ctx, absPath, reqDTO.TagName) {
+		head = git.TagPrefix + reqDTO.TagName
+	} else if head == "" || !git.CheckExists(ctx, absPath, head+"^{commit}") {
+		return "", util.InvalidArgsError()
+	}
+	changelog, err := generateChangelog(ctx, absPath, head)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return "", util.InternalError()
+	}
+	return changelog, nil
+}
+
+// UploadAsset 上传附件 存储在lfs存储中 计入仓库lfs大小
+func UploadAsset(ctx context.Context, reqDTO UploadAssetReqDTO) (AssetDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return AssetDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	release, repo, err := checkReleasePushPerm(ctx, reqDTO.ReleaseId, reqDTO.Operator)
+	if err != nil {
+		return AssetDTO{}, err
+	}
+	sizeLimit := int64(maxAssetSize)
+	if cfg := repo.GetCfg(); cfg.SingleLfsFileLimitSize > 0 && cfg.SingleLfsFileLimitSize < sizeLimit {
+		sizeLimit = cfg.SingleLfsFileLimitSize
+	}
+	if reqDTO.Size > sizeLimit {
+		return AssetDTO{}, util.NewBizErr(apicode.InvalidArgsCode, i18n.ReleaseAssetTooLarge)
+	}
+	// 提前检查 避免超出配额时仍接收上传内容
+	if err = quotasrv.CheckLfsQuota(ctx, repo, reqDTO.Size); err != nil {
+		return AssetDTO{}, err
+	}
+	assets, err := releasemd.ListAssetByReleaseIdList(ctx, []string{release.ReleaseId})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return AssetDTO{}, util.InternalError()
+	}
+	if len(assets) >= maxAssetCount {
+		return AssetDTO{}, util.InvalidArgsError()
+	}
+	for _, asset := range assets {
+		if asset.Name == reqDTO.Name {
+			return AssetDTO{}, util.NewBizErr(apicode.ReleaseAssetConflictCode, i18n.ReleaseAssetNameConflict)
+		}
+	}
+	// 保存前按声明大小占用配额 失败时释放
+	if err = quotasrv.ReserveLfsQuota(ctx, repo.RepoId, reqDTO.Size); err != nil {
+		return AssetDTO{}, err
+	}
+	assetId := releasemd.GenAssetId()
+	assetPath := getAssetPath(repo, assetId)
+	// 多读一个字节 判断实际大小是否超出声明的大小
+	size, err := lfs.StorageImpl.Save(ctx, assetPath, io.LimitReader(reqDTO.Body, reqDTO.Size+1))
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		quotasrv.ReleaseLfsQuota(ctx, repo.RepoId, reqDTO.Size)
+		return AssetDTO{}, util.InternalError()
+	}
+	if size != reqDTO.Size {
+		lfs.StorageImpl.Delete(ctx, assetPath)
+		quotasrv.ReleaseLfsQuota(ctx, repo.RepoId, reqDTO.Size)
+		return AssetDTO{}, util.InvalidArgsError()
+	}
+	asset, err := releasemd.InsertAsset(ctx, releasemd.InsertAssetReqDTO{
+		AssetId:   assetId,
+		ReleaseId: release.ReleaseId,
+		RepoId:    repo.RepoId,
+		Name:      reqDTO.Name,
+		Size:      size,
+		CreateBy:  reqDTO.Operator.Account,
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		lfs.StorageImpl.Delete(ctx, assetPath)
+		quotasrv.ReleaseLfsQuota(ctx, repo.RepoId, reqDTO.Size)
+		return AssetDTO{}, util.InternalError()
+	}
+	return asset2Dto(asset), nil
+}
+
+// DeleteAsset 删除附件
+func DeleteAsset(ctx context.Context, reqDTO DeleteAssetReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	asset, b, err := releasemd.GetAssetByAssetId(ctx, reqDTO.AssetId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if !b {
+		return util.InvalidArgsError()
+	}
+	_, repo, err := checkReleasePushPerm(ctx, asset.ReleaseId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	b, err = releasemd.DeleteAsset(ctx, asset.AssetId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	// 并发删除时只处理一次
+	if !b {
+		return nil
+	}
+	if err = lfs.StorageImpl.Delete(ctx, getAssetPath(repo, asset.AssetId)); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+	}
+	if err = repomd.IncrLfsSize(ctx, repo.RepoId, -asset.Size); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+	}
+	return nil
+}
+
+// DownloadAsset 下载附件 调用方负责关闭
+func DownloadAsset(ctx context.Context, reqDTO DownloadAssetReqDTO) (DownloadAssetRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return DownloadAssetRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	asset, b, err := releasemd.GetAssetByAssetId(ctx, reqDTO.AssetId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return DownloadAssetRespDTO{}, util.InternalError()
+	}
+	if !b {
+		return DownloadAssetRespDTO{}, util.InvalidArgsError()
+	}
+	release, b, err := releasemd.GetByReleaseId(ctx, asset.ReleaseId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return DownloadAssetRespDTO{}, util.InternalError()
+	}
+	if !b {
+		return DownloadAssetRespDTO{}, util.InvalidArgsError()
+	}
+	repo, p, err := getPerm(ctx, release.RepoId, reqDTO.Operator)
+	if err != nil {
+		return DownloadAssetRespDTO{}, err
+	}
+	repoPerm := p.GetRepoPerm(repo.RepoId)
+	if !repoPerm.CanAccess || (release.IsDraft && !repoPerm.CanPush) {
+		return DownloadAssetRespDTO{}, util.UnauthorizedError()
+	}
+	object, err := lfs.StorageImpl.Open(ctx, getAssetPath(repo, asset.AssetId))
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return DownloadAssetRespDTO{}, util.InternalError()
+	}
+	return DownloadAssetRespDTO{
+		ReadCloser: object,
+		Name:       asset.Name,
+		Size:       asset.Size,
+	}, nil
+}
+
+// GetAssetDir 仓库所有附件在lfs存储中的目录
+func GetAssetDir(repo repomd.Repo) string {
+	return filepath.Join(repo.Path, assetDirName)
+}
+
+func getAssetPath(repo repomd.Repo, assetId string) string {
+	return filepath.Join(GetAssetDir(repo), assetId)
+}
+
+// generateChangelog 上一个标签到head之间的提交 没有上一个标签则从头开始
+func generateChangelog(ctx context.Context, repoPath, head string) (string, error) {
+	prevTag, b, err := git.GetPreviousTag(ctx, repoPath, head)
+	if err != nil {
+		return "", err
+	}
+	ref := head
+	if b {
+		ref = git.TagPrefix + prevTag + ".." + head
+	}
+	commits, err := git.ListCommits(ctx, repoPath, git.ListCommitsOpts{
+		Ref:   ref,
+		Limit: changelogMaxCommits,
+	})
+	if err != nil {
+		return "", err
+	}
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "## %s\n\n", i18n.GetByKey(i18n.ReleaseChangelogTitle))
+	for _, commit := range commits {
+		title, _, _ := strings.Cut(strings.TrimSpace(commit.CommitMsg), "\n")
+		fmt.Fprintf(&sb, "- %s (%s) @%s\n", title, util.LongCommitId2ShortId(commit.Id), commit.Author.Account)
+	}
+	if b {
+		fmt.Fprintf(&sb, "\n%s: %s...%s\n", i18n.GetByKey(i18n.ReleaseChangelogCompare), prevTag, strings.TrimPrefix(head, git.TagPrefix))
+	}
+	return sb.String(), nil
+}
+
+// createTag 发布时创建轻量标签 经过pre-receive 保护标签规则同样生效
+func createTag(ctx context.Context, repo repomd.Repo, tagName, target string, operator usermd.UserInfo) error {
+	absPath := filepath.Join(setting.RepoDir(), repo.Path)
+	commitId, err := git.GetRefCommitId(ctx, absPath, target+"^{commit}")
+	if err != nil {
+		return util.InvalidArgsError()
+	}
+	err = git.CreateTag(ctx, absPath, git.CreateTagOpts{
+		PushRefOpts: git.PushRefOpts{
+			RepoId:   repo.RepoId,
+			PusherId: operator.Account,
+		},
+		Tag:      tagName,
+		CommitId: commitId,
+	})
+	if err == nil {
+		return nil
+	}
+	var rejectedErr *git.ErrPushRejected
+	if errors.As(err, &rejectedErr) {
+		return util.NewBizErr(apicode.PushRejectedCode, i18n.RepoPushRejectedWarnFormat, rejectedErr.Error())
+	}
+	logger.Logger.WithContext(ctx).Error(err)
+	return util.InternalError()
+}
+
+func checkReleasePushPerm(ctx context.Context, releaseId string, operator usermd.UserInfo) (releasemd.Release, repomd.Repo, error) {
+	release, b, err := releasemd.GetByReleaseId(ctx, releaseId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return releasemd.Release{}, repomd.Repo{}, util.InternalError()
+	}
+	if !b {
+		return releasemd.Release{}, repomd.Repo{}, util.InvalidArgsError()
+	}
+	repo, p, err := getPerm(ctx, release.RepoId, operator)
+	if err != nil {
+		return releasemd.Release{}, repomd.Repo{}, err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanPush {
+		return releasemd.Release{}, repomd.Repo{}, util.UnauthorizedError()
+	}
+	return release, repo, nil
+}
+
+func release2Dto(ctx context.Context, repoPath string, release releasemd.Release, assets []releasemd.Asset) ReleaseDTO {
+	ret := ReleaseDTO{
+		ReleaseId:    release.ReleaseId,
+		RepoId:       release.RepoId,
+		TagName:      release.TagName,
+		Target:       release.Target,
+		Title:        release.Title,
+		Note:         release.Note,
+		NoteHtml:     markdown.Render(release.Note),
+		IsDraft:      release.IsDraft,
+		IsPrerelease: release.IsPrerelease,
+		CreateBy:     release.CreateBy,
+		Created:      release.Created,
+	}
+	// 标签可能已被删除或尚未创建
+	if commit, err := git.GetCommitByTag(ctx, repoPath, git.TagPrefix+release.TagName); err == nil {
+		ret.CommitId = commit.Id
+		ret.ShortId = util.LongCommitId2ShortId(commit.Id)
+	}
+	ret.Assets, _ = listutil.Map(assets, func(t releasemd.Asset) (AssetDTO, error) {
+		return asset2Dto(t), nil
+	})
+	return ret
+}
+
+func asset2Dto(asset releasemd.Asset) AssetDTO {
+	return AssetDTO{
+		AssetId:  asset.AssetId,
+		Name:     asset.Name,
+		Size:     asset.Size,
+		CreateBy: asset.CreateBy,
+		Created:  asset.Created,
+	}
+}
+
+func getPerm(ctx context.Context, repoId string, operator usermd.UserInfo) (repomd.Repo, perm.Detail, error) {
+	repo, b, err := repomd.GetByRepoId(ctx, repoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return repomd.Repo{}, perm.Detail{}, util.InternalError()
+	}
+	if !b {
+		return repomd.Repo{}, perm.Detail{}, util.InvalidArgsError()
+	}
+	p, b, err := projectmd.GetProjectUserPermDetail(ctx, repo.ProjectId, operator.Account)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return repo, perm.Detail{}, util.InternalError()
+	}
+	if !b {
+		return repo, perm.Detail{}, util.UnauthorizedError()
+	}
+	return repo, p.PermDetail, nil
+}
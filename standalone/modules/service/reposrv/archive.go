@@ -0,0 +1,119 @@
+package reposrv
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/LeeZXin/zsf-utils/idutil"
+	"github.com/LeeZXin/zsf-utils/taskutil"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"zgit/pkg/git"
+	"zgit/setting"
+	"zgit/util"
+)
+
+const (
+	// 压缩包缓存多久未访问则删除
+	archiveCacheExpiry = 7 * 24 * time.Hour
+	// 生成压缩包超时时间
+	archiveTimeout = 10 * time.Minute
+)
+
+var (
+	archiveCleanTask *taskutil.PeriodicalTask
+)
+
+// Archive 打包仓库代码 同一棵树的压缩包会被缓存
+func Archive(ctx context.Context, reqDTO ArchiveReqDTO) (ArchiveRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return ArchiveRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return ArchiveRespDTO{}, err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanAccess {
+		return ArchiveRespDTO{}, util.UnauthorizedError()
+	}
+	if repo.IsEmpty {
+		return ArchiveRespDTO{}, util.InvalidArgsError()
+	}
+	absPath := filepath.Join(setting.RepoDir(), repo.Path)
+	commitId, treeId, err := git.GetRefTreeId(ctx, absPath, reqDTO.RefName)
+	if err != nil {
+		return ArchiveRespDTO{}, util.InvalidArgsError()
+	}
+	ret := ArchiveRespDTO{
+		FilePath: filepath.Join(setting.ArchiveDir(), repo.RepoId, archiveCacheKey(treeId, reqDTO.Prefix)+"."+string(reqDTO.Format)),
+		FileName: fmt.Sprintf("%s-%s.%s", repo.Name, strings.ReplaceAll(reqDTO.RefName, "/", "-"), reqDTO.Format),
+	}
+	// 命中缓存 刷新访问时间
+	if _, err = os.Stat(ret.FilePath); err == nil {
+		now := time.Now()
+		os.Chtimes(ret.FilePath, now, now)
+		return ret, nil
+	}
+	if err = os.MkdirAll(filepath.Dir(ret.FilePath), os.ModePerm); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ArchiveRespDTO{}, util.InternalError()
+	}
+	// 先写临时文件再重命名 避免并发请求读到未写完的文件
+	tmpPath := ret.FilePath + "." + idutil.RandomUuid() + ".tmp"
+	archiveCtx, cancel := context.WithTimeout(ctx, archiveTimeout)
+	defer cancel()
+	err = git.CreateArchive(archiveCtx, absPath, git.CreateArchiveOpts{
+		CommitId: commitId,
+		Format:   reqDTO.Format,
+		Prefix:   reqDTO.Prefix,
+		Output:   tmpPath,
+	})
+	if err == nil {
+		err = os.Rename(tmpPath, ret.FilePath)
+	}
+	if err != nil {
+		util.RemoveAll(tmpPath)
+		logger.Logger.WithContext(ctx).Error(err)
+		return ArchiveRespDTO{}, util.InternalError()
+	}
+	return ret, nil
+}
+
+func archiveCacheKey(treeId, prefix string) string {
+	if prefix == "" {
+		return treeId
+	}
+	h := sha256.Sum256([]byte(prefix))
+	return treeId + "-" + hex.EncodeToString(h[:8])
+}
+
+// InitArchiveCleanTask 定时清理过期的压缩包缓存
+func InitArchiveCleanTask() {
+	archiveCleanTask, _ = taskutil.NewPeriodicalTask(time.Hour, cleanArchiveCache)
+	archiveCleanTask.Start()
+}
+
+func cleanArchiveCache() {
+	expireTime := time.Now().Add(-archiveCacheExpiry)
+	filepath.WalkDir(setting.ArchiveDir(), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().Before(expireTime) {
+			util.RemoveAll(path)
+		}
+		return nil
+	})
+}
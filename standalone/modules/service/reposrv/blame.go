@@ -0,0 +1,93 @@
+package reposrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"path/filepath"
+	"zgit/pkg/git"
+	"zgit/setting"
+	"zgit/util"
+)
+
+const (
+	// 单次blame最多返回行数
+	blameMaxLines = 1000
+)
+
+// Blame 文件每行最后修改的提交 大文件按行号范围分段获取
+func Blame(ctx context.Context, reqDTO BlameReqDTO) (BlameRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return BlameRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return BlameRespDTO{}, err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanAccess {
+		return BlameRespDTO{}, util.UnauthorizedError()
+	}
+	if repo.IsEmpty {
+		return BlameRespDTO{}, util.InvalidArgsError()
+	}
+	absPath := filepath.Join(setting.RepoDir(), repo.Path)
+	commitId, err := git.GetRefCommitId(ctx, absPath, reqDTO.RefName+"^{commit}")
+	if err != nil {
+		return BlameRespDTO{}, util.InvalidArgsError()
+	}
+	if !git.CheckExists(ctx, absPath, commitId+":"+reqDTO.FilePath) {
+		return BlameRespDTO{}, util.InvalidArgsError()
+	}
+	startLine := reqDTO.StartLine
+	if startLine == 0 {
+		startLine = 1
+	}
+	endLine := reqDTO.EndLine
+	if endLine == 0 || endLine-startLine+1 > blameMaxLines {
+		endLine = startLine + blameMaxLines - 1
+	}
+	// 多查一行判断是否还有后续行 超出文件行数git会截断
+	parts, err := git.Blame(ctx, absPath, git.BlameOpts{
+		Ref:       commitId,
+		FilePath:  reqDTO.FilePath,
+		StartLine: startLine,
+		EndLine:   endLine + 1,
+	})
+	if err != nil {
+		// 起始行超出文件行数
+		logger.Logger.WithContext(ctx).Error(err)
+		return BlameRespDTO{}, util.InvalidArgsError()
+	}
+	ret := BlameRespDTO{
+		CommitId:  commitId,
+		StartLine: startLine,
+		EndLine:   startLine - 1,
+	}
+	if len(parts) > 0 {
+		last := &parts[len(parts)-1]
+		if last.EndLine > endLine {
+			ret.HasMore = true
+			last.Lines = last.Lines[:len(last.Lines)-1]
+			last.EndLine--
+			if len(last.Lines) == 0 {
+				parts = parts[:len(parts)-1]
+			}
+		}
+	}
+	if len(parts) > 0 {
+		ret.EndLine = parts[len(parts)-1].EndLine
+	}
+	ret.Parts, _ = listutil.Map(parts, func(t git.BlamePart) (BlamePartDTO, error) {
+		return BlamePartDTO{
+			Commit:    commit2Dto(t.Commit),
+			FilePath:  t.FilePath,
+			StartLine: t.StartLine,
+			EndLine:   t.EndLine,
+			Lines:     t.Lines,
+		}, nil
+	})
+	return ret, nil
+}
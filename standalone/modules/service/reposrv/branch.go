@@ -0,0 +1,286 @@
+package reposrv
+
+import (
+	"context"
+	"github.com/IGLOU-EU/go-wildcard/v2"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"path"
+	"path/filepath"
+	"zgit/pkg/apicode"
+	"zgit/pkg/git"
+	"zgit/pkg/i18n"
+	"zgit/setting"
+	"zgit/standalone/modules/model/branchmd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+// ListBranches 分页展示分支 带最后一次提交和相对默认分支的领先落后数
+func ListBranches(ctx context.Context, reqDTO ListBranchesReqDTO) (ListBranchesRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return ListBranchesRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return ListBranchesRespDTO{}, err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanAccess {
+		return ListBranchesRespDTO{}, util.UnauthorizedError()
+	}
+	absPath := filepath.Join(setting.RepoDir(), repo.Path)
+	branches, total, err := git.ListBranches(ctx, absPath, git.ListBranchesOpts{
+		Keyword: reqDTO.Keyword,
+		Offset:  reqDTO.Offset,
+		Limit:   reqDTO.Limit,
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ListBranchesRespDTO{}, util.InternalError()
+	}
+	pbList, err := branchmd.ListProtectedBranch(ctx, repo.RepoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ListBranchesRespDTO{}, util.InternalError()
+	}
+	// 默认分支不存在时不计算领先落后数
+	hasDefaultBranch := git.CheckRefIsBranch(ctx, absPath, repo.DefaultBranch)
+	ret := ListBranchesRespDTO{
+		DefaultBranch: repo.DefaultBranch,
+		Total:         total,
+	}
+	ret.Branches, err = listutil.Map(branches, func(t git.Branch) (BranchDTO, error) {
+		commit, err := git.GetCommitByCommitId(ctx, absPath, t.CommitId)
+		if err != nil {
+			return BranchDTO{}, err
+		}
+		dto := BranchDTO{
+			Name:        t.Name,
+			IsDefault:   t.Name == repo.DefaultBranch,
+			IsProtected: isProtectedBranch(pbList, t.Name),
+			LastCommit:  commit2Dto(commit),
+		}
+		if hasDefaultBranch && !dto.IsDefault {
+			dto.Ahead, dto.Behind, err = git.CountAheadBehind(ctx, absPath, git.BranchPrefix+repo.DefaultBranch, git.BranchPrefix+t.Name)
+			if err != nil {
+				return BranchDTO{}, err
+			}
+		}
+		return dto, nil
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ListBranchesRespDTO{}, util.InternalError()
+	}
+	return ret, nil
+}
+
+// CreateBranch 基于分支、tag或提交创建分支
+func CreateBranch(ctx context.Context, reqDTO CreateBranchReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, absPath, err := checkPushPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	if git.CheckRefIsBranch(ctx, absPath, reqDTO.Branch) {
+		return util.NewBizErr(apicode.BranchAlreadyExistsCode, i18n.RepoBranchAlreadyExists)
+	}
+	commitId, err := git.GetRefCommitId(ctx, absPath, reqDTO.StartRef+"^{commit}")
+	if err != nil {
+		return util.InvalidArgsError()
+	}
+	// 推送时经过pre-receive 保护分支规则同样生效
+	if err = git.CreateBranch(ctx, absPath, reqDTO.Branch, commitId, git.PushRefOpts{
+		RepoId:   repo.RepoId,
+		PusherId: reqDTO.Operator.Account,
+	}); err != nil {
+		return convertPushErr(ctx, err)
+	}
+	return nil
+}
+
+// DeleteBranch 删除分支 默认分支不允许删除 保护分支由pre-receive拦截
+func DeleteBranch(ctx context.Context, reqDTO DeleteBranchReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, absPath, err := checkPushPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	if reqDTO.Branch == repo.DefaultBranch {
+		return util.NewBizErr(apicode.InvalidArgsCode, i18n.RepoDefaultBranchNotAllowDelete)
+	}
+	if !git.CheckRefIsBranch(ctx, absPath, reqDTO.Branch) {
+		return util.InvalidArgsError()
+	}
+	if err = git.DeleteBranch(ctx, absPath, reqDTO.Branch, git.PushRefOpts{
+		RepoId:   repo.RepoId,
+		PusherId: reqDTO.Operator.Account,
+	}); err != nil {
+		return convertPushErr(ctx, err)
+	}
+	return nil
+}
+
+// RenameBranch 重命名分支 相当于删除旧分支 保护分支同样不允许重命名
+func RenameBranch(ctx context.Context, reqDTO RenameBranchReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, absPath, err := checkPushPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	if !git.CheckRefIsBranch(ctx, absPath, reqDTO.Branch) {
+		return util.InvalidArgsError()
+	}
+	if git.CheckRefIsBranch(ctx, absPath, reqDTO.NewBranch) {
+		return util.NewBizErr(apicode.BranchAlreadyExistsCode, i18n.RepoBranchAlreadyExists)
+	}
+	isDefault := reqDTO.Branch == repo.DefaultBranch
+	// HEAD指向的分支无法删除 先把HEAD指向新分支
+	if isDefault {
+		if err = git.SetDefaultBranch(ctx, absPath, reqDTO.NewBranch); err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+	}
+	if err = git.RenameBranch(ctx, absPath, reqDTO.Branch, reqDTO.NewBranch, git.PushRefOpts{
+		RepoId:   repo.RepoId,
+		PusherId: reqDTO.Operator.Account,
+	}); err != nil {
+		if isDefault {
+			git.SetDefaultBranch(ctx, absPath, reqDTO.Branch)
+		}
+		return convertPushErr(ctx, err)
+	}
+	if isDefault {
+		if err = repomd.UpdateDefaultBranch(ctx, repo.RepoId, reqDTO.NewBranch); err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+	}
+	return nil
+}
+
+// CompareBranches 比较两个分支 提交和文件变更以merge-base为基准
+func CompareBranches(ctx context.Context, reqDTO CompareBranchesReqDTO) (CompareBranchesRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return CompareBranchesRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return CompareBranchesRespDTO{}, err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanAccess {
+		return CompareBranchesRespDTO{}, util.UnauthorizedError()
+	}
+	absPath := filepath.Join(setting.RepoDir(), repo.Path)
+	if !git.CheckRefIsBranch(ctx, absPath, reqDTO.Base) || !git.CheckRefIsBranch(ctx, absPath, reqDTO.Head) {
+		return CompareBranchesRespDTO{}, util.InvalidArgsError()
+	}
+	base := git.BranchPrefix + reqDTO.Base
+	head := git.BranchPrefix + reqDTO.Head
+	baseCommit, err := getBranchCommit(ctx, absPath, base)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return CompareBranchesRespDTO{}, util.InternalError()
+	}
+	headCommit, err := getBranchCommit(ctx, absPath, head)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return CompareBranchesRespDTO{}, util.InternalError()
+	}
+	ret := CompareBranchesRespDTO{
+		Base:       reqDTO.Base,
+		Head:       reqDTO.Head,
+		BaseCommit: commit2Dto(baseCommit),
+		HeadCommit: commit2Dto(headCommit),
+	}
+	ret.Ahead, ret.Behind, err = git.CountAheadBehind(ctx, absPath, base, head)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return CompareBranchesRespDTO{}, util.InternalError()
+	}
+	// 没有共同祖先时直接和base比较
+	diffBase := baseCommit.Id
+	if mergeBase, err := git.MergeBase(ctx, absPath, base, head); err == nil {
+		ret.MergeBase = mergeBase
+		diffBase = mergeBase
+	}
+	commits, err := git.GetGitLogCommitList(ctx, absPath, base, head)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return CompareBranchesRespDTO{}, util.InternalError()
+	}
+	ret.Commits, _ = listutil.Map(commits, func(t git.Commit) (CommitDTO, error) {
+		return commit2Dto(t), nil
+	})
+	stat, err := git.GetDiffNumsStat(ctx, absPath, diffBase, headCommit.Id)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return CompareBranchesRespDTO{}, util.InternalError()
+	}
+	ret.DiffNumsStats = DiffNumsStatInfoDTO{
+		FileChangeNums: stat.FileChangeNums,
+		InsertNums:     stat.InsertNums,
+		DeleteNums:     stat.DeleteNums,
+	}
+	ret.DiffNumsStats.Stats, _ = listutil.Map(stat.Stats, func(t git.DiffNumsStat) (DiffNumsStatDTO, error) {
+		return DiffNumsStatDTO{
+			RawPath:    t.Path,
+			Path:       path.Base(t.Path),
+			TotalNums:  t.TotalNums,
+			InsertNums: t.InsertNums,
+			DeleteNums: t.DeleteNums,
+		}, nil
+	})
+	return ret, nil
+}
+
+// checkPushPerm 修改分支或标签需要推送权限
+func checkPushPerm(ctx context.Context, repoId string, operator usermd.UserInfo) (repomd.Repo, string, error) {
+	repo, p, err := getPerm(ctx, repoId, operator)
+	if err != nil {
+		return repomd.Repo{}, "", err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanPush {
+		return repomd.Repo{}, "", util.UnauthorizedError()
+	}
+	if repo.IsEmpty {
+		return repomd.Repo{}, "", util.InvalidArgsError()
+	}
+	return repo, filepath.Join(setting.RepoDir(), repo.Path), nil
+}
+
+func getBranchCommit(ctx context.Context, repoPath, branch string) (git.Commit, error) {
+	commitId, err := git.GetRefCommitId(ctx, repoPath, branch)
+	if err != nil {
+		return git.Commit{}, err
+	}
+	return git.GetCommitByCommitId(ctx, repoPath, commitId)
+}
+
+func isProtectedBranch(pbList []branchmd.ProtectedBranchDTO, branch string) bool {
+	for _, pb := range pbList {
+		if wildcard.Match(pb.Branch, branch) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,13 +2,16 @@ package reposrv
 
 import (
 	"github.com/LeeZXin/zsf-utils/collections/hashset"
+	"path"
 	"regexp"
 	"strings"
 	"time"
 	"zgit/pkg/git"
+	"zgit/standalone/modules/model/mirrormd"
 	"zgit/standalone/modules/model/projectmd"
 	"zgit/standalone/modules/model/repomd"
 	"zgit/standalone/modules/model/usermd"
+	"zgit/standalone/modules/service/signaturesrv"
 	"zgit/util"
 )
 
@@ -20,6 +23,10 @@ const (
 var (
 	validRepoNamePattern = regexp.MustCompile("^[\\w\\-]{1,32}$")
 	validBranchPattern   = regexp.MustCompile("^\\w{1,32}$")
+	// 压缩包前缀目录 允许多级目录
+	validArchivePrefixPattern = regexp.MustCompile("^[\\w\\-.]{1,64}(/[\\w\\-.]{1,64}){0,3}/?$")
+	// 新建分支或标签名称 允许多级
+	validNewRefPattern = regexp.MustCompile("^\\w[\\w\\-.]{0,63}(/[\\w\\-.]{1,64}){0,3}$")
 )
 
 type InitRepoReqDTO struct {
@@ -58,6 +65,200 @@ func (r *InitRepoReqDTO) IsValid() error {
 	return nil
 }
 
+type ImportRepoReqDTO struct {
+	Operator  usermd.UserInfo
+	ProjectId string
+	Name      string
+	Desc      string
+	RepoType  repomd.RepoType
+	RemoteUrl string
+	Username  string
+	Password  string
+	// 是否作为镜像定时同步
+	IsMirror     bool
+	SyncInterval int
+}
+
+func (r *ImportRepoReqDTO) IsValid() error {
+	if !projectmd.IsProjectIdValid(r.ProjectId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !validRepoNamePattern.MatchString(r.Name) {
+		return util.InvalidArgsError()
+	}
+	if len(r.Desc) > 255 {
+		return util.InvalidArgsError()
+	}
+	if !r.RepoType.IsValid() {
+		return util.InvalidArgsError()
+	}
+	if !mirrormd.IsRemoteUrlValid(r.RemoteUrl) {
+		return util.InvalidArgsError()
+	}
+	if len(r.Username) > 255 || len(r.Password) > 1024 {
+		return util.InvalidArgsError()
+	}
+	if r.IsMirror && !mirrormd.IsSyncIntervalValid(r.SyncInterval) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type ArchiveReqDTO struct {
+	RepoId  string
+	RefName string
+	Format  git.ArchiveFormat
+	// 压缩包内的前缀目录 可选
+	Prefix   string
+	Operator usermd.UserInfo
+}
+
+func (r *ArchiveReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if len(r.RefName) > 128 || len(r.RefName) == 0 || strings.HasPrefix(r.RefName, "-") {
+		return util.InvalidArgsError()
+	}
+	if !r.Format.IsValid() {
+		return util.InvalidArgsError()
+	}
+	if r.Prefix != "" && !validArchivePrefixPattern.MatchString(r.Prefix) {
+		return util.InvalidArgsError()
+	}
+	for _, seg := range strings.Split(r.Prefix, "/") {
+		if seg == "." || seg == ".." {
+			return util.InvalidArgsError()
+		}
+	}
+	return nil
+}
+
+type ArchiveRespDTO struct {
+	// 压缩包本地路径
+	FilePath string
+	// 下载文件名
+	FileName string
+}
+
+type HistoryCommitsReqDTO struct {
+	RepoId  string
+	RefName string
+	// 只看修改过该路径的提交 可选
+	Path   string
+	Follow bool
+	Author string
+	Grep   string
+	// 时间范围 毫秒时间戳 可选
+	Since    int64
+	Until    int64
+	Cursor   int64
+	Limit    int
+	Operator usermd.UserInfo
+}
+
+func (r *HistoryCommitsReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if len(r.RefName) > 128 || len(r.RefName) == 0 || strings.HasPrefix(r.RefName, "-") {
+		return util.InvalidArgsError()
+	}
+	if r.Path != "" && (len(r.Path) > 1024 || strings.HasPrefix(r.Path, "/")) {
+		return util.InvalidArgsError()
+	}
+	// 跟踪重命名只支持单个文件
+	if r.Follow && r.Path == "" {
+		return util.InvalidArgsError()
+	}
+	if len(r.Author) > 128 || len(r.Grep) > 128 {
+		return util.InvalidArgsError()
+	}
+	if r.Since < 0 || r.Until < 0 || (r.Until > 0 && r.Since > r.Until) {
+		return util.InvalidArgsError()
+	}
+	if r.Cursor < 0 || r.Limit <= 0 || r.Limit > 100 {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type CommitSignatureDTO struct {
+	Status   signaturesrv.VerifyStatus
+	SignType string
+	Account  string
+	KeyId    string
+}
+
+type HistoryCommitDTO struct {
+	CommitDTO
+	Parent    []string
+	Signature CommitSignatureDTO
+}
+
+type HistoryCommitsRespDTO struct {
+	// ref解析后的提交id 翻页时作为refName传入可避免分支更新导致数据错乱
+	HeadCommitId string
+	Commits      []HistoryCommitDTO
+	Cursor       int64
+	HasMore      bool
+}
+
+type BlameReqDTO struct {
+	RepoId   string
+	RefName  string
+	FilePath string
+	// 行号范围 从1开始 可选
+	StartLine int
+	EndLine   int
+	Operator  usermd.UserInfo
+}
+
+func (r *BlameReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if len(r.RefName) > 128 || len(r.RefName) == 0 || strings.HasPrefix(r.RefName, "-") {
+		return util.InvalidArgsError()
+	}
+	if len(r.FilePath) > 1024 || len(r.FilePath) == 0 || strings.HasPrefix(r.FilePath, "/") || strings.HasSuffix(r.FilePath, "/") {
+		return util.InvalidArgsError()
+	}
+	if r.StartLine < 0 || r.EndLine < 0 || (r.EndLine > 0 && r.StartLine > r.EndLine) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type BlamePartDTO struct {
+	Commit    CommitDTO
+	FilePath  string
+	StartLine int
+	EndLine   int
+	Lines     []string
+}
+
+type BlameRespDTO struct {
+	CommitId  string
+	StartLine int
+	EndLine   int
+	Parts     []BlamePartDTO
+	// 是否还有后续行
+	HasMore bool
+}
+
 type DeleteRepoReqDTO struct {
 	RepoId   string
 	Operator usermd.UserInfo
@@ -415,3 +616,322 @@ func (r *DiffFileReqDTO) IsValid() error {
 func validateFileName(name string) bool {
 	return len(name) <= 255 && len(name) > 0
 }
+
+type EditFileDTO struct {
+	Action   git.FileAction
+	FilePath string
+	// 移动时的原路径
+	PrevPath string
+	Content  string
+}
+
+type EditFilesReqDTO struct {
+	RepoId string
+	Branch string
+	// 页面打开时的分支提交 分支有新的提交则失败
+	StartCommitId string
+	// 不为空则提交到新分支
+	NewBranch string
+	Message   string
+	Files     []EditFileDTO
+	// 提交到新分支后创建合并请求
+	CreatePr bool
+	PrTitle  string
+	Operator usermd.UserInfo
+}
+
+func (r *EditFilesReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateRef(r.Branch) || strings.HasPrefix(r.Branch, "-") {
+		return util.InvalidArgsError()
+	}
+	if len(r.StartCommitId) == 0 || len(r.StartCommitId) > 64 || strings.HasPrefix(r.StartCommitId, "-") {
+		return util.InvalidArgsError()
+	}
+	if r.NewBranch != "" && !validateNewRefName(r.NewBranch) {
+		return util.InvalidArgsError()
+	}
+	if len(r.Message) == 0 || len(r.Message) > 1024 {
+		return util.InvalidArgsError()
+	}
+	if len(r.Files) == 0 || len(r.Files) > editMaxFiles {
+		return util.InvalidArgsError()
+	}
+	for _, file := range r.Files {
+		if !file.Action.IsValid() || !validateEditPath(file.FilePath) {
+			return util.InvalidArgsError()
+		}
+		if file.Action == git.MoveFileAction && (!validateEditPath(file.PrevPath) || file.PrevPath == file.FilePath) {
+			return util.InvalidArgsError()
+		}
+		if len(file.Content) > editMaxFileSize {
+			return util.InvalidArgsError()
+		}
+	}
+	// 合并请求只能从新分支发起
+	if r.CreatePr && (r.NewBranch == "" || len(r.PrTitle) == 0 || len(r.PrTitle) > 255) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type EditFilesRespDTO struct {
+	CommitId string
+	Branch   string
+	PrId     string
+	// 合并请求创建失败原因
+	PrErrMsg string
+}
+
+type ListBranchesReqDTO struct {
+	RepoId string
+	// 分支名称关键字 可选
+	Keyword  string
+	Offset   int
+	Limit    int
+	Operator usermd.UserInfo
+}
+
+func (r *ListBranchesReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if len(r.Keyword) > 64 {
+		return util.InvalidArgsError()
+	}
+	if r.Offset < 0 || r.Limit <= 0 || r.Limit > 100 {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type BranchDTO struct {
+	Name        string
+	IsDefault   bool
+	IsProtected bool
+	LastCommit  CommitDTO
+	// 相对于默认分支领先和落后的提交数
+	Ahead  int
+	Behind int
+}
+
+type ListBranchesRespDTO struct {
+	DefaultBranch string
+	Branches      []BranchDTO
+	Total         int
+}
+
+type CreateBranchReqDTO struct {
+	RepoId string
+	Branch string
+	// 分支 tag或提交id
+	StartRef string
+	Operator usermd.UserInfo
+}
+
+func (r *CreateBranchReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !validateNewRefName(r.Branch) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateRef(r.StartRef) || strings.HasPrefix(r.StartRef, "-") {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type DeleteBranchReqDTO struct {
+	RepoId   string
+	Branch   string
+	Operator usermd.UserInfo
+}
+
+func (r *DeleteBranchReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateRef(r.Branch) || strings.HasPrefix(r.Branch, "-") {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type RenameBranchReqDTO struct {
+	RepoId    string
+	Branch    string
+	NewBranch string
+	Operator  usermd.UserInfo
+}
+
+func (r *RenameBranchReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateRef(r.Branch) || strings.HasPrefix(r.Branch, "-") {
+		return util.InvalidArgsError()
+	}
+	if !validateNewRefName(r.NewBranch) || r.NewBranch == r.Branch {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type CompareBranchesReqDTO struct {
+	RepoId   string
+	Base     string
+	Head     string
+	Operator usermd.UserInfo
+}
+
+func (r *CompareBranchesReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateRef(r.Base) || strings.HasPrefix(r.Base, "-") {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateRef(r.Head) || strings.HasPrefix(r.Head, "-") {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type CompareBranchesRespDTO struct {
+	Base       string
+	Head       string
+	BaseCommit CommitDTO
+	HeadCommit CommitDTO
+	// 没有共同祖先则为空
+	MergeBase string
+	// head相对于base领先和落后的提交数
+	Ahead  int
+	Behind int
+	// head上有而base上没有的提交
+	Commits       []CommitDTO
+	DiffNumsStats DiffNumsStatInfoDTO
+}
+
+func validateNewRefName(name string) bool {
+	return validNewRefPattern.MatchString(name) &&
+		!strings.Contains(name, "..") &&
+		!strings.HasSuffix(name, ".") &&
+		!strings.HasSuffix(name, ".lock")
+}
+
+// validateEditPath 仓库内的相对路径 不能跳出仓库
+func validateEditPath(filePath string) bool {
+	if len(filePath) == 0 || len(filePath) > 1024 || strings.ContainsRune(filePath, 0) {
+		return false
+	}
+	if path.Clean(filePath) != filePath || strings.HasPrefix(filePath, "/") || strings.HasPrefix(filePath, "../") || filePath == ".." || filePath == "." {
+		return false
+	}
+	for _, name := range strings.Split(filePath, "/") {
+		if strings.EqualFold(name, ".git") {
+			return false
+		}
+	}
+	return true
+}
+
+type CreateTagReqDTO struct {
+	RepoId string
+	Tag    string
+	// 分支 tag或提交id
+	StartRef string
+	// 不为空则创建附注标签
+	Message string
+	// 使用服务端密钥签名
+	Sign     bool
+	Operator usermd.UserInfo
+}
+
+func (r *CreateTagReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !validateNewRefName(r.Tag) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateRef(r.StartRef) || strings.HasPrefix(r.StartRef, "-") {
+		return util.InvalidArgsError()
+	}
+	if len(r.Message) > 1024 {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type DeleteTagReqDTO struct {
+	RepoId   string
+	Tag      string
+	Operator usermd.UserInfo
+}
+
+func (r *DeleteTagReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateRef(r.Tag) || strings.HasPrefix(r.Tag, "-") {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type GetTagReqDTO struct {
+	RepoId   string
+	Tag      string
+	Operator usermd.UserInfo
+}
+
+func (r *GetTagReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateRef(r.Tag) || strings.HasPrefix(r.Tag, "-") {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type TagDTO struct {
+	Name string
+	// 附注标签才有以下信息
+	IsAnnotated bool
+	Tagger      git.User
+	TaggedDate  time.Time
+	Message     string
+	IsSigned    bool
+	Commit      CommitDTO
+}
@@ -0,0 +1,124 @@
+package reposrv
+
+import (
+	"context"
+	"errors"
+	"github.com/LeeZXin/zsf-utils/bizerr"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"path/filepath"
+	"zgit/pkg/apicode"
+	"zgit/pkg/git"
+	"zgit/pkg/i18n"
+	"zgit/setting"
+	"zgit/standalone/modules/service/pullrequestsrv"
+	"zgit/util"
+)
+
+const (
+	// 单次提交最多修改文件数
+	editMaxFiles = 100
+	// 在线编辑单个文件最大大小
+	editMaxFileSize = 1024 * 1024
+)
+
+// EditFiles 在线编辑文件 多个修改作为一个提交推送到分支
+func EditFiles(ctx context.Context, reqDTO EditFilesReqDTO) (EditFilesRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return EditFilesRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return EditFilesRespDTO{}, err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanPush {
+		return EditFilesRespDTO{}, util.UnauthorizedError()
+	}
+	if repo.IsEmpty {
+		return EditFilesRespDTO{}, util.InvalidArgsError()
+	}
+	absPath := filepath.Join(setting.RepoDir(), repo.Path)
+	if !git.CheckRefIsBranch(ctx, absPath, reqDTO.Branch) {
+		return EditFilesRespDTO{}, util.InvalidArgsError()
+	}
+	if reqDTO.NewBranch != "" && git.CheckRefIsBranch(ctx, absPath, reqDTO.NewBranch) {
+		return EditFilesRespDTO{}, util.NewBizErr(apicode.BranchAlreadyExistsCode, i18n.RepoBranchAlreadyExists)
+	}
+	changes, _ := listutil.Map(reqDTO.Files, func(t EditFileDTO) (git.FileChange, error) {
+		return git.FileChange{
+			Action:   t.Action,
+			FilePath: t.FilePath,
+			PrevPath: t.PrevPath,
+			Content:  t.Content,
+		}, nil
+	})
+	// 推送时经过pre-receive 保护分支和推送规则同样生效
+	commitId, err := git.CommitFiles(ctx, absPath, git.CommitFilesOpts{
+		RepoId:   repo.RepoId,
+		PusherId: reqDTO.Operator.Account,
+		Author: git.User{
+			Account: reqDTO.Operator.Account,
+			Email:   reqDTO.Operator.Email,
+		},
+		Branch:        reqDTO.Branch,
+		StartCommitId: reqDTO.StartCommitId,
+		NewBranch:     reqDTO.NewBranch,
+		Message:       reqDTO.Message,
+		Changes:       changes,
+	})
+	if err != nil {
+		return EditFilesRespDTO{}, convertPushErr(ctx, err)
+	}
+	ret := EditFilesRespDTO{
+		CommitId: commitId,
+		Branch:   reqDTO.Branch,
+	}
+	if reqDTO.NewBranch != "" {
+		ret.Branch = reqDTO.NewBranch
+	}
+	if reqDTO.CreatePr {
+		// 新分支已推送成功 合并请求创建失败时仍返回提交和分支 可手动重新创建 Target为源分支 Head为目标分支
+		prRet, err := pullrequestsrv.SubmitPullRequest(ctx, pullrequestsrv.SubmitPullRequestReqDTO{
+			RepoId:   repo.RepoId,
+			Title:    reqDTO.PrTitle,
+			Target:   reqDTO.NewBranch,
+			Head:     reqDTO.Branch,
+			Operator: reqDTO.Operator,
+		})
+		if err != nil {
+			if berr, ok := err.(*bizerr.Err); ok {
+				ret.PrErrMsg = berr.Message
+			} else {
+				ret.PrErrMsg = i18n.GetByKey(i18n.SystemInternalError)
+			}
+		} else {
+			ret.PrId = prRet.PrId
+		}
+	}
+	return ret, nil
+}
+
+func convertPushErr(ctx context.Context, err error) error {
+	var (
+		outOfDateErr *git.ErrPushOutOfDate
+		rejectedErr  *git.ErrPushRejected
+		notFoundErr  *git.ErrFileNotFound
+		existsErr    *git.ErrFileAlreadyExists
+	)
+	switch {
+	case errors.As(err, &outOfDateErr):
+		return util.NewBizErr(apicode.BranchOutOfDateCode, i18n.RepoBranchOutOfDate)
+	case errors.As(err, &rejectedErr):
+		return util.NewBizErr(apicode.PushRejectedCode, i18n.RepoPushRejectedWarnFormat, rejectedErr.Error())
+	case errors.As(err, &notFoundErr):
+		return util.NewBizErr(apicode.FileConflictCode, i18n.RepoFileNotFoundWarnFormat, notFoundErr.FilePath)
+	case errors.As(err, &existsErr):
+		return util.NewBizErr(apicode.FileConflictCode, i18n.RepoFileAlreadyExistsWarnFormat, existsErr.FilePath)
+	default:
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+}
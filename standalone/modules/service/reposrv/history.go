@@ -0,0 +1,84 @@
+package reposrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"path/filepath"
+	"time"
+	"zgit/pkg/git"
+	"zgit/setting"
+	"zgit/standalone/modules/service/signaturesrv"
+	"zgit/util"
+)
+
+// HistoryCommits 提交历史 按偏移量翻页
+func HistoryCommits(ctx context.Context, reqDTO HistoryCommitsReqDTO) (HistoryCommitsRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return HistoryCommitsRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return HistoryCommitsRespDTO{}, err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanAccess {
+		return HistoryCommitsRespDTO{}, util.UnauthorizedError()
+	}
+	if repo.IsEmpty {
+		return HistoryCommitsRespDTO{}, util.InvalidArgsError()
+	}
+	absPath := filepath.Join(setting.RepoDir(), repo.Path)
+	headCommitId, err := git.GetRefCommitId(ctx, absPath, reqDTO.RefName+"^{commit}")
+	if err != nil {
+		return HistoryCommitsRespDTO{}, util.InvalidArgsError()
+	}
+	opts := git.ListCommitsOpts{
+		Ref:    headCommitId,
+		Path:   reqDTO.Path,
+		Follow: reqDTO.Follow,
+		Author: reqDTO.Author,
+		Grep:   reqDTO.Grep,
+		Skip:   int(reqDTO.Cursor),
+		// 多查一条判断是否还有下一页
+		Limit: reqDTO.Limit + 1,
+	}
+	if reqDTO.Since > 0 {
+		opts.Since = time.UnixMilli(reqDTO.Since)
+	}
+	if reqDTO.Until > 0 {
+		opts.Until = time.UnixMilli(reqDTO.Until)
+	}
+	commitList, err := git.ListCommits(ctx, absPath, opts)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return HistoryCommitsRespDTO{}, util.InternalError()
+	}
+	ret := HistoryCommitsRespDTO{
+		HeadCommitId: headCommitId,
+	}
+	if len(commitList) > reqDTO.Limit {
+		commitList = commitList[:reqDTO.Limit]
+		ret.HasMore = true
+	}
+	ret.Commits = make([]HistoryCommitDTO, 0, len(commitList))
+	for _, commit := range commitList {
+		sig, err := signaturesrv.VerifyCommitSignature(ctx, commit)
+		if err != nil {
+			return HistoryCommitsRespDTO{}, err
+		}
+		ret.Commits = append(ret.Commits, HistoryCommitDTO{
+			CommitDTO: commit2Dto(commit),
+			Parent:    commit.Parent,
+			Signature: CommitSignatureDTO{
+				Status:   sig.Status,
+				SignType: sig.SignType,
+				Account:  sig.Account,
+				KeyId:    sig.KeyId,
+			},
+		})
+	}
+	ret.Cursor = reqDTO.Cursor + int64(len(commitList))
+	return ret, nil
+}
@@ -7,21 +7,33 @@ import (
 	"github.com/LeeZXin/zsf-utils/listutil"
 	"github.com/LeeZXin/zsf/logger"
 	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"os"
 	"path"
 	"path/filepath"
+	"time"
 	"zgit/pkg/apicode"
 	"zgit/pkg/git"
+	"zgit/pkg/git/lfs"
 	"zgit/pkg/i18n"
 	"zgit/pkg/perm"
 	"zgit/setting"
+	"zgit/standalone/modules/model/codeindexmd"
+	"zgit/standalone/modules/model/mirrormd"
 	"zgit/standalone/modules/model/projectmd"
+	"zgit/standalone/modules/model/releasemd"
 	"zgit/standalone/modules/model/repomd"
 	"zgit/standalone/modules/model/usermd"
+	"zgit/standalone/modules/service/codesearchsrv"
+	"zgit/standalone/modules/service/quotasrv"
+	"zgit/standalone/modules/service/releasesrv"
+	"zgit/standalone/modules/service/wikisrv"
 	"zgit/util"
 )
 
 const (
 	LsTreeLimit = 25
+
+	importRepoTimeout = 30 * time.Minute
 )
 
 // GetInfoByPath 通过相对路径获取仓库信息
@@ -228,6 +240,10 @@ func InitRepo(ctx context.Context, reqDTO InitRepoReqDTO) error {
 	if p.PermDetail.ProjectPerm.CanInitRepo {
 		return util.UnauthorizedError()
 	}
+	// 检查仓库数量配额
+	if err = quotasrv.CheckRepoCountQuota(ctx); err != nil {
+		return err
+	}
 	// 相对路径
 	relativePath := util.JoinRelativeRepoPath(setting.StandaloneCorpId(), reqDTO.Name)
 	// 拼接绝对路径
@@ -283,11 +299,15 @@ func InitRepo(ctx context.Context, reqDTO InitRepoReqDTO) error {
 			size, err := git.GetRepoSize(absPath)
 			logger.Logger.WithContext(ctx).Infof("repo size: %d", size)
 			if err == nil {
-				repomd.UpdateTotalAndGitSize(ctx, repo.Path, size, size)
+				repomd.UpdateTotalAndGitSize(ctx, repo.RepoId, size, size)
 			}
 		}
 		return nil
 	}); err != nil {
+		// 目录已存在说明有并发创建的同名仓库 不能删除
+		if errors.Is(err, os.ErrExist) {
+			return util.NewBizErr(apicode.InvalidArgsCode, i18n.RepoAlreadyExists)
+		}
 		// 如果有异常 删掉这个仓库
 		util.RemoveAll(absPath)
 		logger.Logger.WithContext(ctx).Error(err)
@@ -296,6 +316,117 @@ func InitRepo(ctx context.Context, reqDTO InitRepoReqDTO) error {
 	return nil
 }
 
+// ImportRepo 从远端导入仓库 可选择作为镜像定时同步
+func ImportRepo(ctx context.Context, reqDTO ImportRepoReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	// 校验项目信息
+	p, b, err := projectmd.GetProjectUserPermDetail(ctx, reqDTO.ProjectId, reqDTO.Operator.Account)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if !b {
+		return util.UnauthorizedError()
+	}
+	if !p.PermDetail.ProjectPerm.CanInitRepo {
+		return util.UnauthorizedError()
+	}
+	// 检查仓库数量配额
+	if err = quotasrv.CheckRepoCountQuota(ctx); err != nil {
+		return err
+	}
+	relativePath := util.JoinRelativeRepoPath(setting.StandaloneCorpId(), reqDTO.Name)
+	absPath := util.JoinAbsRepoPath(setting.StandaloneCorpId(), reqDTO.Name)
+	_, b, err = repomd.GetByPath(ctx, relativePath)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if b {
+		return util.NewBizErr(apicode.InvalidArgsCode, i18n.RepoAlreadyExists)
+	}
+	var encryptedPassword string
+	if reqDTO.IsMirror && reqDTO.Password != "" {
+		encryptedPassword, err = util.AesGcmEncrypt(setting.SecretKeyBytes(), reqDTO.Password)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+	}
+	importCtx, cancel := context.WithTimeout(ctx, importRepoTimeout)
+	defer cancel()
+	if err = util.CheckRemoteHost(importCtx, reqDTO.RemoteUrl); err != nil {
+		logger.Logger.WithContext(ctx).Errorf("import repo: %s err: %v", reqDTO.RemoteUrl, err)
+		return util.NewBizErr(apicode.InvalidArgsCode, i18n.RepoRemoteUrlNotAllowed)
+	}
+	defaultBranch, err := git.ImportRepository(importCtx, git.ImportRepoOpts{
+		RepoPath:  absPath,
+		RemoteUrl: reqDTO.RemoteUrl,
+		Auth: git.RemoteAuth{
+			Username: reqDTO.Username,
+			Password: reqDTO.Password,
+		},
+		IsMirror: reqDTO.IsMirror,
+	})
+	// 目录已存在说明有并发创建的同名仓库 不能删除
+	if errors.Is(err, os.ErrExist) {
+		return util.NewBizErr(apicode.InvalidArgsCode, i18n.RepoAlreadyExists)
+	}
+	if err != nil {
+		util.RemoveAll(absPath)
+		logger.Logger.WithContext(ctx).Errorf("import repo: %s err: %v", reqDTO.RemoteUrl, err)
+		return util.NewBizErr(apicode.RepoImportFailedCode, i18n.RepoImportFailed)
+	}
+	isEmpty := defaultBranch == ""
+	if isEmpty {
+		defaultBranch = setting.DefaultBranch()
+		git.SetDefaultBranch(ctx, absPath, defaultBranch)
+	}
+	size, err := git.GetRepoSize(absPath)
+	if err != nil {
+		util.RemoveAll(absPath)
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if err = mysqlstore.WithTx(ctx, func(ctx context.Context) error {
+		repo, err := repomd.InsertRepo(ctx, repomd.InsertRepoReqDTO{
+			Name:          reqDTO.Name,
+			Path:          relativePath,
+			Author:        reqDTO.Operator.Account,
+			ProjectId:     reqDTO.ProjectId,
+			RepoDesc:      reqDTO.Desc,
+			DefaultBranch: defaultBranch,
+			RepoType:      reqDTO.RepoType,
+			IsEmpty:       isEmpty,
+			TotalSize:     size,
+			GitSize:       size,
+		})
+		if err != nil {
+			return err
+		}
+		if !reqDTO.IsMirror {
+			return nil
+		}
+		return mirrormd.InsertPullMirror(ctx, mirrormd.InsertPullMirrorReqDTO{
+			RepoId:       repo.RepoId,
+			RemoteUrl:    reqDTO.RemoteUrl,
+			Username:     reqDTO.Username,
+			Password:     encryptedPassword,
+			SyncInterval: reqDTO.SyncInterval,
+			NextSyncTime: time.Now().Add(time.Duration(reqDTO.SyncInterval) * time.Minute).UnixMilli(),
+		})
+	}); err != nil {
+		util.RemoveAll(absPath)
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
 // AllGitIgnoreTemplateList 所有gitignore模版名称
 func AllGitIgnoreTemplateList() []string {
 	return gitignoreSet.AllKeys()
@@ -342,11 +473,38 @@ func DeleteRepo(ctx context.Context, reqDTO DeleteRepoReqDTO) error {
 		if err != nil {
 			return err
 		}
+		_, err = mirrormd.DeletePullMirror(ctx, repo.RepoId)
+		if err != nil {
+			return err
+		}
+		err = mirrormd.DeletePushMirrorByRepoId(ctx, repo.RepoId)
+		if err != nil {
+			return err
+		}
+		err = codeindexmd.DeleteCodeIndex(ctx, repo.RepoId)
+		if err != nil {
+			return err
+		}
+		err = releasemd.DeleteReleaseByRepoId(ctx, repo.RepoId)
+		if err != nil {
+			return err
+		}
+		err = releasemd.DeleteAssetByRepoId(ctx, repo.RepoId)
+		if err != nil {
+			return err
+		}
 		err = util.RemoveAll(absPath)
 		if err != nil {
 			return err
 		}
-		// todo 删除wiki
+		// 删除压缩包缓存
+		util.RemoveAll(filepath.Join(setting.ArchiveDir(), repo.RepoId))
+		// 删除代码搜索索引
+		codesearchsrv.DeleteIndex(repo.RepoId)
+		// 删除wiki
+		util.RemoveAll(wikisrv.GetWikiAbsPath(repo))
+		// 删除发布版本附件
+		lfs.StorageImpl.Delete(ctx, releasesrv.GetAssetDir(repo))
 		return nil
 	}); err != nil {
 		if _, ok := err.(*bizerr.Err); ok {
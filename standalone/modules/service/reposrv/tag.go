@@ -0,0 +1,122 @@
+package reposrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"path/filepath"
+	"strings"
+	"zgit/pkg/apicode"
+	"zgit/pkg/git"
+	"zgit/pkg/i18n"
+	"zgit/setting"
+	"zgit/util"
+)
+
+// CreateTag 创建轻量或附注标签 可用服务端密钥签名
+func CreateTag(ctx context.Context, reqDTO CreateTagReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, absPath, err := checkPushPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	if git.CheckRefIsTag(ctx, absPath, reqDTO.Tag) {
+		return util.NewBizErr(apicode.TagAlreadyExistsCode, i18n.RepoTagAlreadyExists)
+	}
+	commitId, err := git.GetRefCommitId(ctx, absPath, reqDTO.StartRef+"^{commit}")
+	if err != nil {
+		return util.InvalidArgsError()
+	}
+	opts := git.CreateTagOpts{
+		PushRefOpts: git.PushRefOpts{
+			RepoId:   repo.RepoId,
+			PusherId: reqDTO.Operator.Account,
+		},
+		Tag:      reqDTO.Tag,
+		CommitId: commitId,
+		Message:  reqDTO.Message,
+		Tagger: git.User{
+			Account: reqDTO.Operator.Account,
+			Email:   reqDTO.Operator.Email,
+		},
+	}
+	if reqDTO.Sign {
+		opts.SignKeyId = git.GetGpnKeyId(absPath, git.TagScene)
+		if opts.SignKeyId == "" {
+			return util.NewBizErr(apicode.InvalidArgsCode, i18n.RepoSignKeyNotConfigured)
+		}
+	}
+	// 推送时经过pre-receive 保护标签规则同样生效
+	if err = git.CreateTag(ctx, absPath, opts); err != nil {
+		return convertPushErr(ctx, err)
+	}
+	return nil
+}
+
+// DeleteTag 删除标签 保护标签由pre-receive拦截
+func DeleteTag(ctx context.Context, reqDTO DeleteTagReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, absPath, err := checkPushPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	if !git.CheckRefIsTag(ctx, absPath, reqDTO.Tag) {
+		return util.InvalidArgsError()
+	}
+	if err = git.DeleteTag(ctx, absPath, reqDTO.Tag, git.PushRefOpts{
+		RepoId:   repo.RepoId,
+		PusherId: reqDTO.Operator.Account,
+	}); err != nil {
+		return convertPushErr(ctx, err)
+	}
+	return nil
+}
+
+// GetTag 标签详情
+func GetTag(ctx context.Context, reqDTO GetTagReqDTO) (TagDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return TagDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return TagDTO{}, err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanAccess {
+		return TagDTO{}, util.UnauthorizedError()
+	}
+	absPath := filepath.Join(setting.RepoDir(), repo.Path)
+	if !git.CheckRefIsTag(ctx, absPath, reqDTO.Tag) {
+		return TagDTO{}, util.InvalidArgsError()
+	}
+	commit, err := git.GetCommitByTag(ctx, absPath, git.TagPrefix+reqDTO.Tag)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return TagDTO{}, util.InternalError()
+	}
+	return tag2Dto(reqDTO.Tag, commit), nil
+}
+
+func tag2Dto(name string, commit git.Commit) TagDTO {
+	ret := TagDTO{
+		Name:   name,
+		Commit: commit2Dto(commit),
+	}
+	if commit.Tag != nil {
+		ret.IsAnnotated = true
+		ret.Tagger = commit.Tag.Tagger
+		ret.TaggedDate = commit.Tag.TagTime
+		ret.Message = strings.TrimSpace(commit.Tag.CommitMsg)
+		ret.IsSigned = commit.Tag.GpgSig != ""
+	}
+	return ret
+}
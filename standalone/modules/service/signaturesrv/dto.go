@@ -0,0 +1,29 @@
+package signaturesrv
+
+type VerifyStatus string
+
+const (
+	// UnsignedStatus 未签名
+	UnsignedStatus VerifyStatus = "unsigned"
+	// VerifiedStatus 签名有效且公钥已登记
+	VerifiedStatus VerifyStatus = "verified"
+	// UnverifiedStatus 有签名但无法校验
+	UnverifiedStatus VerifyStatus = "unverified"
+)
+
+const (
+	GpgSignType = "gpg"
+	SshSignType = "ssh"
+)
+
+type CommitSignatureDTO struct {
+	Status   VerifyStatus
+	SignType string
+	// 签名公钥所属用户
+	Account string
+	KeyId   string
+}
+
+func (s CommitSignatureDTO) IsVerified() bool {
+	return s.Status == VerifiedStatus
+}
@@ -0,0 +1,84 @@
+package signaturesrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf/logger"
+	"zgit/pkg/git"
+	"zgit/pkg/git/signature"
+	"zgit/standalone/modules/model/gpgkeymd"
+	"zgit/standalone/modules/service/sshkeysrv"
+	"zgit/util"
+)
+
+// VerifyCommitSignature 使用已登记的用户公钥校验提交签名
+func VerifyCommitSignature(ctx context.Context, commit git.Commit) (CommitSignatureDTO, error) {
+	switch {
+	case commit.GpgSig.IsGPGSig():
+		return verifyGpgSignature(ctx, commit)
+	case commit.GpgSig.IsSSHSig():
+		return verifySshSignature(ctx, commit)
+	default:
+		return CommitSignatureDTO{
+			Status: UnsignedStatus,
+		}, nil
+	}
+}
+
+func verifyGpgSignature(ctx context.Context, commit git.Commit) (CommitSignatureDTO, error) {
+	ret := CommitSignatureDTO{
+		Status:   UnverifiedStatus,
+		SignType: GpgSignType,
+	}
+	sig, err := signature.ParseGPGSignature(commit.GpgSig)
+	if err != nil {
+		return ret, nil
+	}
+	keyId := sig.GetGPGSignatureKeyId()
+	if keyId == "" {
+		return ret, nil
+	}
+	ret.KeyId = keyId
+	gpgKey, b, err := gpgkeymd.SearchByGpgKeyId(ctx, keyId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ret, util.InternalError()
+	}
+	if !b || gpgKey.IsExpired() {
+		return ret, nil
+	}
+	entityList, err := signature.ConvertArmoredGPGKeyString(gpgKey.Content)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ret, nil
+	}
+	if commit.VerifyGPGSignature(signature.GetGPGEntityListPublicKeys(entityList)...) == nil {
+		ret.Status = VerifiedStatus
+		ret.Account = gpgKey.Account
+	}
+	return ret, nil
+}
+
+func verifySshSignature(ctx context.Context, commit git.Commit) (CommitSignatureDTO, error) {
+	ret := CommitSignatureDTO{
+		Status:   UnverifiedStatus,
+		SignType: SshSignType,
+	}
+	publicKey, err := signature.GetSshSignaturePublicKey(commit.GpgSig.String())
+	if err != nil {
+		return ret, nil
+	}
+	sshKey, b, err := sshkeysrv.SearchByKeyContent(ctx, publicKey)
+	if err != nil {
+		return ret, err
+	}
+	// 只认可已校验过的公钥
+	if !b || !sshKey.Verified {
+		return ret, nil
+	}
+	ret.KeyId = sshKey.Fingerprint
+	if commit.VerifySshSignature(sshKey.Content) == nil {
+		ret.Status = VerifiedStatus
+		ret.Account = sshKey.Account
+	}
+	return ret, nil
+}
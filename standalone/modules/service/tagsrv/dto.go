@@ -0,0 +1,68 @@
+package tagsrv
+
+import (
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/tagmd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+type InsertProtectedTagReqDTO struct {
+	RepoId   string
+	Tag      string
+	Cfg      tagmd.ProtectedTagCfg
+	Operator usermd.UserInfo
+}
+
+func (r *InsertProtectedTagReqDTO) IsValid() error {
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !tagmd.IsWildcardTagValid(r.Tag) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if len(r.Cfg.CreatorList) > 50 {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type DeleteProtectedTagReqDTO struct {
+	Tid      string
+	Operator usermd.UserInfo
+}
+
+func (r *DeleteProtectedTagReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !tagmd.IsTidValid(r.Tid) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type ListProtectedTagReqDTO struct {
+	RepoId   string
+	Operator usermd.UserInfo
+}
+
+func (r *ListProtectedTagReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type ProtectedTagDTO struct {
+	Tid    string
+	RepoId string
+	Tag    string
+	Cfg    tagmd.ProtectedTagCfg
+}
@@ -0,0 +1,131 @@
+package tagsrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"zgit/pkg/apicode"
+	"zgit/pkg/i18n"
+	"zgit/standalone/modules/model/projectmd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/tagmd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+func InsertProtectedTag(ctx context.Context, reqDTO InsertProtectedTagReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	_, err := checkPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	_, b, err := tagmd.GetProtectedTag(ctx, reqDTO.RepoId, reqDTO.Tag)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if b {
+		return util.AlreadyExistsError()
+	}
+	for _, account := range reqDTO.Cfg.CreatorList {
+		_, b, err = usermd.GetByAccount(ctx, account)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+		// 账号不合法
+		if !b {
+			return util.NewBizErr(apicode.InvalidArgsCode, i18n.UserAccountNotFoundWarnFormat, account)
+		}
+	}
+	if err = tagmd.InsertProtectedTag(ctx, tagmd.InsertProtectedTagReqDTO{
+		RepoId: reqDTO.RepoId,
+		Tag:    reqDTO.Tag,
+		Cfg:    reqDTO.Cfg,
+	}); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
+func DeleteProtectedTag(ctx context.Context, reqDTO DeleteProtectedTagReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	pt, b, err := tagmd.GetProtectedTagByTid(ctx, reqDTO.Tid)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if !b {
+		return util.InvalidArgsError()
+	}
+	_, err = checkPerm(ctx, pt.RepoId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	_, err = tagmd.DeleteProtectedTag(ctx, pt)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
+func ListProtectedTag(ctx context.Context, reqDTO ListProtectedTagReqDTO) ([]ProtectedTagDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return nil, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	_, err := checkPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return nil, err
+	}
+	tagList, err := tagmd.ListProtectedTag(ctx, reqDTO.RepoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return nil, util.InternalError()
+	}
+	ret, _ := listutil.Map(tagList, func(t tagmd.ProtectedTagDTO) (ProtectedTagDTO, error) {
+		return ProtectedTagDTO{
+			Tid:    t.Tid,
+			RepoId: t.RepoId,
+			Tag:    t.Tag,
+			Cfg:    t.Cfg,
+		}, nil
+	})
+	return ret, nil
+}
+
+// checkPerm 与保护分支共用管理权限
+func checkPerm(ctx context.Context, repoId string, operator usermd.UserInfo) (repomd.Repo, error) {
+	repo, b, err := repomd.GetByRepoId(ctx, repoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return repomd.Repo{}, util.InternalError()
+	}
+	if !b {
+		return repomd.Repo{}, util.InvalidArgsError()
+	}
+	permDetail, b, err := projectmd.GetProjectUserPermDetail(ctx, repo.ProjectId, operator.Account)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return repo, util.InternalError()
+	}
+	if !b {
+		return repo, util.UnauthorizedError()
+	}
+	if !permDetail.PermDetail.GetRepoPerm(repoId).CanHandleProtectedBranch {
+		return repo, util.UnauthorizedError()
+	}
+	return repo, nil
+}
@@ -9,6 +9,7 @@ import (
 	"zgit/pkg/apicode"
 	"zgit/pkg/apisession"
 	"zgit/pkg/i18n"
+	"zgit/standalone/modules/model/accesstokenmd"
 	"zgit/standalone/modules/model/usermd"
 	"zgit/standalone/modules/service/cfgsrv"
 	"zgit/util"
@@ -196,8 +197,15 @@ func DeleteUser(ctx context.Context, reqDTO DeleteUserReqDTO) error {
 	if !b {
 		return util.InvalidArgsError()
 	}
-	// 数据库删除用户
-	_, err = usermd.DeleteUser(ctx, user)
+	err = mysqlstore.WithTx(ctx, func(ctx context.Context) error {
+		// 数据库删除用户
+		_, err := usermd.DeleteUser(ctx, user)
+		if err != nil {
+			return err
+		}
+		// 删除个人访问令牌
+		return accesstokenmd.DeleteAccessTokenByAccount(ctx, user.Account)
+	})
 	if err != nil {
 		logger.Logger.WithContext(ctx).Error(err)
 		return util.InternalError()
@@ -340,3 +348,24 @@ func UpdatePassword(ctx context.Context, reqDTO UpdatePasswordReqDTO) error {
 	}
 	return nil
 }
+
+// CheckAccountAndPassword 校验账号密码 用于http basic认证
+func CheckAccountAndPassword(ctx context.Context, reqDTO LoginReqDTO) (usermd.UserInfo, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return usermd.UserInfo{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	user, b, err := usermd.GetByAccount(ctx, reqDTO.Account)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return usermd.UserInfo{}, util.InternalError()
+	}
+	if !b {
+		return usermd.UserInfo{}, util.UnauthorizedError()
+	}
+	if user.IsProhibited || user.Password != util.EncryptUserPassword(reqDTO.Password) {
+		return usermd.UserInfo{}, util.UnauthorizedError()
+	}
+	return user.ToUserInfo(), nil
+}
@@ -0,0 +1,150 @@
+package webhooksrv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+	"zgit/pkg/webhook"
+	"zgit/standalone/modules/model/webhookmd"
+	"zgit/util"
+)
+
+const (
+	// 最多尝试次数
+	maxAttempts = 3
+	// 首次重试间隔 之后每次翻倍
+	retryBackoff = 2 * time.Second
+	// 响应体最多记录64k
+	maxResponseBodySize = 64 * 1024
+	// 最大并发投递数
+	maxConcurrentDeliveries = 32
+)
+
+var (
+	// httpClient 不走代理 不跟随重定向 连接时校验解析后的地址 防止请求内网服务
+	httpClient = &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: 5 * time.Second,
+				Control: checkDialAddr,
+			}).DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConnsPerHost: 4,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return errRedirectNotAllowed
+		},
+	}
+	errRedirectNotAllowed = errors.New("redirect is not allowed")
+	deliverSem            = make(chan struct{}, maxConcurrentDeliveries)
+)
+
+// submitDelivery 记录投递请求并异步投递
+func submitDelivery(ctx context.Context, hook webhookmd.WebhookDTO, event string, body []byte) error {
+	header := map[string]string{
+		"Content-Type":          hook.Cfg.ContentType,
+		webhook.EventHeader:     event,
+		webhook.SignatureHeader: webhook.Sign(hook.Cfg.Secret, body),
+	}
+	if header[webhook.SignatureHeader] == "" {
+		delete(header, webhook.SignatureHeader)
+	}
+	delivery, err := webhookmd.InsertDelivery(ctx, webhookmd.InsertDeliveryReqDTO{
+		HookId:        hook.HookId,
+		Event:         event,
+		RequestUrl:    hook.Cfg.Url,
+		RequestHeader: header,
+		RequestBody:   string(body),
+	})
+	if err != nil {
+		return err
+	}
+	go deliver(delivery)
+	return nil
+}
+
+// deliver 投递 失败后按指数退避重试
+func deliver(delivery webhookmd.DeliveryDTO) {
+	deliverSem <- struct{}{}
+	defer func() {
+		<-deliverSem
+	}()
+	var result webhookmd.UpdateDeliveryResultReqDTO
+	backoff := retryBackoff
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result = doDeliver(delivery)
+		result.Attempts = attempt
+		if result.IsSuccess {
+			break
+		}
+		if attempt < maxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	result.DeliveryId = delivery.DeliveryId
+	ctx, closer := mysqlstore.Context(context.Background())
+	defer closer.Close()
+	if _, err := webhookmd.UpdateDeliveryResult(ctx, result); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+	}
+}
+
+func doDeliver(delivery webhookmd.DeliveryDTO) webhookmd.UpdateDeliveryResultReqDTO {
+	var ret webhookmd.UpdateDeliveryResultReqDTO
+	req, err := http.NewRequest(http.MethodPost, delivery.RequestUrl, bytes.NewReader([]byte(delivery.RequestBody)))
+	if err != nil {
+		ret.ErrMsg = err.Error()
+		return ret
+	}
+	for k, v := range delivery.RequestHeader {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set(webhook.DeliveryHeader, delivery.DeliveryId)
+	req.Header.Set("User-Agent", "zgit-webhook")
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		ret.Latency = time.Since(start).Milliseconds()
+		ret.ErrMsg = err.Error()
+		return ret
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodySize))
+	ret.Latency = time.Since(start).Milliseconds()
+	if err != nil {
+		ret.ErrMsg = err.Error()
+	}
+	respHeader, _ := json.Marshal(resp.Header)
+	ret.ResponseStatus = resp.StatusCode
+	ret.ResponseHeader = string(respHeader)
+	ret.ResponseBody = string(respBody)
+	ret.IsSuccess = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !ret.IsSuccess && ret.ErrMsg == "" {
+		ret.ErrMsg = fmt.Sprintf("unexpected status code: %d", resp.StatusCode)
+	}
+	return ret
+}
+
+// checkDialAddr 校验实际连接的地址 域名解析结果同样会经过这里
+func checkDialAddr(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || util.IsInternalIp(ip) {
+		return fmt.Errorf("address %s is not allowed", address)
+	}
+	return nil
+}
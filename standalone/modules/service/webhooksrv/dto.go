@@ -0,0 +1,191 @@
+package webhooksrv
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"time"
+	"zgit/pkg/webhook"
+	"zgit/standalone/modules/model/projectmd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/standalone/modules/model/webhookmd"
+	"zgit/util"
+)
+
+type InsertWebhookReqDTO struct {
+	ProjectId string
+	// 为空表示项目级别webhook
+	RepoId   string
+	Cfg      webhookmd.WebhookCfg
+	Operator usermd.UserInfo
+}
+
+func (r *InsertWebhookReqDTO) IsValid() error {
+	if !projectmd.IsProjectIdValid(r.ProjectId) {
+		return util.InvalidArgsError()
+	}
+	if r.RepoId != "" && !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return validateCfg(r.Cfg)
+}
+
+type UpdateWebhookReqDTO struct {
+	HookId string
+	// secret为空时保留原secret
+	Cfg      webhookmd.WebhookCfg
+	Operator usermd.UserInfo
+}
+
+func (r *UpdateWebhookReqDTO) IsValid() error {
+	if !webhookmd.IsHookIdValid(r.HookId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return validateCfg(r.Cfg)
+}
+
+type DeleteWebhookReqDTO struct {
+	HookId   string
+	Operator usermd.UserInfo
+}
+
+func (r *DeleteWebhookReqDTO) IsValid() error {
+	if !webhookmd.IsHookIdValid(r.HookId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type ListWebhookReqDTO struct {
+	ProjectId string
+	RepoId    string
+	Operator  usermd.UserInfo
+}
+
+func (r *ListWebhookReqDTO) IsValid() error {
+	if !projectmd.IsProjectIdValid(r.ProjectId) {
+		return util.InvalidArgsError()
+	}
+	if r.RepoId != "" && !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type ListDeliveryReqDTO struct {
+	HookId   string
+	Offset   int64
+	Limit    int
+	Operator usermd.UserInfo
+}
+
+func (r *ListDeliveryReqDTO) IsValid() error {
+	if !webhookmd.IsHookIdValid(r.HookId) {
+		return util.InvalidArgsError()
+	}
+	if r.Offset < 0 {
+		return util.InvalidArgsError()
+	}
+	if r.Limit <= 0 || r.Limit > 1000 {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type RedeliverReqDTO struct {
+	DeliveryId string
+	Operator   usermd.UserInfo
+}
+
+func (r *RedeliverReqDTO) IsValid() error {
+	if !webhookmd.IsDeliveryIdValid(r.DeliveryId) {
+		return util.InvalidArgsError()
+	}
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+func validateCfg(cfg webhookmd.WebhookCfg) error {
+	if len(cfg.Url) == 0 || len(cfg.Url) > 1024 {
+		return util.InvalidArgsError()
+	}
+	parsed, err := url.Parse(cfg.Url)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return util.InvalidArgsError()
+	}
+	// 地址为ip时提前拒绝内网地址 域名在投递时校验
+	hostname := parsed.Hostname()
+	if hostname == "" || strings.EqualFold(hostname, "localhost") {
+		return util.InvalidArgsError()
+	}
+	if ip := net.ParseIP(hostname); ip != nil && util.IsInternalIp(ip) {
+		return util.InvalidArgsError()
+	}
+	if len(cfg.Secret) > 255 {
+		return util.InvalidArgsError()
+	}
+	if !webhook.IsContentTypeValid(cfg.ContentType) {
+		return util.InvalidArgsError()
+	}
+	if len(cfg.Events) == 0 || len(cfg.Events) > 10 {
+		return util.InvalidArgsError()
+	}
+	for _, event := range cfg.Events {
+		if !event.IsValid() {
+			return util.InvalidArgsError()
+		}
+	}
+	return nil
+}
+
+type WebhookDTO struct {
+	HookId      string
+	ProjectId   string
+	RepoId      string
+	Url         string
+	ContentType string
+	Events      []webhook.Event
+	IsActive    bool
+	// 不返回secret 只返回是否设置
+	HasSecret bool
+}
+
+type DeliveryDTO struct {
+	DeliveryId     string
+	HookId         string
+	Event          string
+	RequestUrl     string
+	RequestHeader  map[string]string
+	RequestBody    string
+	ResponseStatus int
+	ResponseHeader string
+	ResponseBody   string
+	ErrMsg         string
+	Latency        int64
+	Attempts       int
+	IsSuccess      bool
+	Created        time.Time
+}
+
+type ListDeliveryRespDTO struct {
+	DeliveryList []DeliveryDTO
+	Cursor       int64
+}
@@ -0,0 +1,225 @@
+package webhooksrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"zgit/standalone/modules/model/projectmd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/standalone/modules/model/webhookmd"
+	"zgit/util"
+)
+
+// InsertWebhook 新增webhook
+func InsertWebhook(ctx context.Context, reqDTO InsertWebhookReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	if err := checkManagePerm(ctx, reqDTO.ProjectId, reqDTO.RepoId, reqDTO.Operator); err != nil {
+		return err
+	}
+	err := webhookmd.InsertWebhook(ctx, webhookmd.InsertWebhookReqDTO{
+		ProjectId: reqDTO.ProjectId,
+		RepoId:    reqDTO.RepoId,
+		Cfg:       reqDTO.Cfg,
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
+// UpdateWebhook 编辑webhook
+func UpdateWebhook(ctx context.Context, reqDTO UpdateWebhookReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	hook, err := checkManagePermByHookId(ctx, reqDTO.HookId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	cfg := reqDTO.Cfg
+	if cfg.Secret == "" {
+		cfg.Secret = hook.Cfg.Secret
+	}
+	if _, err = webhookmd.UpdateWebhookCfg(ctx, hook.HookId, cfg); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
+// DeleteWebhook 删除webhook及其投递记录
+func DeleteWebhook(ctx context.Context, reqDTO DeleteWebhookReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	hook, err := checkManagePermByHookId(ctx, reqDTO.HookId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	err = mysqlstore.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := webhookmd.DeleteWebhook(ctx, hook.HookId); err != nil {
+			return err
+		}
+		return webhookmd.DeleteDeliveryByHookId(ctx, hook.HookId)
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
+// ListWebhook 展示webhook列表
+func ListWebhook(ctx context.Context, reqDTO ListWebhookReqDTO) ([]WebhookDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return nil, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	if err := checkManagePerm(ctx, reqDTO.ProjectId, reqDTO.RepoId, reqDTO.Operator); err != nil {
+		return nil, err
+	}
+	hooks, err := webhookmd.ListWebhook(ctx, reqDTO.ProjectId, reqDTO.RepoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return nil, util.InternalError()
+	}
+	ret, _ := listutil.Map(hooks, func(h webhookmd.WebhookDTO) (WebhookDTO, error) {
+		return WebhookDTO{
+			HookId:      h.HookId,
+			ProjectId:   h.ProjectId,
+			RepoId:      h.RepoId,
+			Url:         h.Cfg.Url,
+			ContentType: h.Cfg.ContentType,
+			Events:      h.Cfg.Events,
+			IsActive:    h.Cfg.IsActive,
+			HasSecret:   h.Cfg.Secret != "",
+		}, nil
+	})
+	return ret, nil
+}
+
+// ListDelivery 展示投递记录
+func ListDelivery(ctx context.Context, reqDTO ListDeliveryReqDTO) (ListDeliveryRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return ListDeliveryRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	if _, err := checkManagePermByHookId(ctx, reqDTO.HookId, reqDTO.Operator); err != nil {
+		return ListDeliveryRespDTO{}, err
+	}
+	deliveries, err := webhookmd.ListDelivery(ctx, webhookmd.ListDeliveryReqDTO{
+		HookId: reqDTO.HookId,
+		Offset: reqDTO.Offset,
+		Limit:  reqDTO.Limit,
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return ListDeliveryRespDTO{}, util.InternalError()
+	}
+	ret := ListDeliveryRespDTO{}
+	ret.DeliveryList, _ = listutil.Map(deliveries, func(d webhookmd.DeliveryDTO) (DeliveryDTO, error) {
+		return DeliveryDTO{
+			DeliveryId:     d.DeliveryId,
+			HookId:         d.HookId,
+			Event:          d.Event,
+			RequestUrl:     d.RequestUrl,
+			RequestHeader:  d.RequestHeader,
+			RequestBody:    d.RequestBody,
+			ResponseStatus: d.ResponseStatus,
+			ResponseHeader: d.ResponseHeader,
+			ResponseBody:   d.ResponseBody,
+			ErrMsg:         d.ErrMsg,
+			Latency:        d.Latency,
+			Attempts:       d.Attempts,
+			IsSuccess:      d.IsSuccess,
+			Created:        d.Created,
+		}, nil
+	})
+	if len(deliveries) > 0 {
+		ret.Cursor = deliveries[len(deliveries)-1].Id
+	}
+	return ret, nil
+}
+
+// Redeliver 重新投递 使用原请求体和当前的webhook配置生成一条新的投递记录
+func Redeliver(ctx context.Context, reqDTO RedeliverReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	delivery, b, err := webhookmd.GetByDeliveryId(ctx, reqDTO.DeliveryId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if !b {
+		return util.InvalidArgsError()
+	}
+	hook, err := checkManagePermByHookId(ctx, delivery.HookId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	if err = submitDelivery(ctx, hook, delivery.Event, []byte(delivery.RequestBody)); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	return nil
+}
+
+func checkManagePermByHookId(ctx context.Context, hookId string, operator usermd.UserInfo) (webhookmd.WebhookDTO, error) {
+	hook, b, err := webhookmd.GetByHookId(ctx, hookId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return webhookmd.WebhookDTO{}, util.InternalError()
+	}
+	if !b {
+		return webhookmd.WebhookDTO{}, util.InvalidArgsError()
+	}
+	return hook, checkManagePerm(ctx, hook.ProjectId, hook.RepoId, operator)
+}
+
+// checkManagePerm 仓库级别需有管理保护分支权限 项目级别需是项目管理员
+func checkManagePerm(ctx context.Context, projectId, repoId string, operator usermd.UserInfo) error {
+	if repoId != "" {
+		repo, b, err := repomd.GetByRepoId(ctx, repoId)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			return util.InternalError()
+		}
+		if !b || repo.ProjectId != projectId {
+			return util.InvalidArgsError()
+		}
+	}
+	detail, b, err := projectmd.GetProjectUserPermDetail(ctx, projectId, operator.Account)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if !b {
+		return util.UnauthorizedError()
+	}
+	if repoId == "" {
+		if !detail.IsAdmin {
+			return util.UnauthorizedError()
+		}
+		return nil
+	}
+	if !detail.PermDetail.GetRepoPerm(repoId).CanHandleProtectedBranch {
+		return util.UnauthorizedError()
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package webhooksrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"strings"
+	"time"
+	"zgit/pkg/git"
+	"zgit/pkg/hook"
+	"zgit/pkg/webhook"
+	"zgit/standalone/modules/model/pullrequestmd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/webhookmd"
+)
+
+// TriggerPushEvent 推送后触发分支和标签事件
+func TriggerPushEvent(ctx context.Context, opts hook.Opts) {
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, hooks, err := getRepoAndHooks(ctx, opts.RepoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+	now := time.Now()
+	for _, info := range opts.RevInfoList {
+		var event webhook.Event
+		if strings.HasPrefix(info.RefName, git.BranchPrefix) {
+			event = webhook.PushEvent
+		} else if strings.HasPrefix(info.RefName, git.TagPrefix) {
+			event = webhook.TagEvent
+		} else {
+			continue
+		}
+		triggerEvent(ctx, hooks, event, webhook.PushPayload{
+			Repo:      repo2Info(repo),
+			Ref:       info.RefName,
+			Before:    info.OldCommitId,
+			After:     info.NewCommitId,
+			IsCreated: info.OldCommitId == git.ZeroCommitId,
+			IsDeleted: info.NewCommitId == git.ZeroCommitId,
+			Pusher:    opts.PusherId,
+			EventTime: now,
+		})
+	}
+}
+
+// TriggerPullRequestEvent 合并请求创建、合并、关闭事件
+func TriggerPullRequestEvent(ctx context.Context, event webhook.Event, pr pullrequestmd.PullRequest, operator string) {
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, hooks, err := getRepoAndHooks(ctx, pr.RepoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return
+	}
+	triggerEvent(ctx, hooks, event, webhook.PullRequestPayload{
+		Repo:        repo2Info(repo),
+		PullRequest: pr2Info(pr),
+		Operator:    operator,
+		EventTime:   time.Now(),
+	})
+}
+
+// TriggerReviewEvent 合并请求评审事件
+func TriggerReviewEvent(ctx context.Context, pr pullrequestmd.PullRequest, reviewer, reviewMsg string, status pullrequestmd.ReviewStatus) {
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, hooks, err := getRepoAndHooks(ctx, pr.RepoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return
+	}
+	triggerEvent(ctx, hooks, webhook.ReviewEvent, webhook.ReviewPayload{
+		Repo:        repo2Info(repo),
+		PullRequest: pr2Info(pr),
+		Reviewer:    reviewer,
+		ReviewMsg:   reviewMsg,
+		Agree:       status == pullrequestmd.AgreeMergeStatus,
+		EventTime:   time.Now(),
+	})
+}
+
+func triggerEvent(ctx context.Context, hooks []webhookmd.WebhookDTO, event webhook.Event, payload any) {
+	for _, h := range hooks {
+		if !h.Cfg.IsActive || !h.Cfg.HasEvent(event) {
+			continue
+		}
+		body, err := webhook.BuildRequestBody(h.Cfg.ContentType, payload)
+		if err != nil {
+			logger.Logger.WithContext(ctx).Error(err)
+			continue
+		}
+		if err = submitDelivery(ctx, h, string(event), body); err != nil {
+			logger.Logger.WithContext(ctx).Errorf("submit webhook: %s delivery err: %v", h.HookId, err)
+		}
+	}
+}
+
+func getRepoAndHooks(ctx context.Context, repoId string) (repomd.Repo, []webhookmd.WebhookDTO, error) {
+	repo, b, err := repomd.GetByRepoId(ctx, repoId)
+	if err != nil || !b {
+		return repomd.Repo{}, nil, err
+	}
+	hooks, err := webhookmd.ListRepoEffectiveWebhook(ctx, repo.ProjectId, repo.RepoId)
+	return repo, hooks, err
+}
+
+func repo2Info(repo repomd.Repo) webhook.RepoInfo {
+	return webhook.RepoInfo{
+		RepoId:    repo.RepoId,
+		Name:      repo.Name,
+		ProjectId: repo.ProjectId,
+	}
+}
+
+func pr2Info(pr pullrequestmd.PullRequest) webhook.PullRequestInfo {
+	return webhook.PullRequestInfo{
+		PrId:           pr.PrId,
+		Title:          pr.Title,
+		Target:         pr.Target,
+		TargetCommitId: pr.TargetCommitId,
+		Head:           pr.Head,
+		HeadCommitId:   pr.HeadCommitId,
+		CreateBy:       pr.CreateBy,
+	}
+}
@@ -0,0 +1,206 @@
+package wikisrv
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"zgit/pkg/git"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/util"
+)
+
+const (
+	// 单个页面最大大小
+	maxPageSize = 1024 * 1024
+)
+
+type PageDTO struct {
+	Name string
+	Size int64
+}
+
+type ListPagesReqDTO struct {
+	RepoId   string
+	Operator usermd.UserInfo
+}
+
+func (r *ListPagesReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type GetPageReqDTO struct {
+	RepoId   string
+	PageName string
+	// 历史版本 为空则是最新版本
+	CommitId string
+	Operator usermd.UserInfo
+}
+
+func (r *GetPageReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !validatePageName(r.PageName) {
+		return util.InvalidArgsError()
+	}
+	if r.CommitId != "" && !validateCommitId(r.CommitId) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type PageDetailDTO struct {
+	Name    string
+	Content string
+	// 渲染后的html
+	Html       string
+	LastCommit CommitDTO
+}
+
+type SavePageReqDTO struct {
+	RepoId   string
+	PageName string
+	Content  string
+	Message  string
+	Operator usermd.UserInfo
+}
+
+func (r *SavePageReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !validatePageName(r.PageName) {
+		return util.InvalidArgsError()
+	}
+	if len(r.Content) > maxPageSize || len(r.Message) > 1024 {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type DeletePageReqDTO struct {
+	RepoId   string
+	PageName string
+	Message  string
+	Operator usermd.UserInfo
+}
+
+func (r *DeletePageReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !validatePageName(r.PageName) {
+		return util.InvalidArgsError()
+	}
+	if len(r.Message) > 1024 {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type PageHistoryReqDTO struct {
+	RepoId   string
+	PageName string
+	Cursor   int64
+	Limit    int
+	Operator usermd.UserInfo
+}
+
+func (r *PageHistoryReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !validatePageName(r.PageName) {
+		return util.InvalidArgsError()
+	}
+	if r.Cursor < 0 || r.Limit <= 0 || r.Limit > 100 {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type PageHistoryRespDTO struct {
+	Commits []CommitDTO
+	Cursor  int64
+	HasMore bool
+}
+
+type DiffPageReqDTO struct {
+	RepoId      string
+	PageName    string
+	OldCommitId string
+	NewCommitId string
+	Operator    usermd.UserInfo
+}
+
+func (r *DiffPageReqDTO) IsValid() error {
+	if !util.ValidateOperator(r.Operator) {
+		return util.InvalidArgsError()
+	}
+	if !repomd.IsRepoIdValid(r.RepoId) {
+		return util.InvalidArgsError()
+	}
+	if !validatePageName(r.PageName) {
+		return util.InvalidArgsError()
+	}
+	if !validateCommitId(r.OldCommitId) || !validateCommitId(r.NewCommitId) {
+		return util.InvalidArgsError()
+	}
+	return nil
+}
+
+type DiffPageRespDTO struct {
+	FileType git.DiffFileType
+	Lines    []DiffLineDTO
+}
+
+type DiffLineDTO struct {
+	Index   int
+	LeftNo  int
+	Prefix  string
+	RightNo int
+	Text    string
+}
+
+type CommitDTO struct {
+	Author        git.User
+	Committer     git.User
+	AuthoredDate  time.Time
+	CommittedDate time.Time
+	CommitMsg     string
+	CommitId      string
+	ShortId       string
+}
+
+// validatePageName 页面名称即文件名 不允许目录
+func validatePageName(name string) bool {
+	if len(name) == 0 || len(name) > 128 || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "-") {
+		return false
+	}
+	return strings.IndexFunc(name, func(r rune) bool {
+		return r == '/' || r == '\\' || unicode.IsControl(r)
+	}) < 0
+}
+
+func validateCommitId(commitId string) bool {
+	return len(commitId) > 0 && len(commitId) <= 64 && !strings.HasPrefix(commitId, "-")
+}
@@ -0,0 +1,338 @@
+package wikisrv
+
+import (
+	"context"
+	"github.com/LeeZXin/zsf-utils/listutil"
+	"github.com/LeeZXin/zsf/logger"
+	"github.com/LeeZXin/zsf/xorm/mysqlstore"
+	"path/filepath"
+	"strings"
+	"sync"
+	"zgit/pkg/apicode"
+	"zgit/pkg/git"
+	"zgit/pkg/i18n"
+	"zgit/pkg/markdown"
+	"zgit/pkg/perm"
+	"zgit/setting"
+	"zgit/standalone/modules/model/projectmd"
+	"zgit/standalone/modules/model/repomd"
+	"zgit/standalone/modules/model/usermd"
+	"zgit/standalone/modules/service/quotasrv"
+	"zgit/util"
+)
+
+var (
+	// 防止并发创建同一个wiki
+	initMu sync.Mutex
+)
+
+// ListPages 列出wiki页面 wiki未创建返回空
+func ListPages(ctx context.Context, reqDTO ListPagesReqDTO) ([]PageDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return nil, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return nil, err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanAccess {
+		return nil, util.UnauthorizedError()
+	}
+	absPath := GetWikiAbsPath(repo)
+	exist, err := util.IsExist(absPath)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return nil, util.InternalError()
+	}
+	if !exist {
+		return []PageDTO{}, nil
+	}
+	blobs, err := git.ListWikiPages(ctx, absPath)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return nil, util.InternalError()
+	}
+	return listutil.Map(blobs, func(t git.TreeBlob) (PageDTO, error) {
+		return PageDTO{
+			Name: strings.TrimSuffix(t.Path, git.WikiPageExt),
+			Size: t.Size,
+		}, nil
+	})
+}
+
+// GetPage 获取页面内容和渲染后的html
+func GetPage(ctx context.Context, reqDTO GetPageReqDTO) (PageDetailDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return PageDetailDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return PageDetailDTO{}, err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanAccess {
+		return PageDetailDTO{}, util.UnauthorizedError()
+	}
+	absPath := GetWikiAbsPath(repo)
+	ref := git.BranchPrefix + git.WikiDefaultBranch
+	if reqDTO.CommitId != "" {
+		ref = reqDTO.CommitId
+	}
+	fileName := reqDTO.PageName + git.WikiPageExt
+	if !git.CheckExists(ctx, absPath, ref+":"+fileName) {
+		return PageDetailDTO{}, util.NewBizErr(apicode.InvalidArgsCode, i18n.WikiPageNotFound)
+	}
+	_, content, _, err := git.GetFileContentByRef(ctx, absPath, ref, fileName)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return PageDetailDTO{}, util.InternalError()
+	}
+	commit, err := git.GetFileLastCommit(ctx, absPath, ref, fileName)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return PageDetailDTO{}, util.InternalError()
+	}
+	return PageDetailDTO{
+		Name:       reqDTO.PageName,
+		Content:    content,
+		Html:       markdown.RenderWiki(content),
+		LastCommit: commit2Dto(commit),
+	}, nil
+}
+
+// SavePage 新建或更新页面 wiki不存在则先创建
+func SavePage(ctx context.Context, reqDTO SavePageReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanPush {
+		return util.UnauthorizedError()
+	}
+	// 页面内容即新增大小 wiki大小计入仓库大小
+	if err = quotasrv.CheckGitQuota(ctx, repo, int64(len(reqDTO.Content))); err != nil {
+		return err
+	}
+	if err = EnsureWiki(ctx, repo); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	message := reqDTO.Message
+	if message == "" {
+		message = "Update " + reqDTO.PageName
+	}
+	absPath := GetWikiAbsPath(repo)
+	if err = git.UpdateWikiPage(ctx, absPath, reqDTO.PageName+git.WikiPageExt, reqDTO.Content, message, git.User{
+		Account: reqDTO.Operator.Account,
+		Email:   reqDTO.Operator.Email,
+	}); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if err = updateWikiSize(ctx, repo); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+	}
+	return nil
+}
+
+// DeletePage 删除页面
+func DeletePage(ctx context.Context, reqDTO DeletePageReqDTO) error {
+	if err := reqDTO.IsValid(); err != nil {
+		return err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanPush {
+		return util.UnauthorizedError()
+	}
+	absPath := GetWikiAbsPath(repo)
+	fileName := reqDTO.PageName + git.WikiPageExt
+	if !git.CheckExists(ctx, absPath, git.BranchPrefix+git.WikiDefaultBranch+":"+fileName) {
+		return util.NewBizErr(apicode.InvalidArgsCode, i18n.WikiPageNotFound)
+	}
+	message := reqDTO.Message
+	if message == "" {
+		message = "Delete " + reqDTO.PageName
+	}
+	if err = git.DeleteWikiPage(ctx, absPath, fileName, message, git.User{
+		Account: reqDTO.Operator.Account,
+		Email:   reqDTO.Operator.Email,
+	}); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return util.InternalError()
+	}
+	if err = updateWikiSize(ctx, repo); err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+	}
+	return nil
+}
+
+// PageHistory 页面修改历史 按偏移量翻页
+func PageHistory(ctx context.Context, reqDTO PageHistoryReqDTO) (PageHistoryRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return PageHistoryRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return PageHistoryRespDTO{}, err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanAccess {
+		return PageHistoryRespDTO{}, util.UnauthorizedError()
+	}
+	absPath := GetWikiAbsPath(repo)
+	ret := PageHistoryRespDTO{
+		Commits: []CommitDTO{},
+		Cursor:  reqDTO.Cursor,
+	}
+	if !git.CheckExists(ctx, absPath, git.BranchPrefix+git.WikiDefaultBranch) {
+		return ret, nil
+	}
+	commitList, err := git.ListCommits(ctx, absPath, git.ListCommitsOpts{
+		Ref:  git.BranchPrefix + git.WikiDefaultBranch,
+		Path: reqDTO.PageName + git.WikiPageExt,
+		Skip: int(reqDTO.Cursor),
+		// 多查一条判断是否还有下一页
+		Limit: reqDTO.Limit + 1,
+	})
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return PageHistoryRespDTO{}, util.InternalError()
+	}
+	if len(commitList) > reqDTO.Limit {
+		commitList = commitList[:reqDTO.Limit]
+		ret.HasMore = true
+	}
+	ret.Commits, _ = listutil.Map(commitList, func(t git.Commit) (CommitDTO, error) {
+		return commit2Dto(t), nil
+	})
+	ret.Cursor = reqDTO.Cursor + int64(len(commitList))
+	return ret, nil
+}
+
+// DiffPage 页面两个版本之间的差异
+func DiffPage(ctx context.Context, reqDTO DiffPageReqDTO) (DiffPageRespDTO, error) {
+	if err := reqDTO.IsValid(); err != nil {
+		return DiffPageRespDTO{}, err
+	}
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, p, err := getPerm(ctx, reqDTO.RepoId, reqDTO.Operator)
+	if err != nil {
+		return DiffPageRespDTO{}, err
+	}
+	if !p.GetRepoPerm(repo.RepoId).CanAccess {
+		return DiffPageRespDTO{}, util.UnauthorizedError()
+	}
+	absPath := GetWikiAbsPath(repo)
+	if !git.CheckRefIsCommit(ctx, absPath, reqDTO.OldCommitId) || !git.CheckRefIsCommit(ctx, absPath, reqDTO.NewCommitId) {
+		return DiffPageRespDTO{}, util.InvalidArgsError()
+	}
+	// GetDiffFileDetail比较的是head到target的差异
+	d, err := git.GetDiffFileDetail(ctx, absPath, reqDTO.NewCommitId, reqDTO.OldCommitId, reqDTO.PageName+git.WikiPageExt)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return DiffPageRespDTO{}, util.InternalError()
+	}
+	ret := DiffPageRespDTO{
+		FileType: d.FileType,
+	}
+	ret.Lines, _ = listutil.Map(d.Lines, func(t git.DiffLine) (DiffLineDTO, error) {
+		return DiffLineDTO{
+			Index:   t.Index,
+			LeftNo:  t.LeftNo,
+			Prefix:  t.Prefix,
+			RightNo: t.RightNo,
+			Text:    t.Text,
+		}, nil
+	})
+	return ret, nil
+}
+
+// GetWikiAbsPath wiki仓库绝对路径
+func GetWikiAbsPath(repo repomd.Repo) string {
+	return filepath.Join(setting.RepoDir(), repo.GetWikiPath())
+}
+
+// EnsureWiki wiki不存在则创建
+func EnsureWiki(ctx context.Context, repo repomd.Repo) error {
+	initMu.Lock()
+	defer initMu.Unlock()
+	absPath := GetWikiAbsPath(repo)
+	exist, err := util.IsExist(absPath)
+	if err != nil || exist {
+		return err
+	}
+	logger.Logger.WithContext(ctx).Infof("init wiki: %s", absPath)
+	return git.InitWiki(ctx, git.Wiki{
+		Owner: git.User{
+			Account: repo.Author,
+		},
+		Name: repo.Name,
+		Path: absPath,
+	})
+}
+
+// UpdateWikiSize 推送wiki后重新计算大小
+func UpdateWikiSize(ctx context.Context, repoId string) error {
+	ctx, closer := mysqlstore.Context(ctx)
+	defer closer.Close()
+	repo, b, err := repomd.GetByRepoId(ctx, repoId)
+	if err != nil || !b {
+		return err
+	}
+	return updateWikiSize(ctx, repo)
+}
+
+func updateWikiSize(ctx context.Context, repo repomd.Repo) error {
+	size, err := git.GetRepoSize(GetWikiAbsPath(repo))
+	if err != nil {
+		return err
+	}
+	return repomd.UpdateTotalAndWikiSize(ctx, repo.RepoId, repo.GitSize+repo.LfsSize+size, size)
+}
+
+func commit2Dto(commit git.Commit) CommitDTO {
+	return CommitDTO{
+		Author:        commit.Author,
+		Committer:     commit.Committer,
+		AuthoredDate:  commit.AuthorSigTime,
+		CommittedDate: commit.CommitSigTime,
+		CommitMsg:     commit.CommitMsg,
+		CommitId:      commit.Id,
+		ShortId:       util.LongCommitId2ShortId(commit.Id),
+	}
+}
+
+func getPerm(ctx context.Context, repoId string, operator usermd.UserInfo) (repomd.Repo, perm.Detail, error) {
+	repo, b, err := repomd.GetByRepoId(ctx, repoId)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return repomd.Repo{}, perm.Detail{}, util.InternalError()
+	}
+	if !b {
+		return repomd.Repo{}, perm.Detail{}, util.InvalidArgsError()
+	}
+	p, b, err := projectmd.GetProjectUserPermDetail(ctx, repo.ProjectId, operator.Account)
+	if err != nil {
+		logger.Logger.WithContext(ctx).Error(err)
+		return repo, perm.Detail{}, util.InternalError()
+	}
+	if !b {
+		return repo, perm.Detail{}, util.UnauthorizedError()
+	}
+	return repo, p.PermDetail, nil
+}
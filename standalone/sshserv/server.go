@@ -2,11 +2,14 @@ package sshserv
 
 import (
 	"context"
+	"errors"
 	"github.com/LeeZXin/zsf/logger"
 	"github.com/gliderlabs/ssh"
 	gossh "golang.org/x/crypto/ssh"
 	"net"
 	"strconv"
+	"strings"
+	"zgit/pkg/i18n"
 	"zgit/setting"
 	"zgit/standalone/modules/model/usermd"
 	"zgit/standalone/modules/service/gitsrv"
@@ -19,12 +22,25 @@ type ContextKey string
 
 const (
 	ZgitUserAccount = ContextKey("zgit-user-account")
+	ZgitFromProxy   = ContextKey("zgit-from-proxy")
+)
+
+const (
+	// ProxyNameEnv 代理名称
+	ProxyNameEnv = "ZGIT_PROXY_NAME"
+	// ProxyLoginUserEnv 代理转发的登录用户
+	ProxyLoginUserEnv = "ZGIT_LOGIN_USER"
 )
 
 func publicKeyHandler(ctx ssh.Context, key ssh.PublicKey) bool {
 	if ctx.User() != setting.GitUser() {
 		return false
 	}
+	// 来自代理的连接 登录用户从环境变量获取
+	if isProxyKey(key) {
+		ctx.SetValue(ZgitFromProxy, true)
+		return true
+	}
 	pubKey, b, err := sshkeysrv.SearchByKeyContent(ctx, key)
 	if err != nil {
 		logger.Logger.Error(err)
@@ -48,7 +64,17 @@ func publicKeyHandler(ctx ssh.Context, key ssh.PublicKey) bool {
 func sessionHandler(session ssh.Session) {
 	ctx, cancel := context.WithCancel(session.Context())
 	defer cancel()
-	userInfo := session.Context().Value(ZgitUserAccount).(usermd.UserInfo)
+	var userInfo usermd.UserInfo
+	if fromProxy, _ := session.Context().Value(ZgitFromProxy).(bool); fromProxy {
+		var err error
+		userInfo, err = getProxyLoginUser(ctx, session)
+		if err != nil {
+			util.ExitWithErrMsg(session, err.Error())
+			return
+		}
+	} else {
+		userInfo = session.Context().Value(ZgitUserAccount).(usermd.UserInfo)
+	}
 	if err := gitsrv.HandleSshCommand(ctx, session.RawCommand(), userInfo, session, gitsrv.HandleGitCommand); err != nil {
 		util.ExitWithErrMsg(session, err.Error())
 	} else {
@@ -56,6 +82,39 @@ func sessionHandler(session ssh.Session) {
 	}
 }
 
+func getProxyLoginUser(ctx context.Context, session ssh.Session) (usermd.UserInfo, error) {
+	var (
+		account                  string
+		accountNum, proxyNameNum int
+	)
+	for _, env := range session.Environ() {
+		k, v, f := strings.Cut(env, "=")
+		if !f {
+			continue
+		}
+		switch k {
+		case ProxyLoginUserEnv:
+			account = v
+			accountNum++
+		case ProxyNameEnv:
+			proxyNameNum++
+		}
+	}
+	// 出现多个代理变量说明有客户端伪造的值 拒绝
+	if account == "" || accountNum > 1 || proxyNameNum > 1 {
+		return usermd.UserInfo{}, errors.New(i18n.GetByKey(i18n.SystemUnauthorized))
+	}
+	userInfo, b, err := usersrv.GetUserInfoByAccount(ctx, account)
+	if err != nil {
+		logger.Logger.Error(err)
+		return usermd.UserInfo{}, errors.New(i18n.GetByKey(i18n.SystemInternalError))
+	}
+	if !b || userInfo.IsProhibited {
+		return usermd.UserInfo{}, errors.New(i18n.GetByKey(i18n.SystemUnauthorized))
+	}
+	return userInfo, nil
+}
+
 type server struct {
 	*ssh.Server
 }
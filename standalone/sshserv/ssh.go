@@ -1,9 +1,11 @@
 package sshserv
 
 import (
+	"bytes"
 	"github.com/LeeZXin/zsf/logger"
 	"github.com/LeeZXin/zsf/property/static"
 	"github.com/LeeZXin/zsf/zsf"
+	gossh "golang.org/x/crypto/ssh"
 	"os"
 	"path/filepath"
 	"zgit/setting"
@@ -16,6 +18,10 @@ var (
 	serverMACs         = []string{"hmac-sha2-256-etm@openssh.com", "hmac-sha2-256", "hmac-sha1"}
 	serverHostKey      = "ssh/zgit.rsa"
 	serverPort         = static.GetInt("ssh.server.port")
+	// 信任的代理公钥 格式同openssh authorized_keys
+	proxyAuthorizedKeysFile = static.GetString("ssh.server.proxyAuthorizedKeys")
+
+	proxyKeys []gossh.PublicKey
 )
 
 func InitSsh() {
@@ -38,5 +44,37 @@ func InitSsh() {
 			logger.Logger.Panicf("gen host key pair failed %s: %v", serverHostKey, err)
 		}
 	}
+	loadProxyKeys()
 	zsf.RegisterApplicationLifeCycle(newServer())
 }
+
+func loadProxyKeys() {
+	if proxyAuthorizedKeysFile == "" {
+		return
+	}
+	if !filepath.IsAbs(proxyAuthorizedKeysFile) {
+		proxyAuthorizedKeysFile = filepath.Join(setting.DataDir(), proxyAuthorizedKeysFile)
+	}
+	content, err := os.ReadFile(proxyAuthorizedKeysFile)
+	if err != nil {
+		logger.Logger.Panicf("read proxy authorized keys failed %s: %v", proxyAuthorizedKeysFile, err)
+	}
+	for len(content) > 0 {
+		var key gossh.PublicKey
+		key, _, _, content, err = gossh.ParseAuthorizedKey(content)
+		if err != nil {
+			break
+		}
+		proxyKeys = append(proxyKeys, key)
+	}
+	logger.Logger.Infof("load %d proxy keys", len(proxyKeys))
+}
+
+func isProxyKey(key gossh.PublicKey) bool {
+	for _, k := range proxyKeys {
+		if k.Type() == key.Type() && bytes.Equal(k.Marshal(), key.Marshal()) {
+			return true
+		}
+	}
+	return false
+}
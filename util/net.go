@@ -0,0 +1,47 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// IsInternalIp 回环、内网、链路本地和未指定地址
+func IsInternalIp(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified()
+}
+
+// CheckRemoteHost 解析远端地址的域名 任一解析结果为内网地址则拒绝 防止服务端请求内网服务
+func CheckRemoteHost(ctx context.Context, remoteUrl string) error {
+	parsed, err := url.Parse(remoteUrl)
+	if err != nil {
+		return err
+	}
+	hostname := parsed.Hostname()
+	if hostname == "" {
+		return fmt.Errorf("remote url: %s has no host", remoteUrl)
+	}
+	if ip := net.ParseIP(hostname); ip != nil {
+		if IsInternalIp(ip) {
+			return fmt.Errorf("remote host: %s is not allowed", hostname)
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, hostname)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if IsInternalIp(addr.IP) {
+			return fmt.Errorf("remote host: %s resolves to internal address: %s", hostname, addr.IP)
+		}
+	}
+	return nil
+}
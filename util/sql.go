@@ -0,0 +1,10 @@
+package util
+
+import "strings"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike 转义like查询中的通配符 需配合 escape '\' 使用
+func EscapeLike(str string) string {
+	return likeEscaper.Replace(str)
+}